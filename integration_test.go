@@ -79,7 +79,7 @@ func TestIntegrationBasicWorkflow(t *testing.T) {
 	}
 	
 	// Test 4: Verify symlinks were created
-	for _, link := range []string{"AGENTS.md", "OPENCODE.md"} {
+	for _, link := range []string{"AGENTS.md", "GEMINI.md"} {
 		info, err := os.Lstat(link)
 		if err != nil {
 			t.Errorf("Link %s was not created: %v", link, err)
@@ -237,4 +237,1538 @@ func TestIntegrationForceFlag(t *testing.T) {
 	if info.Mode()&os.ModeSymlink == 0 {
 		t.Error("File was not replaced with symlink")
 	}
-}
\ No newline at end of file
+}
+
+func TestIntegrationInitGlobal(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	// Fake home with Claude (with instructions) and Codex (without) installed
+	home := t.TempDir()
+	workDir := t.TempDir()
+	env := append(os.Environ(), "HOME="+home)
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	os.MkdirAll(filepath.Join(home, ".codex"), 0755)
+	if err := os.WriteFile(filepath.Join(home, ".claude", "CLAUDE.md"), []byte("personal"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sync without any config must not write a placeholder config
+	cmd := exec.Command(binaryPath, "sync")
+	cmd.Dir = workDir
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err == nil {
+		t.Errorf("Sync without config should fail: %s", output)
+	}
+	globalConfig := filepath.Join(home, ".config", "agentlink", "config.yaml")
+	if _, err := os.Stat(globalConfig); err == nil {
+		t.Fatal("Sync created a global config")
+	}
+
+	cmd = exec.Command(binaryPath, "init", "--global", "--force")
+	cmd.Dir = workDir
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Init --global failed: %v\nOutput: %s", err, output)
+	}
+
+	data, err := os.ReadFile(globalConfig)
+	if err != nil {
+		t.Fatalf("Global config was not created: %v", err)
+	}
+	if !strings.Contains(string(data), "source: ~/.claude/CLAUDE.md") || !strings.Contains(string(data), "- ~/.codex/AGENTS.md") {
+		t.Errorf("Unexpected global config:\n%s", data)
+	}
+
+	cmd = exec.Command(binaryPath, "sync")
+	cmd.Dir = workDir
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Sync failed: %v\nOutput: %s", err, output)
+	}
+
+	content, err := os.ReadFile(filepath.Join(home, ".codex", "AGENTS.md"))
+	if err != nil || string(content) != "personal" {
+		t.Errorf("Codex link does not resolve to the personal source: %v", err)
+	}
+}
+
+func TestIntegrationBootstrapSource(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	env := append(os.Environ(), "HOME="+t.TempDir())
+	config := "source: CLAUDE.md\nlinks:\n  - AGENTS.md\n  - GEMINI.md\n"
+	if err := os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// An existing link file is adopted as the source content
+	if err := os.WriteFile(filepath.Join(workDir, "GEMINI.md"), []byte("from gemini"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binaryPath, "sync")
+	cmd.Dir = workDir
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Sync failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(string(output), "[bootstrap]") {
+		t.Errorf("Sync output doesn't report the bootstrap: %s", output)
+	}
+
+	for _, name := range []string{"CLAUDE.md", "AGENTS.md", "GEMINI.md"} {
+		content, err := os.ReadFile(filepath.Join(workDir, name))
+		if err != nil || string(content) != "from gemini" {
+			t.Errorf("%s does not have the adopted content: %q (%v)", name, content, err)
+		}
+	}
+}
+
+func TestIntegrationSourceSet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	config := "# our instructions\nsource: CLAUDE.md\nlinks:\n  - AGENTS.md\n  - GEMINI.md\n"
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(config), 0644)
+	os.WriteFile(filepath.Join(workDir, "CLAUDE.md"), []byte("instructions"), 0644)
+
+	for _, args := range [][]string{{"sync"}, {"source", "set", "AGENTS.md"}, {"check"}} {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s failed: %v\nOutput: %s", strings.Join(args, " "), err, output)
+		}
+	}
+
+	info, err := os.Lstat(filepath.Join(workDir, "AGENTS.md"))
+	if err != nil || !info.Mode().IsRegular() {
+		t.Errorf("AGENTS.md should be the real file now: %v", err)
+	}
+	for _, name := range []string{"CLAUDE.md", "GEMINI.md"} {
+		target, err := os.Readlink(filepath.Join(workDir, name))
+		if err != nil || target != "AGENTS.md" {
+			t.Errorf("%s should link to AGENTS.md, got %q (%v)", name, target, err)
+		}
+	}
+
+	data, _ := os.ReadFile(filepath.Join(workDir, ".agentlink.yaml"))
+	expected := "# our instructions\nsource: AGENTS.md\nlinks:\n  - CLAUDE.md\n  - GEMINI.md\n"
+	if string(data) != expected {
+		t.Errorf("Unexpected config after source set:\n%s", data)
+	}
+}
+
+func TestIntegrationConfigCommands(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	config := "# team config\nsource: CLAUDE.md # edit this one\nlinks:\n  - AGENTS.md\n"
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(config), 0644)
+	os.WriteFile(filepath.Join(workDir, "CLAUDE.md"), []byte("instructions"), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	// Typos are added with a warning and a suggestion
+	output, err := run("config", "add-link", "GEMIN.md")
+	if err != nil || !strings.Contains(output, "not read by any known tool") || !strings.Contains(output, "Did you mean GEMINI.md?") {
+		t.Errorf("add-link should warn about unknown paths with a suggestion: %v\n%s", err, output)
+	}
+	if output, err := run("config", "remove-link", "GEMIN.md"); err != nil {
+		t.Fatalf("remove-link failed: %v\nOutput: %s", err, output)
+	}
+
+	if output, err := run("config", "add-link", "GEMINI.md", "--sync"); err != nil {
+		t.Fatalf("add-link failed: %v\nOutput: %s", err, output)
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "GEMINI.md")); err != nil {
+		t.Errorf("add-link --sync did not create the link: %v", err)
+	}
+
+	if output, err := run("config", "add-link", "--local", ".github/copilot-instructions.md"); err != nil {
+		t.Fatalf("add-link --local failed: %v\nOutput: %s", err, output)
+	}
+
+	if output, err := run("config", "remove-link", "AGENTS.md"); err != nil {
+		t.Fatalf("remove-link failed: %v\nOutput: %s", err, output)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(workDir, ".agentlink.yaml"))
+	expected := "# team config\nsource: CLAUDE.md # edit this one\nlinks:\n  - GEMINI.md\n"
+	if string(data) != expected {
+		t.Errorf("Unexpected project config:\n%s", data)
+	}
+
+	output, err = run("config", "get", "--local", "links")
+	if err != nil || strings.TrimSpace(output) != ".github/copilot-instructions.md" {
+		t.Errorf("Unexpected local links: %q (%v)", output, err)
+	}
+}
+
+func TestIntegrationConfigMigrate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	configPath := filepath.Join(workDir, ".agentlink.yaml")
+	config := "source: [AGENTS.md, CLAUDE.md] # candidates\nlinks:\n  - CLAUDE.md\n  - GEMINI.md\n"
+	os.WriteFile(configPath, []byte(config), 0644)
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("instructions"), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	// Old configs keep working
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync with an unversioned config failed: %v\nOutput: %s", err, output)
+	}
+
+	output, err := run("config", "migrate", "--dry-run")
+	if err != nil {
+		t.Fatalf("migrate --dry-run failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "+version: 1") || !strings.Contains(output, "-  - CLAUDE.md") {
+		t.Errorf("migrate --dry-run should show a diff: %s", output)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != config {
+		t.Errorf("migrate --dry-run changed the config:\n%s", data)
+	}
+
+	if output, err := run("config", "migrate", "--force"); err != nil {
+		t.Fatalf("migrate failed: %v\nOutput: %s", err, output)
+	}
+	expected := "version: 1\nsource: [AGENTS.md, CLAUDE.md] # candidates\nlinks:\n  - GEMINI.md\n"
+	if data, _ := os.ReadFile(configPath); string(data) != expected {
+		t.Errorf("Unexpected migrated config:\n%s", data)
+	}
+
+	output, err = run("config", "migrate")
+	if err != nil || !strings.Contains(output, "already at version 1") {
+		t.Errorf("migrate of a current config should be a no-op: %s (%v)", output, err)
+	}
+}
+
+func TestIntegrationConfigFormats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workDir, ".config"), 0755)
+	os.WriteFile(filepath.Join(workDir, ".config", "agentlink.toml"), []byte("source = \"CLAUDE.md\"\nlinks = [\"AGENTS.md\"]\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, "CLAUDE.md"), []byte("instructions"), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	// Paths in .config/ are relative to the project root
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if target, err := os.Readlink(filepath.Join(workDir, "AGENTS.md")); err != nil || target != "CLAUDE.md" {
+		t.Errorf("Expected AGENTS.md -> CLAUDE.md in the project root, got %q (%v)", target, err)
+	}
+
+	// A second config takes precedence and check warns about it
+	os.WriteFile(filepath.Join(workDir, ".agentlink.json"), []byte(`{"source": "CLAUDE.md", "links": ["AGENTS.md"]}`), 0644)
+	output, err := run("check")
+	if err != nil || !strings.Contains(output, "Found 2 project config files") {
+		t.Errorf("check should warn about several configs: %s (%v)", output, err)
+	}
+	os.Remove(filepath.Join(workDir, ".agentlink.json"))
+
+	if output, err := run("config", "convert", "yaml"); err != nil {
+		t.Fatalf("convert failed: %v\nOutput: %s", err, output)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, ".config", "agentlink.toml")); !os.IsNotExist(err) {
+		t.Errorf("convert should remove the old file")
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, ".config", "agentlink.yaml"))
+	if err != nil || !strings.Contains(string(data), "source: CLAUDE.md\nlinks:\n  - AGENTS.md\n") {
+		t.Errorf("Unexpected converted config: %s (%v)", data, err)
+	}
+}
+
+func TestIntegrationExtends(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	tmpDir := t.TempDir()
+	workDir := filepath.Join(tmpDir, "repo")
+	os.MkdirAll(workDir, 0755)
+	os.WriteFile(filepath.Join(tmpDir, "team.yaml"), []byte("source: AGENTS.md\nlinks:\n  - CLAUDE.md\n  - GEMINI.md\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte("extends: ../team.yaml\nlinks:\n  - !remove GEMINI.md\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("instructions"), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "CLAUDE.md")); err != nil {
+		t.Errorf("Inherited link was not created in the repo: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "GEMINI.md")); !os.IsNotExist(err) {
+		t.Errorf("Removed link was created")
+	}
+
+	output, err := run("config", "show", "--resolved")
+	if err != nil {
+		t.Fatalf("config show --resolved failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "source: AGENTS.md\nlinks:\n  - CLAUDE.md\n") {
+		t.Errorf("Unexpected resolved config:\n%s", output)
+	}
+}
+
+func TestIntegrationSyncRecursive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	files := map[string]string{
+		".agentlink.yaml":              "source: AGENTS.md\nlinks:\n  - CLAUDE.md\n  - packages/web/GEMINI.md\n",
+		"AGENTS.md":                    "root",
+		".gitignore":                   "vendor/\n",
+		"packages/api/.agentlink.yaml": "source: AGENTS.md\nlinks:\n  - CLAUDE.md\n",
+		"packages/api/AGENTS.md":       "api",
+		"packages/web/.agentlink.yaml": "source: AGENTS.md\nlinks:\n  - GEMINI.md\n",
+		"packages/web/AGENTS.md":       "web",
+		"vendor/lib/.agentlink.yaml":   "source: AGENTS.md\nlinks:\n  - CLAUDE.md\n",
+		// A source that is a directory is not counted as synced
+		"packages/docs/.agentlink.yaml": "source: notes\nlinks:\n  - CLAUDE.md\n",
+		"packages/docs/notes/.keep":     "",
+	}
+	for name, content := range files {
+		path := filepath.Join(workDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	cmd := exec.Command(binaryPath, "sync", "--recursive")
+	cmd.Dir = workDir
+	out, err := cmd.CombinedOutput()
+	output := string(out)
+	if err == nil {
+		t.Errorf("sync --recursive should fail on a conflict\nOutput: %s", output)
+	}
+	if !strings.Contains(output, "Conflict: packages/web/GEMINI.md is a link in .agentlink.yaml and a link in packages/web/.agentlink.yaml") {
+		t.Errorf("Conflict was not reported\nOutput: %s", output)
+	}
+	if !strings.Contains(output, "Synced 3 of 4 configs: 2 created, 0 fixed, 0 unchanged, 0 skipped, 1 conflicts, 1 errors") {
+		t.Errorf("Unexpected summary\nOutput: %s", output)
+	}
+
+	for dir, source := range map[string]string{".": "root", "packages/api": "api"} {
+		content, err := os.ReadFile(filepath.Join(workDir, dir, "CLAUDE.md"))
+		if err != nil || string(content) != source {
+			t.Errorf("%s/CLAUDE.md = %q, %v; expected the %s source", dir, content, err, source)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "packages", "web", "GEMINI.md")); !os.IsNotExist(err) {
+		t.Errorf("Conflicting link was created")
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "vendor", "lib", "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Errorf("Config in an ignored directory was synced")
+	}
+}
+
+func TestIntegrationConditionalLinks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("instructions"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+  - path: GEMINI.md
+    when:
+      env: AGENTLINK_TEST_GEMINI
+groups:
+  codex:
+    when:
+      binary: agentlink-no-such-binary
+    links:
+      - .codex/AGENTS.md
+`), 0644)
+
+	run := func(env []string, args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), env...)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run(nil, "sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	for _, name := range []string{"GEMINI.md", ".codex"} {
+		if _, err := os.Lstat(filepath.Join(workDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was created although its condition is false", name)
+		}
+	}
+
+	output, err := run(nil, "check")
+	if err != nil {
+		t.Errorf("check should pass with skipped links: %v\nOutput: %s", err, output)
+	}
+	if strings.Count(output, "skipped (condition false)") != 2 {
+		t.Errorf("check should report the skipped links\nOutput: %s", output)
+	}
+
+	if output, err := run([]string{"AGENTLINK_TEST_GEMINI=1"}, "sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "GEMINI.md")); err != nil {
+		t.Errorf("GEMINI.md was not created once its condition held: %v", err)
+	}
+}
+
+func TestIntegrationProfiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workDir, "work"), 0755)
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("personal"), 0644)
+	os.WriteFile(filepath.Join(workDir, "work", "INSTRUCTIONS.md"), []byte("work"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+profiles:
+  work:
+    source: work/INSTRUCTIONS.md
+    links:
+      - GEMINI.md
+`), 0644)
+
+	run := func(env []string, args ...string) string {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), env...)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v failed: %v\nOutput: %s", args, err, output)
+		}
+		return string(output)
+	}
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(workDir, name))
+		if err != nil {
+			return ""
+		}
+		return string(content)
+	}
+
+	run(nil, "sync")
+	if read("CLAUDE.md") != "personal" {
+		t.Errorf("CLAUDE.md should link to the default source")
+	}
+
+	// Switching profiles retargets existing links without --force
+	run([]string{"AGENTLINK_PROFILE=work"}, "sync")
+	if read("CLAUDE.md") != "work" || read("GEMINI.md") != "work" {
+		t.Errorf("Links should point to the work source")
+	}
+	if output := run([]string{"AGENTLINK_PROFILE=work"}, "check"); !strings.Contains(output, "Profile: work") {
+		t.Errorf("check should show the active profile\nOutput: %s", output)
+	}
+
+	// Switching back removes the links only the profile has
+	run(nil, "sync", "--profile", "")
+	if read("CLAUDE.md") != "personal" {
+		t.Errorf("CLAUDE.md should link to the default source again")
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "GEMINI.md")); !os.IsNotExist(err) {
+		t.Errorf("GEMINI.md should be removed without the work profile")
+	}
+
+	// Only the profiles define a source, as for a work and a personal setup
+	os.WriteFile(filepath.Join(workDir, "PERSONAL.md"), []byte("personal only"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+links:
+  - CLAUDE.md
+profiles:
+  work:
+    source: work/INSTRUCTIONS.md
+  personal:
+    source: PERSONAL.md
+`), 0644)
+	run(nil, "sync", "--profile", "work", "--force")
+	if output := run(nil, "sync", "--profile", "personal"); strings.Contains(output, "[warning]") {
+		t.Errorf("Switching between profiles should not warn\nOutput: %s", output)
+	}
+	if read("CLAUDE.md") != "personal only" {
+		t.Errorf("CLAUDE.md should link to the personal source")
+	}
+}
+
+func TestIntegrationLinkOptions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("instructions"), 0644)
+	// A regular file where the optional link needs a directory
+	os.WriteFile(filepath.Join(workDir, "blocker"), []byte("file"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - path: skel/CLAUDE.md
+    style: absolute
+  - path: blocker/GEMINI.md
+    optional: true
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	output, err := run("sync")
+	if err != nil {
+		t.Fatalf("sync should not fail on an optional link: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Skipped optional link") {
+		t.Errorf("The optional link failure should be reported\nOutput: %s", output)
+	}
+
+	target, err := os.Readlink(filepath.Join(workDir, "skel", "CLAUDE.md"))
+	if err != nil || !filepath.IsAbs(target) {
+		t.Errorf("skel/CLAUDE.md should have an absolute target, got %q (%v)", target, err)
+	}
+
+	output, err = run("check")
+	if err != nil {
+		t.Errorf("check should not fail on an optional link: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "(optional)") {
+		t.Errorf("check should mark the optional link\nOutput: %s", output)
+	}
+}
+
+func TestIntegrationCopyMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	sourcePath := filepath.Join(workDir, "AGENTS.md")
+	copyPath := filepath.Join(workDir, ".windsurfrules")
+	hardlinkPath := filepath.Join(workDir, "CLAUDE.md")
+	os.WriteFile(sourcePath, []byte("instructions\n"), 0600)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - path: .windsurfrules
+    mode: copy
+    header: true
+  - path: CLAUDE.md
+    mode: hardlink
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	info, err := os.Lstat(copyPath)
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf(".windsurfrules should be a regular file (%v)", err)
+	}
+	content, _ := os.ReadFile(copyPath)
+	if !strings.HasPrefix(string(content), "# Generated by agentlink from AGENTS.md") || !strings.HasSuffix(string(content), "instructions\n") {
+		t.Errorf("Unexpected copy content: %q", content)
+	}
+
+	// A changed source leaves the untouched copy out of date, sync refreshes it
+	os.WriteFile(sourcePath, []byte("new instructions\n"), 0644)
+	if output, err := run("check"); err == nil || !strings.Contains(output, "out of date") {
+		t.Errorf("check should report the copy out of date: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("sync"); err != nil || !strings.Contains(output, "Refreshed copy") {
+		t.Fatalf("sync should refresh the copy: %v\nOutput: %s", err, output)
+	}
+
+	// An edited copy is left alone
+	content, _ = os.ReadFile(copyPath)
+	os.WriteFile(copyPath, append(content, []byte("local edit\n")...), 0644)
+	if output, err := run("sync"); err == nil || !strings.Contains(output, "agentlink absorb") {
+		t.Errorf("sync should refuse to overwrite an edited copy: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("check"); err == nil || !strings.Contains(output, "edited since the last sync") {
+		t.Errorf("check should report the edited copy: %v\nOutput: %s", err, output)
+	}
+	if output, _ := run("diff"); !strings.Contains(output, "+local edit") {
+		t.Errorf("diff should show the edit\nOutput: %s", output)
+	}
+
+	// Absorbing the edit writes it to the source and the copy is in sync again
+	if output, err := run("absorb", ".windsurfrules", "--force"); err != nil {
+		t.Fatalf("absorb failed: %v\nOutput: %s", err, output)
+	}
+	source, _ := os.ReadFile(sourcePath)
+	if string(source) != "new instructions\nlocal edit\n" {
+		t.Errorf("Unexpected source after absorb: %q", source)
+	}
+	// The source is written in place, keeping its mode and its hardlinks
+	sourceInfo, _ := os.Stat(sourcePath)
+	hardlinkInfo, _ := os.Stat(hardlinkPath)
+	if sourceInfo == nil || sourceInfo.Mode().Perm() != 0600 {
+		t.Errorf("absorb should keep the source's mode 0600")
+	}
+	if sourceInfo == nil || hardlinkInfo == nil || !os.SameFile(sourceInfo, hardlinkInfo) {
+		t.Errorf("absorb should keep CLAUDE.md a hardlink to the source")
+	}
+	if output, err := run("check"); err != nil {
+		t.Errorf("check should pass after absorb: %v\nOutput: %s", err, output)
+	}
+
+	if output, err := run("clean"); err != nil {
+		t.Fatalf("clean failed: %v\nOutput: %s", err, output)
+	}
+	if _, err := os.Lstat(copyPath); !os.IsNotExist(err) {
+		t.Errorf("clean should remove the copy")
+	}
+}
+
+func TestIntegrationHardlinkMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	sourcePath := filepath.Join(workDir, "AGENTS.md")
+	linkPath := filepath.Join(workDir, "CLAUDE.md")
+	os.WriteFile(sourcePath, []byte("instructions\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - path: CLAUDE.md
+    mode: hardlink
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	sourceInfo, _ := os.Stat(sourcePath)
+	linkInfo, err := os.Lstat(linkPath)
+	if err != nil || !os.SameFile(sourceInfo, linkInfo) {
+		t.Fatalf("CLAUDE.md should be a hardlink to AGENTS.md (%v)", err)
+	}
+
+	// Saving the source as a new file breaks the hardlink
+	os.Remove(sourcePath)
+	os.WriteFile(sourcePath, []byte("new instructions\n"), 0644)
+	if output, err := run("check"); err == nil || !strings.Contains(output, "no longer a hardlink") {
+		t.Errorf("check should report the broken hardlink: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("sync"); err != nil || !strings.Contains(output, "Relinked") {
+		t.Fatalf("sync should relink: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(linkPath); string(content) != "new instructions\n" {
+		t.Errorf("Unexpected link content after relink: %q", content)
+	}
+
+	// Saving the link as a new file with edits is absorbed into the source
+	os.Remove(linkPath)
+	os.WriteFile(linkPath, []byte("edited instructions\n"), 0644)
+	if output, err := run("sync"); err == nil || !strings.Contains(output, "agentlink absorb") {
+		t.Errorf("sync should point to absorb for the edited hardlink: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("absorb", "--force", "CLAUDE.md"); err != nil || !strings.Contains(output, "Relinked") {
+		t.Fatalf("absorb should relink: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(sourcePath); string(content) != "edited instructions\n" {
+		t.Errorf("absorb should write the edits to the source, got %q", content)
+	}
+	sourceInfo, _ = os.Stat(sourcePath)
+	if linkInfo, err := os.Lstat(linkPath); err != nil || !os.SameFile(sourceInfo, linkInfo) {
+		t.Errorf("CLAUDE.md should be a hardlink to AGENTS.md again (%v)", err)
+	}
+}
+
+func TestIntegrationCompose(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	teamPath := filepath.Join(workDir, "team.md")
+	outputPath := filepath.Join(workDir, ".agentlink", "AGENTS.md")
+	os.WriteFile(teamPath, []byte("team rules\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("repo rules\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  combined:
+    type: compose
+    fragments:
+      - team.md
+      - "@source"
+    output: .agentlink/AGENTS.md
+    links:
+      - GEMINI.md
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	content, err := os.ReadFile(filepath.Join(workDir, "GEMINI.md"))
+	if err != nil || !strings.HasSuffix(string(content), "team rules\n\nrepo rules\n") || !strings.Contains(string(content), "Do not edit") {
+		t.Errorf("GEMINI.md should show the composed file (%v): %q", err, content)
+	}
+	if target, _ := os.Readlink(filepath.Join(workDir, "GEMINI.md")); target != filepath.Join(".agentlink", "AGENTS.md") {
+		t.Errorf("GEMINI.md should link to the output, got %q", target)
+	}
+
+	// A changed fragment makes the output stale until the next sync
+	os.WriteFile(teamPath, []byte("new team rules\n"), 0644)
+	if output, err := run("check"); err == nil || !strings.Contains(output, "out of date") {
+		t.Errorf("check should report the stale output: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("sync"); err != nil || !strings.Contains(output, "Regenerated") {
+		t.Fatalf("sync should regenerate the output: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(outputPath); !strings.Contains(string(content), "new team rules") {
+		t.Errorf("Output was not regenerated: %q", content)
+	}
+	if output, err := run("check"); err != nil {
+		t.Errorf("check should pass after sync: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestIntegrationRenderMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte(`# Rules
+Shared.
+<!-- agentlink:only claude -->
+@docs/claude.md
+<!-- agentlink:end -->
+<!-- agentlink:except copilot -->
+Not for Copilot.
+<!-- agentlink:end -->
+`), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - path: CLAUDE.md
+    mode: render
+  - path: .github/copilot-instructions.md
+    mode: render
+  - GEMINI.md
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	expected := map[string]string{
+		"CLAUDE.md":                       "# Rules\nShared.\n@docs/claude.md\nNot for Copilot.\n",
+		".github/copilot-instructions.md": "# Rules\nShared.\n",
+	}
+	for path, want := range expected {
+		if content, _ := os.ReadFile(filepath.Join(workDir, path)); string(content) != want {
+			t.Errorf("%s = %q, expected %q", path, content, want)
+		}
+	}
+	if _, err := os.Readlink(filepath.Join(workDir, "GEMINI.md")); err != nil {
+		t.Errorf("GEMINI.md should stay a plain symlink: %v", err)
+	}
+
+	if output, err := run("check"); err != nil || !strings.Contains(output, "claude copy of") {
+		t.Errorf("check should pass and show the rendered copies: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestIntegrationAdapterModes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	sourcePath := filepath.Join(workDir, "AGENTS.md")
+	os.WriteFile(sourcePath, []byte("Shared.\n<!-- agentlink:only copilot -->\nCopilot hint.\n<!-- agentlink:end -->\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - path: .cursor/rules/project.mdc
+    mode: cursor
+    frontmatter:
+      description: Project rules
+      globs: "**/*.go"
+  - path: .github/instructions/project.instructions.md
+    mode: copilot
+    frontmatter:
+      applyTo: "**/*.go"
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	expected := map[string]string{
+		".cursor/rules/project.mdc":                    "---\ndescription: Project rules\nglobs: '**/*.go'\nalwaysApply: false\n---\n\nShared.\n",
+		".github/instructions/project.instructions.md": "---\napplyTo: '**/*.go'\n---\n\nShared.\nCopilot hint.\n",
+	}
+	for path, want := range expected {
+		if content, _ := os.ReadFile(filepath.Join(workDir, path)); string(content) != want {
+			t.Errorf("%s = %q, expected %q", path, content, want)
+		}
+	}
+
+	// A source change shows up in check until sync brings the body up to date
+	os.WriteFile(sourcePath, []byte("Changed.\n"), 0644)
+	if output, err := run("check"); err == nil || !strings.Contains(output, "out of date") {
+		t.Errorf("check should report the stale body: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("check"); err != nil || !strings.Contains(output, "Cursor rule from") {
+		t.Errorf("check should pass after sync: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestIntegrationConfigureMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	sourcePath := filepath.Join(workDir, "AGENTS.md")
+	settingsPath := filepath.Join(workDir, ".gemini", "settings.json")
+	os.WriteFile(sourcePath, []byte("# Instructions\n"), 0644)
+	os.MkdirAll(filepath.Dir(settingsPath), 0755)
+	os.WriteFile(settingsPath, []byte("{\n  \"theme\": \"Dracula\"\n}\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - path: GEMINI.md
+    mode: configure
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	// A symlink from an earlier sync is replaced by the setting
+	os.Symlink("AGENTS.md", filepath.Join(workDir, "GEMINI.md"))
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	expected := "{\n  \"theme\": \"Dracula\",\n  \"contextFileName\": \"AGENTS.md\"\n}\n"
+	if content, _ := os.ReadFile(settingsPath); string(content) != expected {
+		t.Errorf("settings = %q, expected %q", content, expected)
+	}
+	if _, err := os.Lstat(filepath.Join(workDir, "GEMINI.md")); !os.IsNotExist(err) {
+		t.Errorf("GEMINI.md symlink should be removed")
+	}
+	if output, err := run("check"); err != nil || !strings.Contains(output, "gemini reads AGENTS.md") {
+		t.Errorf("check should pass after sync: %v\nOutput: %s", err, output)
+	}
+
+	// Clean takes the setting out again, keeping the rest of the file
+	if output, err := run("clean"); err != nil {
+		t.Fatalf("clean failed: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(settingsPath); string(content) != "{\n  \"theme\": \"Dracula\"\n}\n" {
+		t.Errorf("clean should restore the settings, got %q", content)
+	}
+	if output, err := run("check"); err == nil || !strings.Contains(output, "not configured") {
+		t.Errorf("check should report the missing setting: %v\nOutput: %s", err, output)
+	}
+
+	// The Codex config is shared, a name two projects add stays until
+	// neither uses it
+	codexHome := t.TempDir()
+	projects := []string{t.TempDir(), t.TempDir()}
+	runIn := func(dir string, args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir, "CODEX_HOME="+codexHome)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+	for i, dir := range projects {
+		os.WriteFile(filepath.Join(dir, "CLAUDE.md"), []byte("# Instructions\n"), 0644)
+		os.WriteFile(filepath.Join(dir, ".agentlink.yaml"), []byte(`version: 1
+source: CLAUDE.md
+links:
+  - path: AGENTS.md
+    mode: configure
+    tool: codex
+`), 0644)
+		output, err := runIn(dir, "sync")
+		if err != nil {
+			t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+		}
+		if i == 0 && !strings.Contains(output, "config.toml is global") {
+			t.Errorf("sync should say the Codex config is global\nOutput: %s", output)
+		}
+	}
+	codexConfig := filepath.Join(codexHome, "config.toml")
+	if output, err := runIn(projects[1], "clean"); err != nil {
+		t.Fatalf("clean failed: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(codexConfig); !strings.Contains(string(content), `"CLAUDE.md"`) {
+		t.Errorf("clean should keep a name another project uses:\n%s", content)
+	}
+	if output, err := runIn(projects[0], "check"); err != nil {
+		t.Errorf("check should pass in the other project: %v\nOutput: %s", err, output)
+	}
+	if output, err := runIn(projects[0], "clean"); err != nil {
+		t.Fatalf("clean failed: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(codexConfig); strings.Contains(string(content), `"CLAUDE.md"`) {
+		t.Errorf("clean in the last project should remove the name:\n%s", content)
+	}
+}
+
+func TestIntegrationAgentsGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	agentsDir := filepath.Join(workDir, ".claude", "agents")
+	os.MkdirAll(agentsDir, 0755)
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("# Instructions\n"), 0644)
+	os.WriteFile(filepath.Join(agentsDir, "reviewer.md"), []byte("---\nname: reviewer\ndescription: Reviews code\ncolor: blue\n---\n\nReview the diff.\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  subagents:
+    type: agents
+    dir: .claude/agents
+    tools: [opencode]
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	output, err := run("sync")
+	if err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "no opencode equivalent for color: blue") {
+		t.Errorf("sync should report the unmapped field\nOutput: %s", output)
+	}
+	translated := filepath.Join(workDir, ".opencode", "agent", "reviewer.md")
+	expected := "---\ndescription: Reviews code\nmode: subagent\n---\n\nReview the diff.\n"
+	if content, _ := os.ReadFile(translated); string(content) != expected {
+		t.Errorf("translation = %q, expected %q", content, expected)
+	}
+	if output, err := run("check"); err != nil || !strings.Contains(output, "opencode translation of") {
+		t.Errorf("check should pass after sync: %v\nOutput: %s", err, output)
+	}
+
+	// Deleting the definition removes its translation on the next sync
+	os.Remove(filepath.Join(agentsDir, "reviewer.md"))
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if _, err := os.Lstat(translated); !os.IsNotExist(err) {
+		t.Errorf("translation of a deleted definition should be removed")
+	}
+}
+
+func TestIntegrationIgnoreGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("# Instructions\n"), 0644)
+	// The existing .aiderignore becomes the canonical file
+	os.WriteFile(filepath.Join(workDir, ".aiderignore"), []byte(".env\n!.env.example\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  ignore:
+    type: ignore
+    file: .aiignore
+    tools: [aider, gemini]
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(filepath.Join(workDir, ".aiignore")); string(content) != ".env\n!.env.example\n" {
+		t.Errorf(".aiignore = %q, expected the adopted .aiderignore", content)
+	}
+	for _, name := range []string{".aiderignore", ".geminiignore"} {
+		if target, err := os.Readlink(filepath.Join(workDir, name)); err != nil || target != ".aiignore" {
+			t.Errorf("%s -> %q (%v), expected a symlink to .aiignore", name, target, err)
+		}
+	}
+
+	if output, err := run("check"); err != nil {
+		t.Errorf("check should pass after sync: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestIntegrationMCPGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("# Instructions\n"), 0644)
+	// A server set up by hand is kept
+	os.WriteFile(filepath.Join(workDir, ".mcp.json"), []byte("{\n  \"mcpServers\": {\n    \"local\": {\n      \"command\": \"./server\"\n    }\n  }\n}\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  mcp:
+    type: mcp
+    tools: [claude, cursor]
+    servers:
+      files:
+        command: npx
+        args: ["-y", "@modelcontextprotocol/server-filesystem", "."]
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	claude, _ := os.ReadFile(filepath.Join(workDir, ".mcp.json"))
+	if !strings.Contains(string(claude), `"local"`) || !strings.Contains(string(claude), `"files"`) {
+		t.Errorf(".mcp.json should list local and files:\n%s", claude)
+	}
+	if cursor, err := os.ReadFile(filepath.Join(workDir, ".cursor", "mcp.json")); err != nil || !strings.Contains(string(cursor), `"npx"`) {
+		t.Errorf(".cursor/mcp.json should list files (%v):\n%s", err, cursor)
+	}
+	if output, err := run("check"); err != nil {
+		t.Errorf("check should pass after sync: %v\nOutput: %s", err, output)
+	}
+
+	// An entry edited by hand is drift
+	os.WriteFile(filepath.Join(workDir, ".cursor", "mcp.json"), []byte("{\n  \"mcpServers\": {\n    \"files\": {\n      \"command\": \"uvx\"\n    }\n  }\n}\n"), 0644)
+	output, err := run("check")
+	if err == nil || !strings.Contains(output, "edited since the last sync") {
+		t.Errorf("check should report the edited server: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("sync"); err == nil {
+		t.Errorf("sync should not overwrite the edited server\nOutput: %s", output)
+	}
+	if output, err := run("sync", "--force"); err != nil {
+		t.Fatalf("sync --force failed: %v\nOutput: %s", err, output)
+	}
+
+	// Clean takes out only the servers agentlink wrote
+	if output, err := run("clean"); err != nil {
+		t.Fatalf("clean failed: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(filepath.Join(workDir, ".mcp.json")); strings.Contains(string(content), `"files"`) || !strings.Contains(string(content), `"local"`) {
+		t.Errorf(".mcp.json should only list local after clean:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, ".cursor", "mcp.json")); !os.IsNotExist(err) {
+		t.Errorf(".cursor/mcp.json should be removed once empty")
+	}
+
+	// A server of the same name that agentlink never wrote is the user's
+	own := "{\n  \"mcpServers\": {\n    \"files\": {\n      \"command\": \"mine\"\n    }\n  }\n}\n"
+	os.WriteFile(filepath.Join(workDir, ".mcp.json"), []byte(own), 0644)
+	if output, err := run("sync", "--force"); err == nil || !strings.Contains(output, "not managed by agentlink") {
+		t.Errorf("sync should refuse to overwrite the user's server: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("check"); err == nil || !strings.Contains(output, "not managed by agentlink") {
+		t.Errorf("check should report the user's server: %v\nOutput: %s", err, output)
+	}
+	run("clean")
+	if content, _ := os.ReadFile(filepath.Join(workDir, ".mcp.json")); string(content) != own {
+		t.Errorf("the user's server should be left alone, got:\n%s", content)
+	}
+
+	// A server two projects write to the global Codex config stays until
+	// neither lists it
+	codexHome := t.TempDir()
+	projects := []string{t.TempDir(), t.TempDir()}
+	runIn := func(dir string, args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir, "CODEX_HOME="+codexHome)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+	for _, dir := range projects {
+		os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("# Instructions\n"), 0644)
+		os.WriteFile(filepath.Join(dir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  mcp:
+    type: mcp
+    tools: [codex]
+    servers:
+      files:
+        command: npx
+`), 0644)
+		if output, err := runIn(dir, "sync"); err != nil {
+			t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+		}
+	}
+	codexConfig := filepath.Join(codexHome, "config.toml")
+	if output, err := runIn(projects[1], "clean"); err != nil {
+		t.Fatalf("clean failed: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(codexConfig); !strings.Contains(string(content), "[mcp_servers.files]") {
+		t.Errorf("clean should keep a server another project writes:\n%s", content)
+	}
+	if output, err := runIn(projects[0], "check"); err != nil {
+		t.Errorf("check should pass in the other project: %v\nOutput: %s", err, output)
+	}
+	if output, err := runIn(projects[0], "clean"); err != nil {
+		t.Fatalf("clean failed: %v\nOutput: %s", err, output)
+	}
+	if content, _ := os.ReadFile(codexConfig); strings.Contains(string(content), "[mcp_servers.files]") {
+		t.Errorf("clean in the last project should remove the server:\n%s", content)
+	}
+}
+
+func TestIntegrationLint(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workDir, "docs"), 0755)
+	os.WriteFile(filepath.Join(workDir, "docs", "style.md"), []byte("# Style\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, "docs", "guide.md"), []byte("# Guide\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("# Instructions\n\nSee @docs/style.md and [the guide](docs/guide.md).\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+  - .github/copilot-instructions.md
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+
+	// Both references break from .github, and only there
+	output, err := run("lint")
+	if err == nil {
+		t.Errorf("lint should fail with broken references\nOutput: %s", output)
+	}
+	for _, expected := range []string{
+		".github/copilot-instructions.md (GitHub Copilot): 2 of 2 references broken",
+		"use the root-relative /docs/guide.md",
+		"write ../docs/style.md in an <!-- agentlink:only copilot --> section",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("lint output should contain %q\nOutput: %s", expected, output)
+		}
+	}
+	if strings.Contains(output, "CLAUDE.md (Claude Code): ") {
+		t.Errorf("CLAUDE.md should have no broken references\nOutput: %s", output)
+	}
+
+	// Following the suggestions fixes them
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte(`# Instructions
+
+See [the guide](/docs/guide.md).
+<!-- agentlink:except copilot -->
+@docs/style.md
+<!-- agentlink:end -->
+<!-- agentlink:only copilot -->
+@../docs/style.md
+<!-- agentlink:end -->
+`), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+  - path: .github/copilot-instructions.md
+    mode: render
+`), 0644)
+	if output, err := run("sync", "--force"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+	if output, err := run("lint"); err != nil {
+		t.Errorf("lint should pass after the fixes: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestIntegrationBudgets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(origDir, "agentlink")
+	if _, err := os.Stat(binaryPath); err != nil {
+		t.Fatalf("Binary not found at %s. Make sure to run 'go build -o agentlink ./cmd/agentlink' first", binaryPath)
+	}
+
+	workDir := t.TempDir()
+	stateDir := t.TempDir()
+	os.MkdirAll(filepath.Join(workDir, "docs"), 0755)
+	// Small on its own, but the import takes it over Claude Code's budget
+	os.WriteFile(filepath.Join(workDir, "docs", "style.md"), []byte(strings.Repeat("Keep functions small.\n", 200)), 0644)
+	os.WriteFile(filepath.Join(workDir, "AGENTS.md"), []byte("# Instructions\n\n@docs/style.md\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte(`version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+budgets:
+  claude:
+    tokens: 500
+`), 0644)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "XDG_STATE_HOME="+stateDir)
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+
+	if output, err := run("sync"); err != nil {
+		t.Fatalf("sync failed: %v\nOutput: %s", err, output)
+	}
+
+	expected := "over the 500 token budget of Claude Code"
+	output, err := run("lint")
+	if err == nil || !strings.Contains(output, "CLAUDE.md is about") || !strings.Contains(output, expected) {
+		t.Errorf("lint should fail on the budget: %v\nOutput: %s", err, output)
+	}
+	// Codex doesn't expand imports, so AGENTS.md is within its budget
+	if strings.Contains(output, "Codex CLI") && strings.Contains(output, "budget of Codex") {
+		t.Errorf("AGENTS.md should be within the budget of Codex\nOutput: %s", output)
+	}
+
+	// check only warns
+	output, err = run("check")
+	if err != nil || !strings.Contains(output, expected) {
+		t.Errorf("check should warn about the budget and pass: %v\nOutput: %s", err, output)
+	}
+
+	os.WriteFile(filepath.Join(workDir, ".agentlink.yaml"), []byte("version: 1\nsource: AGENTS.md\nlinks:\n  - CLAUDE.md\n"), 0644)
+	if output, err := run("lint"); err != nil {
+		t.Errorf("lint should pass within the default budgets: %v\nOutput: %s", err, output)
+	}
+}
@@ -0,0 +1,356 @@
+// Package agents translates subagent and command definitions between the
+// frontmatter dialects of AI tools. The body of a definition is kept as it
+// is, only the frontmatter is mapped.
+package agents
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition is a subagent or command in a form shared by all tools
+type Definition struct {
+	// Name is the file name without .md, which is how tools name it
+	Name string
+	// Description says what the definition is for
+	Description string
+	// Model is a provider/model id, e.g. anthropic/claude-sonnet-4-5, or
+	// empty for the tool's default
+	Model string
+	// Mode is an OpenCode agent mode: subagent, primary or all
+	Mode string
+	// Tools are the tools the definition may use, in OpenCode's names, or
+	// nil for all of them
+	Tools []string
+	// Body is everything after the frontmatter
+	Body []byte
+	// Unmapped lists the frontmatter fields that have no equivalent, as
+	// "key: value"
+	Unmapped []string
+}
+
+// claudeTools maps Claude Code's tool names to OpenCode's
+var claudeTools = map[string]string{
+	"Bash":      "bash",
+	"Edit":      "edit",
+	"MultiEdit": "edit",
+	"Write":     "write",
+	"Read":      "read",
+	"Grep":      "grep",
+	"Glob":      "glob",
+	"LS":        "list",
+	"WebFetch":  "webfetch",
+	"TodoWrite": "todowrite",
+}
+
+// openCodeTools are OpenCode's built-in tools, in the order they are written
+var openCodeTools = []string{"bash", "edit", "write", "read", "grep", "glob", "list", "webfetch", "todowrite", "todoread"}
+
+// Translate rewrites the definition content of kind, named name, from the
+// dialect of one tool to another's. It returns the new content and the
+// fields that could not be mapped.
+func Translate(content []byte, name, kind, from, to string) ([]byte, []string, error) {
+	def, err := Parse(content, name, kind, from)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, unmapped, err := def.Render(kind, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, append(def.Unmapped, unmapped...), nil
+}
+
+// Parse reads a definition of kind written for tool
+func Parse(content []byte, name, kind, tool string) (*Definition, error) {
+	def := &Definition{Name: name, Body: content}
+	if tool == "claude" && kind == tools.KindAgent {
+		// Claude Code's agents are all subagents
+		def.Mode = "subagent"
+	}
+
+	front, body, ok := splitFrontmatter(content)
+	if !ok {
+		return def, nil
+	}
+	def.Body = body
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(front, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse frontmatter of %s: %w", name, err)
+	}
+	if len(doc.Content) == 0 {
+		return def, nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("frontmatter of %s is not a mapping", name)
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i].Value, mapping.Content[i+1]
+		switch tool {
+		case "claude":
+			def.parseClaude(kind, key, value)
+		case "opencode":
+			def.parseOpenCode(kind, key, value)
+		default:
+			return nil, fmt.Errorf("%s definitions cannot be translated", tool)
+		}
+	}
+	return def, nil
+}
+
+// parseClaude reads one frontmatter field of a Claude Code subagent or
+// command
+func (d *Definition) parseClaude(kind, key string, value *yaml.Node) {
+	switch {
+	case key == "description":
+		d.Description = value.Value
+	case key == "name" && kind == tools.KindAgent:
+		// The file name names the definition everywhere
+		if value.Value != d.Name {
+			d.unmapped(key, value)
+		}
+	case key == "model":
+		switch {
+		case value.Value == "inherit":
+		case strings.HasPrefix(value.Value, "claude-"):
+			d.Model = "anthropic/" + value.Value
+		default:
+			// Aliases like sonnet follow Claude Code's latest model
+			d.unmapped(key, value)
+		}
+	case key == "tools" && kind == tools.KindAgent, key == "allowed-tools" && kind == tools.KindCommand:
+		d.Tools = []string{}
+		for _, name := range splitList(value) {
+			if tool, ok := claudeTools[name]; ok {
+				d.Tools = appendUnique(d.Tools, tool)
+			} else {
+				d.Unmapped = append(d.Unmapped, key+": "+name)
+			}
+		}
+	default:
+		d.unmapped(key, value)
+	}
+}
+
+// parseOpenCode reads one frontmatter field of an OpenCode agent or command
+func (d *Definition) parseOpenCode(kind, key string, value *yaml.Node) {
+	switch {
+	case key == "description":
+		d.Description = value.Value
+	case key == "model":
+		d.Model = value.Value
+	case key == "mode" && kind == tools.KindAgent:
+		d.Mode = value.Value
+	case key == "tools" && kind == tools.KindAgent && value.Kind == yaml.MappingNode:
+		d.Tools = d.openCodeToolList(value)
+	default:
+		d.unmapped(key, value)
+	}
+}
+
+// openCodeToolList turns OpenCode's map of tools switched on and off into
+// the list of built-in tools allowed, or nil if all are
+func (d *Definition) openCodeToolList(value *yaml.Node) []string {
+	enabled := make(map[string]bool)
+	for _, tool := range openCodeTools {
+		enabled[tool] = true
+	}
+	restricted := false
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		name, on := value.Content[i].Value, value.Content[i+1].Value == "true"
+		if name == "*" {
+			for tool := range enabled {
+				enabled[tool] = on
+			}
+			restricted = restricted || !on
+			continue
+		}
+		if _, ok := enabled[name]; !ok {
+			d.Unmapped = append(d.Unmapped, "tools: "+name)
+			continue
+		}
+		enabled[name] = on
+		restricted = restricted || !on
+	}
+	if !restricted {
+		return nil
+	}
+
+	list := []string{}
+	for _, tool := range openCodeTools {
+		if enabled[tool] {
+			list = append(list, tool)
+		}
+	}
+	return list
+}
+
+// Render writes the definition as kind for tool, returning the fields it
+// could not map
+func (d *Definition) Render(kind, tool string) ([]byte, []string, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value *yaml.Node) {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	var unmapped []string
+
+	switch tool {
+	case "claude":
+		if kind == tools.KindAgent {
+			add("name", scalar(d.Name))
+		}
+		if d.Description != "" {
+			add("description", scalar(d.Description))
+		}
+		if d.Tools != nil {
+			key := "tools"
+			if kind == tools.KindCommand {
+				key = "allowed-tools"
+			}
+			var names []string
+			for _, name := range d.Tools {
+				if claude := claudeTool(name); claude != "" {
+					names = append(names, claude)
+				} else {
+					unmapped = append(unmapped, "tools: "+name)
+				}
+			}
+			add(key, scalar(strings.Join(names, ", ")))
+		}
+		switch {
+		case d.Model == "":
+		case strings.HasPrefix(d.Model, "anthropic/"):
+			add("model", scalar(strings.TrimPrefix(d.Model, "anthropic/")))
+		default:
+			unmapped = append(unmapped, "model: "+d.Model)
+		}
+		if kind == tools.KindAgent && d.Mode == "primary" {
+			unmapped = append(unmapped, "mode: primary")
+		}
+
+	case "opencode":
+		if d.Description != "" {
+			add("description", scalar(d.Description))
+		}
+		if kind == tools.KindAgent && d.Mode != "" {
+			add("mode", scalar(d.Mode))
+		}
+		if d.Model != "" {
+			add("model", scalar(d.Model))
+		}
+		if d.Tools != nil {
+			if kind == tools.KindCommand {
+				unmapped = append(unmapped, "tools: "+strings.Join(d.Tools, ", "))
+			} else {
+				toolMap := &yaml.Node{Kind: yaml.MappingNode}
+				for _, name := range openCodeTools {
+					allowed := "false"
+					for _, other := range d.Tools {
+						if other == name {
+							allowed = "true"
+						}
+					}
+					toolMap.Content = append(toolMap.Content, scalar(name), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: allowed})
+				}
+				add("tools", toolMap)
+			}
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("%s definitions cannot be translated", tool)
+	}
+
+	var out bytes.Buffer
+	if len(mapping.Content) > 0 {
+		out.WriteString("---\n")
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(mapping); err != nil {
+			return nil, nil, fmt.Errorf("failed to write frontmatter of %s: %w", d.Name, err)
+		}
+		encoder.Close()
+		out.WriteString("---\n")
+	}
+	out.Write(d.Body)
+	return out.Bytes(), unmapped, nil
+}
+
+// unmapped records a field without an equivalent
+func (d *Definition) unmapped(key string, value *yaml.Node) {
+	if value.Kind == yaml.ScalarNode {
+		d.Unmapped = append(d.Unmapped, key+": "+value.Value)
+	} else {
+		d.Unmapped = append(d.Unmapped, key)
+	}
+}
+
+// splitFrontmatter splits content into the frontmatter between --- lines
+// and the body after it
+func splitFrontmatter(content []byte) (front, body []byte, ok bool) {
+	rest, found := bytes.CutPrefix(content, []byte("---\n"))
+	if !found {
+		return nil, content, false
+	}
+	if bytes.HasPrefix(rest, []byte("---\n")) {
+		return nil, rest[len("---\n"):], true
+	}
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		return nil, content, false
+	}
+	return rest[:end+1], rest[end+len("\n---\n"):], true
+}
+
+// splitList returns the items of a comma-separated string or a list
+func splitList(value *yaml.Node) []string {
+	var items []string
+	if value.Kind == yaml.SequenceNode {
+		for _, item := range value.Content {
+			items = append(items, item.Value)
+		}
+		return items
+	}
+	for _, item := range strings.Split(value.Value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// claudeTool returns Claude Code's name for an OpenCode tool, or ""
+func claudeTool(name string) string {
+	var matches []string
+	for claude, other := range claudeTools {
+		if other == name {
+			matches = append(matches, claude)
+		}
+	}
+	// Edit and MultiEdit both become edit, Edit is the one to go back to
+	sort.Strings(matches)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
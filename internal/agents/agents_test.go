@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/martinmose/agentlink/internal/tools"
+)
+
+func TestTranslateClaudeAgentToOpenCode(t *testing.T) {
+	content := []byte(`---
+name: reviewer
+description: Reviews code changes
+tools: Read, Grep, Glob, Task
+model: sonnet
+color: blue
+---
+
+You review code.
+`)
+
+	out, unmapped, err := Translate(content, "reviewer", tools.KindAgent, "claude", "opencode")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	expected := `---
+description: Reviews code changes
+mode: subagent
+tools:
+  bash: false
+  edit: false
+  write: false
+  read: true
+  grep: true
+  glob: true
+  list: false
+  webfetch: false
+  todowrite: false
+  todoread: false
+---
+
+You review code.
+`
+	if string(out) != expected {
+		t.Errorf("Translate() =\n%s\nexpected:\n%s", out, expected)
+	}
+	expectedUnmapped := []string{"tools: Task", "model: sonnet", "color: blue"}
+	if !reflect.DeepEqual(unmapped, expectedUnmapped) {
+		t.Errorf("unmapped = %q, expected %q", unmapped, expectedUnmapped)
+	}
+}
+
+func TestTranslateOpenCodeAgentToClaude(t *testing.T) {
+	content := []byte(`---
+description: Writes docs
+mode: subagent
+model: anthropic/claude-sonnet-4-5
+temperature: 0.2
+tools:
+  bash: false
+---
+Write docs.
+`)
+
+	out, unmapped, err := Translate(content, "docs", tools.KindAgent, "opencode", "claude")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	expected := `---
+name: docs
+description: Writes docs
+tools: Edit, Write, Read, Grep, Glob, LS, WebFetch, TodoWrite
+model: claude-sonnet-4-5
+---
+Write docs.
+`
+	if string(out) != expected {
+		t.Errorf("Translate() =\n%s\nexpected:\n%s", out, expected)
+	}
+	// todoread has no Claude Code equivalent
+	expectedUnmapped := []string{"temperature: 0.2", "tools: todoread"}
+	if !reflect.DeepEqual(unmapped, expectedUnmapped) {
+		t.Errorf("unmapped = %q, expected %q", unmapped, expectedUnmapped)
+	}
+}
+
+func TestTranslateCommand(t *testing.T) {
+	content := []byte("---\ndescription: Run the tests\nargument-hint: [package]\n---\nRun go test $ARGUMENTS\n")
+
+	out, unmapped, err := Translate(content, "test", tools.KindCommand, "claude", "opencode")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	expected := "---\ndescription: Run the tests\n---\nRun go test $ARGUMENTS\n"
+	if string(out) != expected {
+		t.Errorf("Translate() = %q, expected %q", out, expected)
+	}
+	if !reflect.DeepEqual(unmapped, []string{"argument-hint"}) {
+		t.Errorf("unmapped = %q", unmapped)
+	}
+}
+
+func TestTranslateWithoutFrontmatter(t *testing.T) {
+	content := []byte("Just a prompt.\n")
+
+	out, unmapped, err := Translate(content, "plain", tools.KindCommand, "opencode", "claude")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if string(out) != string(content) || len(unmapped) != 0 {
+		t.Errorf("Translate() = %q, %q, expected the content unchanged", out, unmapped)
+	}
+}
@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/agents"
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/state"
+	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/martinmose/agentlink/internal/tools"
+)
+
+// translation is a definition of an agents group translated for one tool
+type translation struct {
+	// Source is the canonical definition
+	Source string
+	// Path is where the tool reads the translation
+	Path string
+	// Tool is the tool it is translated for
+	Tool string
+	// Content is the translated definition
+	Content []byte
+	// Unmapped are the frontmatter fields the tool has no equivalent for
+	Unmapped []string
+}
+
+// translations returns every definition in an agents group's dir
+// translated for each of its tools
+func translations(group *config.Group) ([]translation, error) {
+	from, kind, root, ok := tools.MatchDefinitionDir(group.Dir)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a known agents or commands directory", group.Dir)
+	}
+	sources, err := filepath.Glob(filepath.Join(group.Dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(sources)
+
+	var result []translation
+	for _, name := range group.Tools {
+		to, _ := tools.Lookup(name)
+		dir := filepath.Join(root, to.DefinitionDir(kind))
+		for _, source := range sources {
+			content, err := os.ReadFile(source)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", source, err)
+			}
+			base := filepath.Base(source)
+			translated, unmapped, err := agents.Translate(content, strings.TrimSuffix(base, ".md"), kind, from.Name, to.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to translate %s: %w", source, err)
+			}
+			result = append(result, translation{
+				Source:   source,
+				Path:     filepath.Join(dir, base),
+				Tool:     to.Name,
+				Content:  translated,
+				Unmapped: unmapped,
+			})
+		}
+	}
+	return result, nil
+}
+
+// translateGroups writes the translations of each active agents group, and
+// removes the ones whose definition was deleted
+func translateGroups(manager *symlink.Manager, cfg *config.Config, st *state.State, summary *syncSummary) {
+	for _, group := range cfg.GroupList() {
+		if !group.IsAgents() {
+			continue
+		}
+		if ok, _ := group.When.Eval(); !ok {
+			continue
+		}
+
+		list, err := translations(group)
+		if err != nil {
+			printError("Failed to translate group %s: %v", group.Name, err)
+			summary.errors++
+			continue
+		}
+
+		current := make(map[string]bool)
+		for _, t := range list {
+			current[t.Path] = true
+			var hash string
+			if file, ok := st.Get(t.Path); ok {
+				hash = file.Hash
+			}
+			action, err := manager.WriteGenerated(t.Path, t.Content, hash)
+			if err != nil {
+				printError("Failed to write %s: %v", t.Path, err)
+				summary.errors++
+				continue
+			}
+			if !dryRun {
+				st.Set(t.Path, state.File{Source: t.Source, Hash: state.Hash(t.Content)})
+			}
+			switch action {
+			case "skip":
+				summary.unchanged++
+				if verbose {
+					printSkip("%s is up to date with %s", t.Path, t.Source)
+				}
+			case "create":
+				summary.created++
+				printCreate("%s (%s translation of %s)", t.Path, t.Tool, t.Source)
+			default:
+				summary.fixed++
+				printOK("Updated %s translation %s from %s", t.Tool, t.Path, t.Source)
+			}
+			if len(t.Unmapped) > 0 && action != "skip" {
+				printWarning("%s: no %s equivalent for %s", t.Source, t.Tool, strings.Join(t.Unmapped, ", "))
+			}
+		}
+
+		for _, path := range orphanedTranslations(group, st, current) {
+			file, _ := st.Get(path)
+			if err := manager.RemoveGenerated(path, file.Hash); err != nil {
+				printWarning("Kept %s (%v)", path, err)
+				continue
+			}
+			if !dryRun {
+				st.Delete(path)
+			}
+			printOK("Removed %s (%s was deleted)", path, file.Source)
+			summary.fixed++
+		}
+	}
+}
+
+// orphanedTranslations returns the translations the state records for a
+// group's dir whose definition no longer exists
+func orphanedTranslations(group *config.Group, st *state.State, current map[string]bool) []string {
+	var paths []string
+	for path, file := range st.Files {
+		if current[path] || filepath.Dir(file.Source) != group.Dir {
+			continue
+		}
+		if _, err := os.Stat(file.Source); os.IsNotExist(err) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// checkTranslated prints the status of each agents group's translations
+// and reports whether any of them has a problem
+func checkTranslated(manager *symlink.Manager, cfg *config.Config, st *state.State) bool {
+	hasProblems := false
+	printed := false
+	for _, group := range cfg.GroupList() {
+		if !group.IsAgents() {
+			continue
+		}
+		if !printed {
+			fmt.Printf("Translated:\n")
+			printed = true
+		}
+		if ok, _ := group.When.Eval(); !ok {
+			fmt.Printf("  %s -> skipped (condition false)\n", group.Dir)
+			continue
+		}
+
+		list, err := translations(group)
+		if err != nil {
+			fmt.Printf("  %s -> %v ✗\n", group.Dir, err)
+			hasProblems = true
+			continue
+		}
+		for _, t := range list {
+			var hash string
+			if file, ok := st.Get(t.Path); ok {
+				hash = file.Hash
+			}
+			fmt.Printf("  %s -> ", t.Path)
+			switch info := manager.CheckGenerated(t.Path, t.Content, hash); info.Status {
+			case symlink.StatusOK:
+				fmt.Printf("%s translation of %s ✓", t.Tool, t.Source)
+			case symlink.StatusMissing:
+				fmt.Printf("missing")
+				hasProblems = true
+			case symlink.StatusStale:
+				fmt.Printf("out of date with %s ✗", t.Source)
+				hasProblems = true
+			case symlink.StatusModified:
+				fmt.Printf("edited since the last sync ✗")
+				hasProblems = true
+			default:
+				fmt.Printf("%s ✗", info.Status)
+				hasProblems = true
+			}
+			if len(t.Unmapped) > 0 && verbose {
+				fmt.Printf(" (no equivalent for %s)", strings.Join(t.Unmapped, ", "))
+			}
+			fmt.Printf("\n")
+		}
+	}
+	return hasProblems
+}
+
+// cleanTranslated removes the translations of each agents group that were
+// not edited since the last sync, and returns how many were removed and
+// skipped
+func cleanTranslated(manager *symlink.Manager, cfg *config.Config, st *state.State) (removed, skipped int) {
+	for _, group := range cfg.GroupList() {
+		if !group.IsAgents() {
+			continue
+		}
+		list, err := translations(group)
+		if err != nil {
+			printWarning("Skipped group %s (%v)", group.Name, err)
+			skipped++
+			continue
+		}
+		paths := orphanedTranslations(group, st, nil)
+		for _, t := range list {
+			paths = append(paths, t.Path)
+		}
+
+		for _, path := range paths {
+			file, ok := st.Get(path)
+			if _, err := os.Lstat(path); os.IsNotExist(err) || !ok {
+				continue
+			}
+			if err := manager.RemoveGenerated(path, file.Hash); err != nil {
+				printWarning("Skipped %s (%v)", path, err)
+				skipped++
+				continue
+			}
+			st.Delete(path)
+			printOK("Removed translated %s", path)
+			removed++
+		}
+	}
+	return removed, skipped
+}
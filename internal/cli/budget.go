@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/refs"
+	"github.com/martinmose/agentlink/internal/tokens"
+)
+
+// overBudget returns a message for each file whose content, with its
+// imports for tools that expand them, is more than a tool reading it takes
+func overBudget(cfg *config.Config, files []lintFile, root, homeDir string) []string {
+	var problems []string
+	for _, file := range files {
+		for _, tool := range file.Tools {
+			budget := cfg.BudgetFor(tool)
+			if budget.Bytes == 0 && budget.Tokens == 0 {
+				continue
+			}
+
+			content, what := file.Content, ""
+			if tool.ExpandsImports {
+				var imported []string
+				content, imported = refs.Expand(file.Path, file.Content, root, homeDir)
+				if len(imported) > 0 {
+					what = " with imports"
+				}
+			}
+
+			if budget.Bytes > 0 && len(content) > budget.Bytes {
+				problems = append(problems, fmt.Sprintf("%s is %d bytes%s, over the %d byte budget of %s", displayPath(file.Path), len(content), what, budget.Bytes, tool.DisplayName))
+			}
+			if estimate := tokens.Estimate(content); budget.Tokens > 0 && estimate > budget.Tokens {
+				problems = append(problems, fmt.Sprintf("%s is about %d tokens%s, over the %d token budget of %s", displayPath(file.Path), estimate, what, budget.Tokens, tool.DisplayName))
+			}
+		}
+	}
+	return problems
+}
+
+// checkBudgets warns about files over the budget of a tool reading them
+func checkBudgets(cfg *config.Config) {
+	root := config.ConfigBaseDir(cfg.Path)
+	homeDir, _ := os.UserHomeDir()
+	files, _, err := lintFiles(cfg, root, homeDir)
+	if err != nil {
+		return
+	}
+	for _, problem := range overBudget(cfg, files, root, homeDir) {
+		printWarning("%s (see 'agentlink lint')", problem)
+	}
+}
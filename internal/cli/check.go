@@ -3,19 +3,32 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/render"
 	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/martinmose/agentlink/internal/tools"
 	"github.com/spf13/cobra"
 )
 
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check status of symlinks",
-	Long: `Check the status of each symlink defined in the configuration.
+	Long: `Check the status of each symlink defined in the configuration, and exit
+with non-zero code if any problems are found.
 
-Reports the status of each link (OK, missing, wrong target, not a symlink, broken)
-and exits with non-zero code if any problems are found.`,
+Reports per link:
+  symlink    OK, missing, wrong target, not a symlink or broken
+  hardlink   also diverged, when an editor saved either file as a new one
+  copy       also out of date when the source changed, and edited when the
+             copy changed since the last sync (likewise render, cursor and
+             copilot)
+  configure  whether the tool's settings name the source
+  mcp        servers missing from or drifted in a tool's MCP config
+
+A source over the size budget of a tool it is linked for is warned about,
+see 'agentlink lint'.`,
 	RunE: runCheck,
 }
 
@@ -34,17 +47,28 @@ func runCheck(cmd *cobra.Command, args []string) error {
 			printInfo("Run 'agentlink init' to create one")
 		} else {
 			printError("No global config found at %s", configPath)
-			printInfo("Run 'agentlink sync' to create a default config")
+			printInfo("Run 'agentlink init --global' to create one")
 		}
 		return fmt.Errorf("no config found")
 	}
 
-	cfg, err := config.LoadConfig(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		printError("Failed to load config: %v", err)
 		return err
 	}
 
+	if configs := config.ProjectConfigs("."); len(configs) > 1 {
+		printWarning("Found %d project config files, using %s", len(configs), configs[0])
+		for _, ignored := range configs[1:] {
+			printWarning("  ignored: %s", ignored)
+		}
+	}
+
+	if cfg.Version < config.CurrentVersion {
+		printWarning("Config is version %d, run 'agentlink config migrate' to upgrade it to version %d", cfg.Version, config.CurrentVersion)
+	}
+
 	if verbose {
 		if isProject {
 			printInfo("Checking project config: %s", configPath)
@@ -53,66 +77,223 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	st, err := loadState()
+	if err != nil {
+		printWarning("%v", err)
+	}
+
 	// Create symlink manager
 	manager := symlink.NewManager(false, false, verbose)
 
 	// Check each link
 	hasProblems := false
+	hasModifiedCopy := false
 	
 	// Check source file
 	sourceStatus := "OK"
-	if err := manager.ValidateSource(cfg.Source); err != nil {
+	if _, err := os.Lstat(cfg.Source); os.IsNotExist(err) {
+		sourceStatus = "missing - 'agentlink sync' will create it"
+		hasProblems = true
+	} else if err := manager.ValidateSource(cfg.Source); err != nil {
 		sourceStatus = fmt.Sprintf("ERROR: %v", err)
 		hasProblems = true
 	}
 
 	// Print header
 	fmt.Printf("Source: %s [%s]\n", cfg.Source, sourceStatus)
+	if cfg.ActiveProfile != "" {
+		fmt.Printf("Profile: %s\n", cfg.ActiveProfile)
+	} else if len(cfg.ProfileNames) > 0 {
+		fmt.Printf("Profile: none (defined: %s)\n", strings.Join(cfg.ProfileNames, ", "))
+	}
+	if len(cfg.Candidates) > 1 {
+		fmt.Printf("Chosen: %s\n", cfg.SourceReason)
+		fmt.Printf("Candidates:\n")
+		for _, candidate := range cfg.Candidates {
+			fmt.Printf("  %s: %s\n", candidate.Path, candidate.Note)
+		}
+	}
+	checkMarkers(cfg.Source)
+	checkBudgets(cfg)
+
+	if checkComposed(manager, cfg) {
+		hasProblems = true
+	}
+	if checkTranslated(manager, cfg, st) {
+		hasProblems = true
+	}
+	if checkMCP(cfg, st) {
+		hasProblems = true
+	}
+
 	fmt.Printf("Links:\n")
 	maxPathLen := 0
 	
 	// Calculate max path length for formatting
-	for _, linkPath := range cfg.Links {
+	for _, linkPath := range cfg.LinkPaths() {
 		if len(linkPath) > maxPathLen {
 			maxPathLen = len(linkPath)
 		}
 	}
 
-	for _, linkPath := range cfg.Links {
-		info := manager.CheckLink(linkPath, cfg.Source)
+	for _, link := range cfg.AllLinks() {
+		linkPath := link.Path
+		if ok, reason := link.Active(); !ok {
+			fmt.Printf("  %-*s -> skipped (condition false)\n", maxPathLen, linkPath)
+			if verbose {
+				fmt.Printf("  %-*s    %s\n", maxPathLen, "", reason)
+			}
+			continue
+		}
+
+		if link.Mode == config.ModeConfigure {
+			ok, status := checkConfigured(link, link.Target)
+			if !ok && !link.Optional {
+				hasProblems = true
+			}
+			fmt.Printf("  %-*s -> %s", maxPathLen, linkPath, status)
+			if link.Optional {
+				fmt.Printf(" (optional)")
+			}
+			fmt.Printf("\n")
+			continue
+		}
+
+		info := manager.CheckLink(linkPath, link.Target, copyOptions(link, st))
 		
 		_ = info.Status.String() // We handle status display in the switch below
 
-		if info.Status != symlink.StatusOK {
+		// Problems with optional links are shown but don't fail the check
+		if info.Status != symlink.StatusOK && !link.Optional {
 			hasProblems = true
 		}
+		if info.Status == symlink.StatusModified {
+			hasModifiedCopy = true
+		}
 
 		// Format the output nicely
 		fmt.Printf("  %-*s -> ", maxPathLen, linkPath)
 		
 		switch info.Status {
 		case symlink.StatusOK:
-			fmt.Printf("%s ✓\n", cfg.Source)
+			switch link.Mode {
+			case config.ModeCopy:
+				fmt.Printf("copy of %s ✓", link.Target)
+			case config.ModeRender:
+				fmt.Printf("%s copy of %s ✓", strings.Join(link.Tool, "/"), link.Target)
+			case config.ModeCursor:
+				fmt.Printf("Cursor rule from %s ✓", link.Target)
+			case config.ModeCopilot:
+				fmt.Printf("Copilot instructions from %s ✓", link.Target)
+			case config.ModeHardlink:
+				fmt.Printf("hardlink to %s ✓", link.Target)
+			default:
+				fmt.Printf("%s ✓", link.Target)
+			}
+		case symlink.StatusStale:
+			fmt.Printf("out of date with %s ✗", link.Target)
+		case symlink.StatusModified:
+			fmt.Printf("copy was edited since the last sync ✗")
+		case symlink.StatusWrongMode:
+			fmt.Printf("not a %s ✗", link.Mode)
+		case symlink.StatusDiverged:
+			fmt.Printf("no longer a hardlink to %s, saved as a new file ✗", link.Target)
 		case symlink.StatusMissing:
-			fmt.Printf("missing\n")
+			fmt.Printf("missing")
 		case symlink.StatusWrongTarget:
-			fmt.Printf("%s (expected %s) ✗\n", info.Target, cfg.Source)
+			fmt.Printf("%s (expected %s) ✗", info.Target, link.Target)
+		case symlink.StatusWrongStyle:
+			fmt.Printf("%s (expected a %s target) ✗", info.Target, linkOptions(link).Style)
 		case symlink.StatusNotSymlink:
-			fmt.Printf("not a symlink ✗\n")
+			fmt.Printf("not a symlink ✗")
 		case symlink.StatusBroken:
 			if info.Error != nil {
-				fmt.Printf("broken: %v ✗\n", info.Error)
+				fmt.Printf("broken: %v ✗", info.Error)
 			} else {
-				fmt.Printf("broken ✗\n")
+				fmt.Printf("broken ✗")
 			}
 		}
+		if link.Optional {
+			fmt.Printf(" (optional)")
+		}
+		fmt.Printf("\n")
 	}
 
 	if hasProblems || sourceStatus != "OK" {
 		fmt.Printf("\nFound problems. Run 'agentlink sync' to fix them.\n")
+		if hasModifiedCopy {
+			fmt.Printf("Edited copies are kept, run 'agentlink diff' to see the edits and 'agentlink absorb' to keep them.\n")
+		}
 		return fmt.Errorf("configuration has problems")
 	}
 
 	fmt.Printf("\nAll links are correctly configured ✓\n")
 	return nil
-}
\ No newline at end of file
+}
+
+// checkComposed prints the status of each compose group's output and
+// reports whether any of them has a problem
+func checkComposed(manager *symlink.Manager, cfg *config.Config) bool {
+	hasProblems := false
+	printed := false
+	profile, _ := selectedProfile()
+	for _, group := range cfg.GroupList() {
+		if !group.IsCompose() {
+			continue
+		}
+		if !printed {
+			fmt.Printf("Generated:\n")
+			printed = true
+		}
+		if ok, _ := group.When.Eval(); !ok {
+			fmt.Printf("  %s -> skipped (condition false)\n", group.Output)
+			continue
+		}
+
+		fragments, err := cfg.FragmentPaths(group, profile)
+		if err != nil {
+			fmt.Printf("  %s -> %v ✗\n", group.Output, err)
+			hasProblems = true
+			continue
+		}
+
+		info := manager.CheckCompose(group.Output, fragments)
+		fmt.Printf("  %s -> ", group.Output)
+		switch info.Status {
+		case symlink.StatusOK:
+			fmt.Printf("composed from %d fragments ✓\n", len(fragments))
+		case symlink.StatusMissing:
+			fmt.Printf("missing\n")
+		case symlink.StatusStale:
+			fmt.Printf("out of date, fragments changed ✗\n")
+		case symlink.StatusModified:
+			fmt.Printf("edited by hand, edit the fragments instead ✗\n")
+		case symlink.StatusWrongMode:
+			fmt.Printf("not a regular file ✗\n")
+		default:
+			fmt.Printf("broken: %v ✗\n", info.Error)
+		}
+		if info.Status != symlink.StatusOK {
+			hasProblems = true
+		}
+	}
+	return hasProblems
+}
+
+// checkMarkers warns about agentlink:only and agentlink:except markers in
+// the source that name tools agentlink doesn't know
+func checkMarkers(sourcePath string) {
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return
+	}
+	known := make(map[string]bool)
+	for _, name := range tools.Names() {
+		known[name] = true
+	}
+	for _, name := range render.Tools(content) {
+		if !known[strings.ToLower(name)] {
+			printWarning("Source markers name unknown tool %q (known: %s)", name, strings.Join(tools.Names(), ", "))
+		}
+	}
+}
@@ -15,7 +15,11 @@ var cleanCmd = &cobra.Command{
 	Long: `Remove symlinks that are managed by agentlink.
 
 Only removes symlinks that point to the configured source file.
-Never removes the source file itself or regular files.`,
+Never removes the source file itself or regular files, except copies made
+by links with mode copy or hardlink and files generated by compose and
+agents groups, as long as they were not edited since the last sync. Links with mode
+configure have the source taken out of the tools' settings again, and the
+servers of mcp groups are taken out of the tools' MCP configs.`,
 	RunE: runClean,
 }
 
@@ -38,7 +42,7 @@ func runClean(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no config found")
 	}
 
-	cfg, err := config.LoadConfig(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		printError("Failed to load config: %v", err)
 		return err
@@ -57,22 +61,65 @@ func runClean(cmd *cobra.Command, args []string) error {
 
 	printInfo("Source: %s (will NOT be removed)", cfg.Source)
 
+	st, err := loadState()
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+
 	// Process each link
 	removedCount := 0
 	skippedCount := 0
 
-	for _, linkPath := range cfg.Links {
+	for _, link := range cfg.AllLinks() {
+		linkPath := link.Path
 		if verbose {
 			printInfo("Processing link: %s", linkPath)
 		}
 
-		info := manager.CheckLink(linkPath, cfg.Source)
+		if link.Mode == config.ModeConfigure {
+			removed, err := unconfigureLink(st, cfg.Path, link, link.Target)
+			switch {
+			case err != nil:
+				printWarning("Skipped %s (%v)", linkPath, err)
+				skippedCount++
+			case removed:
+				removedCount++
+			default:
+				if verbose {
+					printSkip("%s (not configured)", linkPath)
+				}
+				skippedCount++
+			}
+			continue
+		}
+
+		if link.IsFile() {
+			if _, err := os.Lstat(linkPath); os.IsNotExist(err) {
+				if verbose {
+					printSkip("%s (already missing)", linkPath)
+				}
+				skippedCount++
+				continue
+			}
+			if err := manager.RemoveFile(linkPath, link.Target, copyOptions(link, st)); err != nil {
+				printWarning("Skipped %s (%v)", linkPath, err)
+				skippedCount++
+				continue
+			}
+			st.Delete(linkPath)
+			printOK("Removed %s %s", link.Mode, linkPath)
+			removedCount++
+			continue
+		}
+
+		info := manager.CheckLink(linkPath, link.Target, symlink.Options{})
 		
 		switch info.Status {
-		case symlink.StatusOK:
+		case symlink.StatusOK, symlink.StatusWrongStyle:
 			// This is a symlink pointing to our source - remove it
 			if !dryRun {
-				if err := manager.RemoveLink(linkPath, cfg.Source); err != nil {
+				if err := manager.RemoveLink(linkPath, link.Target); err != nil {
 					printError("Failed to remove %s: %v", linkPath, err)
 					continue
 				}
@@ -87,7 +134,7 @@ func runClean(cmd *cobra.Command, args []string) error {
 			skippedCount++
 			
 		case symlink.StatusWrongTarget:
-			printWarning("Skipped %s (points to %s, not %s)", linkPath, info.Target, cfg.Source)
+			printWarning("Skipped %s (points to %s, not %s)", linkPath, info.Target, link.Target)
 			skippedCount++
 			
 		case symlink.StatusNotSymlink:
@@ -107,6 +154,35 @@ func runClean(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Generated outputs go once nothing links to them
+	for _, group := range cfg.GroupList() {
+		if !group.IsCompose() {
+			continue
+		}
+		if _, err := os.Lstat(group.Output); os.IsNotExist(err) {
+			continue
+		}
+		if err := manager.RemoveComposed(group.Output); err != nil {
+			printWarning("Skipped %s (%v)", group.Output, err)
+			skippedCount++
+			continue
+		}
+		printOK("Removed generated %s", group.Output)
+		removedCount++
+	}
+
+	removed, skipped := cleanTranslated(manager, cfg, st)
+	removedCount += removed
+	skippedCount += skipped
+	removed, skipped = cleanMCP(cfg, st)
+	removedCount += removed
+	skippedCount += skipped
+
+	if err := saveState(st); err != nil {
+		printError("%v", err)
+		return err
+	}
+
 	// Summary
 	if dryRun {
 		printInfo("Dry run completed - would remove %d symlinks, skip %d items", removedCount, skippedCount)
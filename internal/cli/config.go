@@ -0,0 +1,515 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/martinmose/agentlink/internal/textdiff"
+	"github.com/martinmose/agentlink/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configGlobal bool
+	configLocal  bool
+	addLinkSync  bool
+	showResolved bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and edit the config file",
+	Long: `Read and edit the config file without losing comments or ordering.
+
+By default the project config (.agentlink.yaml or one of its alternatives) is
+used, falling back to the global config like every other command. Use --local for .agentlink.local.yaml,
+an uncommitted override merged over the project config, or --global for
+~/.config/agentlink/config.yaml.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:       "get <key>",
+	Short:     "Print a config value (version, extends, source, links or template)",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"version", "extends", "source", "links", "template"},
+	RunE:      runConfigGet,
+}
+
+var configSetSourceCmd = &cobra.Command{
+	Use:   "set-source <path>",
+	Short: "Set the source in the config",
+	Long: `Set the source in the config file.
+
+Only the config is changed. To also move the content and retarget existing
+links, use 'agentlink source set'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigSetSource,
+}
+
+var configAddLinkCmd = &cobra.Command{
+	Use:   "add-link <path>",
+	Short: "Add a link to the config",
+	Long: `Add a link to the config file.
+
+The path is checked against the instruction files of known tools. Paths no
+known tool reads are added with a warning, to catch typos like AGENT.md.
+Use --sync to create the link straight away.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigAddLink,
+}
+
+var configRemoveLinkCmd = &cobra.Command{
+	Use:   "remove-link <path>",
+	Short: "Remove a link from the config",
+	Long: `Remove a link from the config file.
+
+If the link exists as a symlink to the source, it is removed as well.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigRemoveLink,
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the config file to the current version",
+	Long: `Upgrade an older config file to the current config version.
+
+Older files keep working, they are upgraded in memory whenever they are
+loaded. This rewrites the file itself (comments are preserved) after showing
+a diff of the changes and asking for confirmation. Use --force to skip the
+confirmation, or --dry-run to only show the diff.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrate,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for config files",
+	Long: `Print the JSON Schema for config files.
+
+Editors using yaml-language-server pick it up from the modeline 'agentlink
+init' writes at the top of new configs. To use it offline, save it and point
+the modeline at the file:
+
+  agentlink config schema > ~/.config/agentlink/schema.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Stdout.Write(config.SchemaJSON)
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the config file",
+	Long: `Print the config file.
+
+With --resolved, print the effective config instead: the configs it extends
+and the local config merged in, with paths relative to the project.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+var configConvertCmd = &cobra.Command{
+	Use:       "convert <yaml|json|toml>",
+	Short:     "Convert the config file to another format",
+	ValidArgs: []string{"yaml", "json", "toml"},
+	Long: `Convert the config file to another format, e.g. .agentlink.yaml to
+.agentlink.toml. The new file replaces the old one in the same location.
+
+Comments are kept when converting YAML to TOML. JSON has none, and
+converting from TOML loses them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigConvert,
+}
+
+func init() {
+	configCmd.PersistentFlags().BoolVar(&configGlobal, "global", false, "use the global config")
+	configCmd.PersistentFlags().BoolVar(&configLocal, "local", false, "use the local config ("+config.LocalConfigName+")")
+	configAddLinkCmd.Flags().BoolVar(&addLinkSync, "sync", false, "run sync after adding the link")
+	configShowCmd.Flags().BoolVar(&showResolved, "resolved", false, "print the effective config with extends and local overrides merged")
+
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetSourceCmd)
+	configCmd.AddCommand(configAddLinkCmd)
+	configCmd.AddCommand(configRemoveLinkCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configConvertCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// configScopePath returns the config file selected by --global and --local
+func configScopePath() (string, error) {
+	if configGlobal && configLocal {
+		return "", fmt.Errorf("--global and --local cannot be combined")
+	}
+
+	if configGlobal {
+		return config.GlobalConfigPath(), nil
+	}
+
+	if configLocal {
+		projectConfig, isProject := config.FindConfigPath()
+		if !isProject {
+			printError("No project config found in current directory")
+			printInfo("A local config overrides a project config, run 'agentlink init' first")
+			return "", fmt.Errorf("no project config found")
+		}
+		return config.LocalConfigPath(projectConfig), nil
+	}
+
+	configPath, _ := config.FindConfigPath()
+	return configPath, nil
+}
+
+// loadScopeDocument loads the selected config file for editing. A missing
+// local config starts out empty; other scopes must exist.
+func loadScopeDocument() (*config.Document, error) {
+	configPath, err := configScopePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if configLocal {
+			return config.NewDocument(configPath), nil
+		}
+		printError("No config found at %s", configPath)
+		printInfo("Run 'agentlink init' (or 'agentlink init --global') to create one")
+		return nil, fmt.Errorf("no config found")
+	}
+
+	doc, err := config.LoadDocument(configPath)
+	if err != nil {
+		printError("Failed to load config: %v", err)
+		return nil, err
+	}
+	return doc, nil
+}
+
+// saveDocument writes doc unless this is a dry run
+func saveDocument(doc *config.Document) error {
+	if dryRun {
+		return nil
+	}
+	if err := doc.Save(); err != nil {
+		printError("Failed to save config: %v", err)
+		return err
+	}
+	return nil
+}
+
+// effectiveConfig loads the merged config the edited file takes part in, or
+// nil if it cannot be loaded (e.g. it is still incomplete)
+func effectiveConfig() *config.Config {
+	configPath, _ := config.FindConfigPath()
+	if configGlobal {
+		configPath = config.GlobalConfigPath()
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// argPath expands a path given on the command line relative to the current
+// directory
+func argPath(arg string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return config.ExpandPath(arg, cwd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	doc, err := loadScopeDocument()
+	if err != nil {
+		return err
+	}
+
+	values, ok := doc.Values(args[0])
+	if !ok {
+		return fmt.Errorf("%s is not set in %s", args[0], doc.Path)
+	}
+	for _, value := range values {
+		fmt.Println(value)
+	}
+
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	if !showResolved {
+		doc, err := loadScopeDocument()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(doc.Path)
+		if err != nil {
+			printError("Failed to read config: %v", err)
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+
+	configPath, _ := config.FindConfigPath()
+	if configGlobal {
+		configPath = config.GlobalConfigPath()
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		printError("No config found at %s", configPath)
+		return fmt.Errorf("no config found")
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		printError("Failed to load config: %v", err)
+		return err
+	}
+
+	data, err := cfg.ResolvedDocument().Bytes()
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func runConfigSetSource(cmd *cobra.Command, args []string) error {
+	doc, err := loadScopeDocument()
+	if err != nil {
+		return err
+	}
+
+	source, err := argPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	doc.SetSource(source)
+	if err := saveDocument(doc); err != nil {
+		return err
+	}
+
+	printOK("Set source to %s in %s", doc.ConfigPath(source), doc.Path)
+	return nil
+}
+
+func runConfigAddLink(cmd *cobra.Command, args []string) error {
+	doc, err := loadScopeDocument()
+	if err != nil {
+		return err
+	}
+
+	linkPath, err := argPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	checkKnownLink(linkPath)
+
+	if cfg := effectiveConfig(); cfg != nil {
+		if linkPath == cfg.Source {
+			printError("%s is the source, it cannot also be a link", linkPath)
+			return fmt.Errorf("link is the source")
+		}
+	}
+
+	if !doc.AddLink(linkPath) {
+		printInfo("%s is already a link in %s", doc.ConfigPath(linkPath), doc.Path)
+	} else {
+		if err := saveDocument(doc); err != nil {
+			return err
+		}
+		printOK("Added %s to %s", doc.ConfigPath(linkPath), doc.Path)
+	}
+
+	if addLinkSync {
+		return runSync(cmd, nil)
+	}
+	return nil
+}
+
+// checkKnownLink warns when a link path is read by no tool in the registry,
+// which is most likely a typo
+func checkKnownLink(linkPath string) {
+	root := ""
+	if projectConfig, isProject := config.FindConfigPath(); isProject && !configGlobal {
+		root = config.ConfigBaseDir(projectConfig)
+	}
+	homeDir, _ := os.UserHomeDir()
+
+	matches := tools.MatchPath(linkPath, root, homeDir)
+	if len(matches) > 0 {
+		if verbose {
+			for _, tool := range matches {
+				printInfo("%s is read by %s", linkPath, tool.DisplayName)
+			}
+		}
+		return
+	}
+
+	printWarning("%s is not read by any known tool, adding it anyway", linkPath)
+	if suggestion := tools.SuggestFile(linkPath); suggestion != "" && suggestion != filepath.Base(linkPath) {
+		printInfo("Did you mean %s?", suggestion)
+	}
+}
+
+func runConfigRemoveLink(cmd *cobra.Command, args []string) error {
+	doc, err := loadScopeDocument()
+	if err != nil {
+		return err
+	}
+
+	linkPath, err := argPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Load before editing, the source may be defined in the edited file
+	cfg := effectiveConfig()
+
+	if !doc.RemoveLink(linkPath) {
+		printError("%s is not a link in %s", linkPath, doc.Path)
+		return fmt.Errorf("link not found")
+	}
+	if err := saveDocument(doc); err != nil {
+		return err
+	}
+	printOK("Removed %s from %s", doc.ConfigPath(linkPath), doc.Path)
+
+	if cfg == nil {
+		return nil
+	}
+	manager := symlink.NewManager(dryRun, force, verbose)
+	if manager.CheckLink(linkPath, cfg.Source, symlink.Options{}).LinksToTarget() {
+		if err := manager.RemoveLink(linkPath, cfg.Source); err != nil {
+			printError("Failed to remove symlink %s: %v", linkPath, err)
+			return err
+		}
+		printOK("Removed symlink %s", linkPath)
+	}
+
+	return nil
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	doc, err := loadScopeDocument()
+	if err != nil {
+		return err
+	}
+
+	from, steps, err := doc.Migrate()
+	if err != nil {
+		printError("Failed to migrate config: %v", err)
+		return err
+	}
+	if len(steps) == 0 {
+		printOK("%s is already at version %d", doc.Path, config.CurrentVersion)
+		return nil
+	}
+
+	original, err := os.ReadFile(doc.Path)
+	if err != nil {
+		printError("Failed to read config: %v", err)
+		return err
+	}
+
+	migrated, err := doc.Bytes()
+	if err != nil {
+		printError("Failed to encode config: %v", err)
+		return err
+	}
+
+	printInfo("Migrating %s from version %d to %d:", doc.Path, from, config.CurrentVersion)
+	for _, step := range steps {
+		printInfo("  %s", step)
+	}
+	fmt.Print(textdiff.Unified(doc.Path, doc.Path+" (migrated)", string(original), string(migrated)))
+
+	if dryRun {
+		printInfo("Dry run completed - no changes made")
+		return nil
+	}
+
+	if !force {
+		ok, err := confirm("Write the migrated config?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			printInfo("Cancelled")
+			return nil
+		}
+	}
+
+	if err := saveDocument(doc); err != nil {
+		return err
+	}
+	printOK("Migrated %s to version %d", doc.Path, config.CurrentVersion)
+	return nil
+}
+
+func runConfigConvert(cmd *cobra.Command, args []string) error {
+	format, err := config.ParseFormat(args[0])
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+	if configLocal {
+		printError("The local config is always %s", config.LocalConfigName)
+		return fmt.Errorf("cannot convert the local config")
+	}
+
+	doc, err := loadScopeDocument()
+	if err != nil {
+		return err
+	}
+
+	oldPath := doc.Path
+	if doc.Format() == format {
+		printInfo("%s is already %s", oldPath, format)
+		return nil
+	}
+
+	hadComments := doc.HasComments()
+	doc.Convert(format)
+
+	if _, err := os.Stat(doc.Path); err == nil && !force {
+		printError("%s already exists (use --force to overwrite)", doc.Path)
+		return fmt.Errorf("target file exists")
+	}
+
+	if dryRun {
+		data, err := doc.Bytes()
+		if err != nil {
+			printError("%v", err)
+			return err
+		}
+		printInfo("Would write %s:", doc.Path)
+		fmt.Print(string(data))
+		printInfo("Would remove %s", oldPath)
+		return nil
+	}
+
+	if err := saveDocument(doc); err != nil {
+		return err
+	}
+	if err := os.Remove(oldPath); err != nil {
+		printError("Failed to remove %s: %v", oldPath, err)
+		return err
+	}
+
+	printOK("Converted %s to %s", oldPath, doc.Path)
+	if hadComments && format == config.FormatJSON {
+		printWarning("JSON has no comments, the ones in %s were dropped", oldPath)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/state"
+	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/martinmose/agentlink/internal/toolconfig"
+)
+
+// configureSettings returns the settings that point the tools of a
+// configure link at sourcePath. The tools look the name up in the
+// directories they read, so the source must sit where the link would.
+func configureSettings(link config.Link, sourcePath string) ([]toolconfig.Setting, error) {
+	if filepath.Dir(sourcePath) != filepath.Dir(link.Path) {
+		return nil, fmt.Errorf("%s is not in the same directory as %s, tools only find it by name there", sourcePath, link.Path)
+	}
+	settings := make([]toolconfig.Setting, 0, len(link.Tool))
+	for _, tool := range link.Tool {
+		setting, err := toolconfig.For(tool, link.Path)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// configureLink points the tools of a configure link at sourcePath through
+// their settings, and removes a symlink left at the link's path by an
+// earlier sync. It returns the action taken, like processLink.
+func configureLink(manager *symlink.Manager, link config.Link, sourcePath string) (string, error) {
+	settings, err := configureSettings(link, sourcePath)
+	if err != nil {
+		return "", err
+	}
+
+	action := "skip"
+	name := filepath.Base(sourcePath)
+	for _, setting := range settings {
+		changed, err := setting.Add(name, dryRun)
+		if err != nil {
+			return "", err
+		}
+		if changed {
+			printOK("Configured %s to read %s (%s)", setting.Tool, name, setting)
+			if setting.Global {
+				printWarning("%s is global, %s now looks for %s in every project", setting.Path, setting.Tool, name)
+			}
+			action = "create"
+		} else if verbose {
+			printSkip("%s already reads %s (%s)", setting.Tool, name, setting)
+		}
+	}
+
+	if manager.CheckLink(link.Path, sourcePath, symlink.Options{}).LinksToTarget() {
+		if err := manager.RemoveLink(link.Path, sourcePath); err != nil {
+			return "", err
+		}
+		printOK("Removed %s (%s reads %s directly now)", link.Path, strings.Join(link.Tool, "/"), name)
+		action = "fix"
+	}
+	return action, nil
+}
+
+// settingKey is where the state records the configs that make a setting
+// list name
+func settingKey(setting toolconfig.Setting, name string) string {
+	return setting.Path + ":" + setting.Key + "=" + name
+}
+
+// recordConfigured records that cfgPath makes the tools of a configure link
+// read sourcePath. A setting such as the Codex config is shared by every
+// project, so the name stays until no config that added it is left.
+func recordConfigured(st *state.State, cfgPath string, link config.Link, sourcePath string) {
+	if dryRun {
+		return
+	}
+	settings, err := configureSettings(link, sourcePath)
+	if err != nil {
+		return
+	}
+	for _, setting := range settings {
+		st.AddOwner(settingKey(setting, filepath.Base(sourcePath)), cfgPath, "")
+	}
+}
+
+// checkConfigured reports whether the tools of a configure link read
+// sourcePath, describing the result for check
+func checkConfigured(link config.Link, sourcePath string) (bool, string) {
+	settings, err := configureSettings(link, sourcePath)
+	if err != nil {
+		return false, fmt.Sprintf("%v ✗", err)
+	}
+
+	name := filepath.Base(sourcePath)
+	var missing, where []string
+	for _, setting := range settings {
+		ok, err := setting.Has(name)
+		if err != nil {
+			return false, fmt.Sprintf("%v ✗", err)
+		}
+		if !ok {
+			missing = append(missing, setting.Tool)
+		}
+		where = append(where, setting.String())
+	}
+	if len(missing) > 0 {
+		return false, fmt.Sprintf("%s not configured to read %s ✗", strings.Join(missing, "/"), name)
+	}
+	return true, fmt.Sprintf("%s reads %s (%s) ✓", strings.Join(link.Tool, "/"), name, strings.Join(where, ", "))
+}
+
+// unconfigureLink takes sourcePath out of the settings of a configure
+// link's tools and reports whether any of them changed. A setting other
+// configs also make list the name is left as it is.
+func unconfigureLink(st *state.State, cfgPath string, link config.Link, sourcePath string) (bool, error) {
+	settings, err := configureSettings(link, sourcePath)
+	if err != nil {
+		return false, err
+	}
+
+	removed := false
+	name := filepath.Base(sourcePath)
+	for _, setting := range settings {
+		if st.RemoveOwner(settingKey(setting, name), cfgPath) {
+			if verbose {
+				printSkip("%s in %s (other configs still use it)", name, setting)
+			}
+			continue
+		}
+		changed, err := setting.Remove(name, dryRun)
+		if err != nil {
+			return removed, err
+		}
+		if changed {
+			printOK("Removed %s from %s", name, setting)
+			removed = true
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/state"
+	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/martinmose/agentlink/internal/textdiff"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [link...]",
+	Short: "Show how copies differ from the source",
+	Long: `Show how copied links (mode copy, render, cursor or copilot) differ from
+what sync would write.
+
+Without arguments every copy in the configuration is compared. Lines only
+in the copy are shown as additions, e.g. edits made to the copy instead of
+the source. Exits with non-zero code if any copy differs.`,
+	RunE: runDiff,
+}
+
+var absorbCmd = &cobra.Command{
+	Use:   "absorb <link>",
+	Short: "Write the edits made to a copy back to the source",
+	Long: `Replace the source with the content of a copy that was edited since the
+last sync, so the edits are kept and the other links pick them up. A
+hardlink an editor broke by saving a new file is absorbed the same way and
+then linked to the source again.
+
+The changes to the source are shown and confirmed first, unless --force is
+given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAbsorb,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(absorbCmd)
+}
+
+// loadState loads the state file that records the copies sync wrote
+func loadState() (*state.State, error) {
+	path, err := state.DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate state file: %w", err)
+	}
+	return state.Load(path)
+}
+
+// saveState writes st back, unless this is a dry run
+func saveState(st *state.State) error {
+	if dryRun {
+		return nil
+	}
+	return st.Save()
+}
+
+// copyOptions returns the options of link with the hash of the copy or
+// hardlink the last sync wrote, so an untouched file can be told from an
+// edited one
+func copyOptions(link config.Link, st *state.State) symlink.Options {
+	opts := linkOptions(link)
+	if link.IsFile() && st != nil {
+		if file, ok := st.Get(link.Path); ok {
+			opts.Hash = file.Hash
+		}
+	}
+	return opts
+}
+
+// recordCopy records in st that the copy or hardlink at linkPath holds the
+// current content of sourcePath
+func recordCopy(st *state.State, linkPath, sourcePath string, opts symlink.Options) {
+	if dryRun {
+		return
+	}
+	content, err := symlink.CopyContent(linkPath, sourcePath, opts)
+	if err != nil {
+		return
+	}
+	st.Set(linkPath, state.File{Source: sourcePath, Hash: state.Hash(content)})
+}
+
+// loadCopies loads the config and returns its copy links, or only the ones
+// named in args. With hardlinks, links in hardlink mode count as copies, as
+// one an editor broke holds a copy of its own.
+func loadCopies(args []string, hardlinks bool) (*config.Config, []config.Link, error) {
+	configPath, isProject := config.FindConfigPath()
+	cfg, err := loadSyncConfig(configPath, isProject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	copies := make(map[string]config.Link)
+	var all []config.Link
+	for _, link := range cfg.AllLinks() {
+		if link.IsCopy() || (hardlinks && link.Mode == config.ModeHardlink) {
+			copies[link.Path] = link
+			all = append(all, link)
+		}
+	}
+	if len(args) == 0 {
+		return cfg, all, nil
+	}
+
+	var links []config.Link
+	for _, arg := range args {
+		linkPath, err := argPath(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		link, ok := copies[linkPath]
+		if !ok {
+			modes := "copy, render, cursor or copilot"
+			if hardlinks {
+				modes = "copy, hardlink, render, cursor or copilot"
+			}
+			printError("%s is not a copied link (mode %s)", arg, modes)
+			return nil, nil, fmt.Errorf("not a copy")
+		}
+		links = append(links, link)
+	}
+	return cfg, links, nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	_, links, err := loadCopies(args, false)
+	if err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		printInfo("No copied links (mode copy, render, cursor or copilot)")
+		return nil
+	}
+
+	differs := false
+	for _, link := range links {
+		expected, err := symlink.CopyContent(link.Path, link.Target, linkOptions(link))
+		if err != nil {
+			printError("%v", err)
+			return err
+		}
+		content, err := os.ReadFile(link.Path)
+		if os.IsNotExist(err) {
+			printWarning("%s is missing, run 'agentlink sync' to create it", link.Path)
+			differs = true
+			continue
+		}
+		if err != nil {
+			printError("Failed to read %s: %v", link.Path, err)
+			return err
+		}
+
+		if diff := textdiff.Unified(link.Target, link.Path, string(expected), string(content)); diff != "" {
+			fmt.Print(diff)
+			differs = true
+		} else if verbose {
+			printOK("%s is up to date", link.Path)
+		}
+	}
+
+	if differs {
+		return fmt.Errorf("copies differ from the source")
+	}
+	return nil
+}
+
+func runAbsorb(cmd *cobra.Command, args []string) error {
+	cfg, links, err := loadCopies(args, true)
+	if err != nil {
+		return err
+	}
+	link := links[0]
+	opts := linkOptions(link)
+	if len(link.Append) > 0 {
+		printError("%s joins %s and %s, edit those files instead", link.Path, link.Target, strings.Join(link.Append, ", "))
+		return fmt.Errorf("cannot absorb a joined copy")
+	}
+	if link.Target != cfg.Source {
+		printError("%s is a copy of %s, generated by group %s, edit its fragments instead", link.Path, link.Target, link.Group)
+		return fmt.Errorf("not a copy of the source")
+	}
+	if link.Mode != config.ModeCopy && link.Mode != config.ModeHardlink {
+		printError("%s is rendered for %s, copy the edits into %s by hand", link.Path, strings.Join(link.RenderTools(), "/"), cfg.Source)
+		printInfo("Run 'agentlink diff %s' to see them", args[0])
+		return fmt.Errorf("cannot absorb a rendered copy")
+	}
+
+	content, err := os.ReadFile(link.Path)
+	if err != nil {
+		printError("Failed to read %s: %v", link.Path, err)
+		return err
+	}
+	source, err := os.ReadFile(cfg.Source)
+	if err != nil {
+		printError("Failed to read source: %v", err)
+		return err
+	}
+
+	absorbed := symlink.StripHeader(content, link.Path, cfg.Source)
+	diff := textdiff.Unified(cfg.Source, cfg.Source+" (absorbed)", string(source), string(absorbed))
+	if diff == "" {
+		printOK("%s has no edits to absorb", link.Path)
+		return nil
+	}
+	fmt.Print(diff)
+
+	if dryRun {
+		printInfo("Dry run completed - no changes made")
+		return nil
+	}
+
+	if !force {
+		ok, err := confirm(fmt.Sprintf("Write the edits in %s to %s?", link.Path, cfg.Source))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			printInfo("Cancelled")
+			return nil
+		}
+	}
+
+	// Written in place, so hardlinks to the source keep sharing it
+	manager := symlink.NewManager(dryRun, force, verbose)
+	if err := manager.UpdateFile(cfg.Source, absorbed); err != nil {
+		printError("Failed to write source: %v", err)
+		return err
+	}
+	printOK("Absorbed %s into %s", link.Path, cfg.Source)
+
+	// A hardlink that diverged shares the source again
+	if link.Mode == config.ModeHardlink {
+		if _, err := manager.FixLink(link.Path, cfg.Source, opts); err != nil {
+			printError("Failed to relink %s: %v", link.Path, err)
+			return err
+		}
+		printOK("Relinked %s -> %s", link.Path, cfg.Source)
+	}
+
+	// The copy now matches the source, record it as written by sync
+	st, err := loadState()
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+	recordCopy(st, link.Path, cfg.Source, opts)
+	if err := saveState(st); err != nil {
+		printError("%v", err)
+		return err
+	}
+
+	printInfo("Run 'agentlink sync' to update the other links")
+	return nil
+}
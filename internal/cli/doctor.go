@@ -95,18 +95,21 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		fmt.Printf("⚠️  No .git directory (not in a git repository)\n")
 	}
 	
-	if _, err := os.Stat(".agentlink.yaml"); err == nil {
-		fmt.Printf("✓ Project config found: .agentlink.yaml\n")
+	if configs := config.ProjectConfigs("."); len(configs) > 0 {
+		fmt.Printf("✓ Project config found: %s\n", configs[0])
+		for _, ignored := range configs[1:] {
+			fmt.Printf("⚠️  Ignored, %s takes precedence: %s\n", filepath.Base(configs[0]), ignored)
+		}
 		
 		// Try to load and validate it
-		if cfg, err := config.LoadConfig(".agentlink.yaml"); err != nil {
+		if cfg, err := loadConfig(configs[0]); err != nil {
 			fmt.Printf("✗ Project config is invalid: %v\n", err)
 			hasIssues = true
 		} else {
 			fmt.Printf("✓ Project config is valid\n")
 			if verbose {
 				fmt.Printf("  Source: %s\n", cfg.Source)
-				fmt.Printf("  Links: %d configured\n", len(cfg.Links))
+				fmt.Printf("  Links: %d configured\n", len(cfg.AllLinks()))
 			}
 		}
 	} else {
@@ -116,24 +119,24 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 
 	// Check global config
 	fmt.Printf("Global Configuration:\n")
-	globalConfig, _ := config.FindConfigPath()
+	globalConfig := config.GlobalConfigPath()
 	if _, err := os.Stat(globalConfig); err == nil {
 		fmt.Printf("✓ Global config found: %s\n", globalConfig)
 		
 		// Try to load and validate it
-		if cfg, err := config.LoadConfig(globalConfig); err != nil {
+		if cfg, err := loadConfig(globalConfig); err != nil {
 			fmt.Printf("✗ Global config is invalid: %v\n", err)
 			hasIssues = true
 		} else {
 			fmt.Printf("✓ Global config is valid\n")
 			if verbose {
 				fmt.Printf("  Source: %s\n", cfg.Source)
-				fmt.Printf("  Links: %d configured\n", len(cfg.Links))
+				fmt.Printf("  Links: %d configured\n", len(cfg.AllLinks()))
 			}
 		}
 	} else {
 		fmt.Printf("⚠️  No global config found: %s\n", globalConfig)
-		fmt.Printf("    (This is normal - will be created when needed)\n")
+		fmt.Printf("    (This is normal - run 'agentlink init --global' to create one)\n")
 	}
 	fmt.Printf("\n")
 
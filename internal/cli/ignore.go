@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/symlink"
+)
+
+// bootstrapIgnoreFiles creates the missing file of each active ignore
+// group, from the first tool ignore file that exists, and returns the
+// groups whose file could not be created
+func bootstrapIgnoreFiles(manager *symlink.Manager, cfg *config.Config, summary *syncSummary) map[string]bool {
+	failed := make(map[string]bool)
+	for _, group := range cfg.GroupList() {
+		if !group.IsIgnore() {
+			continue
+		}
+		if ok, _ := group.When.Eval(); !ok {
+			continue
+		}
+		if _, err := os.Lstat(group.File); !os.IsNotExist(err) {
+			continue
+		}
+
+		var paths []string
+		for _, tool := range group.IgnoreTools() {
+			paths = append(paths, filepath.Join(group.Root, tool.IgnoreFile))
+		}
+		adopted, err := manager.BootstrapSource(group.File, paths, nil)
+		if err != nil {
+			printError("Failed to create %s for group %s: %v", group.File, group.Name, err)
+			summary.errors++
+			failed[group.Name] = true
+			continue
+		}
+		if adopted != "" {
+			printBootstrap("Created %s from %s", group.File, adopted)
+		} else {
+			printBootstrap("Created empty %s", group.File)
+		}
+		summary.created++
+	}
+	return failed
+}
@@ -1,30 +1,42 @@
 package cli
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/tools"
 	"github.com/spf13/cobra"
 )
 
+var initGlobal bool
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Create .agentlink.yaml in current directory",
 	Long: `Create a .agentlink.yaml configuration file in the current directory.
 
-If no .git directory is found, you'll be prompted to confirm creation.`,
+If no .git directory is found, you'll be prompted to confirm creation.
+
+With --global, detect the AI tools installed in your home directory
+(~/.claude, ~/.codex, ~/.gemini, ~/.config/opencode, ...) and create
+~/.config/agentlink/config.yaml with a personal source and links to
+every other tool's instruction file.`,
 	RunE: runInit,
 }
 
 func init() {
+	initCmd.Flags().BoolVar(&initGlobal, "global", false, "create the global config from tools detected in your home directory")
 	rootCmd.AddCommand(initCmd)
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	if initGlobal {
+		return runInitGlobal()
+	}
+
 	configPath := ".agentlink.yaml"
 
 	// Check if config already exists
@@ -34,20 +46,21 @@ func runInit(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("config file already exists")
 		}
 		printWarning("Overwriting existing .agentlink.yaml")
+	} else if configs := config.ProjectConfigs("."); len(configs) > 0 {
+		// Another format or location would take a back seat to the new file
+		printError("%s already exists", configs[0])
+		printInfo("Edit it, or remove it first to start over")
+		return fmt.Errorf("config file already exists")
 	}
 
 	// Check for .git directory
 	if _, err := os.Stat(".git"); os.IsNotExist(err) {
 		if !force {
-			fmt.Print("No .git directory found. Create .agentlink.yaml here anyway? (y/N): ")
-			reader := bufio.NewReader(os.Stdin)
-			response, err := reader.ReadString('\n')
+			ok, err := confirm("No .git directory found. Create .agentlink.yaml here anyway?")
 			if err != nil {
-				return fmt.Errorf("failed to read input: %w", err)
+				return err
 			}
-			
-			response = strings.ToLower(strings.TrimSpace(response))
-			if response != "y" && response != "yes" {
+			if !ok {
 				printInfo("Cancelled")
 				return nil
 			}
@@ -72,4 +85,118 @@ func runInit(cmd *cobra.Command, args []string) error {
 	printInfo("Edit the config file and run 'agentlink sync' after creating your source file")
 
 	return nil
-}
\ No newline at end of file
+}
+
+func runInitGlobal() error {
+	configPath := config.GlobalConfigPath()
+
+	// Check if config already exists
+	if _, err := os.Stat(configPath); err == nil {
+		if !force {
+			printError("%s already exists (use --force to overwrite)", configPath)
+			return fmt.Errorf("config file already exists")
+		}
+		printWarning("Overwriting existing %s", configPath)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	detections := tools.DetectHome(homeDir)
+	if len(detections) == 0 {
+		printError("No AI tool directories found in %s", homeDir)
+		printInfo("Looked for:")
+		for _, tool := range tools.Registry {
+			if tool.HomeDir != "" {
+				printInfo("  ~/%s (%s)", tool.HomeDir, tool.DisplayName)
+			}
+		}
+		return fmt.Errorf("no tools detected")
+	}
+
+	for _, d := range detections {
+		switch {
+		case d.IsSymlink:
+			printInfo("Found %s at %s (%s is a symlink)", d.Tool.DisplayName, d.Dir, filepath.Base(d.File))
+		case d.FileExists:
+			printInfo("Found %s at %s (%s exists)", d.Tool.DisplayName, d.Dir, filepath.Base(d.File))
+		default:
+			printInfo("Found %s at %s", d.Tool.DisplayName, d.Dir)
+		}
+	}
+
+	source, links := tools.ProposeGlobal(detections)
+	if source == "" {
+		printError("Every detected instruction file is a symlink, none can be the source")
+		return fmt.Errorf("no source to propose")
+	}
+	if len(links) == 0 {
+		printError("Only one tool detected (%s), nothing to link", source)
+		return fmt.Errorf("nothing to link")
+	}
+
+	fmt.Printf("\nProposed global config:\n")
+	fmt.Printf("  source: %s\n", source)
+	fmt.Printf("  links:\n")
+	for _, link := range links {
+		fmt.Printf("    - %s\n", link)
+	}
+	fmt.Printf("\n")
+
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		printWarning("Source %s does not exist yet, create it before running 'agentlink sync'", source)
+	}
+	for _, link := range links {
+		if info, err := os.Lstat(link); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if differsFromSource(link, source) {
+			printWarning("%s differs from the source, 'agentlink sync --force' would replace its content", link)
+		} else {
+			printInfo("%s matches the source, use 'agentlink sync --force' to replace it with a link", link)
+		}
+	}
+
+	if dryRun {
+		printInfo("Would create %s", configPath)
+		return nil
+	}
+
+	if !force {
+		ok, err := confirm(fmt.Sprintf("Write this config to %s?", configPath))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			printInfo("Cancelled")
+			return nil
+		}
+	}
+
+	if err := config.CreateGlobalConfig(configPath, source, links); err != nil {
+		printError("Failed to create config file: %v", err)
+		return err
+	}
+
+	printOK("Created %s", configPath)
+	printInfo("Run 'agentlink sync' to create the links")
+
+	return nil
+}
+
+// differsFromSource reports whether the content of path differs from the
+// source file
+func differsFromSource(path, source string) bool {
+	linkContent, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	sourceContent, err := os.ReadFile(source)
+	if err != nil {
+		return true
+	}
+
+	return !bytes.Equal(linkContent, sourceContent)
+}
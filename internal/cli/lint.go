@@ -0,0 +1,283 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/refs"
+	"github.com/martinmose/agentlink/internal/render"
+	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/martinmose/agentlink/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check references and size budgets from each link's location",
+	Long: `Check the @imports and relative markdown links in the source, and its
+size against the budget of each tool that reads it.
+
+Tools resolve a reference like @docs/style.md or [guide](docs/guide.md)
+from the directory of the file they read, so a reference that works in the
+source can point to nothing from a link in another directory, such as
+.github/copilot-instructions.md. lint resolves every relative reference of
+the source, and of compose outputs, from each link's directory and reports
+the broken ones with the tools that read the link.
+
+For links with mode copy, render, cursor or copilot, the content the tool
+reads is checked, so a reference rewritten in an agentlink:only section
+is checked as the tool sees it.
+
+Codex truncates its instruction file beyond 32 KiB, and Claude Code and
+Windsurf have limits of their own. The bytes a tool reads, with the files
+it imports for tools that expand @imports, and their tokens as estimated
+offline, are checked against the tool's budget; budgets: in the config
+sets other limits. Exits with a non-zero code if any reference is broken
+or any budget exceeded.`,
+	RunE: runLint,
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+// lintFile is a file a tool reads and the references in it
+type lintFile struct {
+	// Path is where the tool reads the file
+	Path string
+	// Target is the file it is linked to, the same as Path for the source
+	Target string
+	// Tools are the tools that read the file
+	Tools []tools.Tool
+	Refs  []refs.Ref
+	// Copied is set when the tool reads a copy, whose references can
+	// differ from the target's
+	Copied bool
+	// Content is what the tools read
+	Content []byte
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	configPath, isProject := config.FindConfigPath()
+	cfg, err := loadSyncConfig(configPath, isProject)
+	if err != nil {
+		return err
+	}
+	root := config.ConfigBaseDir(cfg.Path)
+	homeDir, _ := os.UserHomeDir()
+
+	files, missing, err := lintFiles(cfg, root, homeDir)
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+	for _, path := range missing {
+		printWarning("%s is missing, run 'agentlink sync' to create it", path)
+	}
+
+	broken := 0
+	for _, file := range files {
+		label := displayPath(file.Path)
+		if len(file.Tools) > 0 {
+			var names []string
+			for _, tool := range file.Tools {
+				names = append(names, tool.DisplayName)
+			}
+			label += " (" + strings.Join(names, ", ") + ")"
+		}
+
+		var problems []string
+		for _, ref := range file.Refs {
+			resolved := refs.Resolve(ref, filepath.Dir(file.Path), root, homeDir)
+			if _, err := os.Stat(resolved); err == nil {
+				continue
+			}
+			if file.Path != file.Target && !file.Copied && !resolvesFromTarget(file, ref, root, homeDir) {
+				// Broken in the target as well, which is reported there
+				continue
+			}
+			problem := fmt.Sprintf("line %d: %s -> %s doesn't exist", ref.Line, ref.Text, displayPath(resolved))
+			if fix := suggestFix(file, ref, root, homeDir); fix != "" {
+				problem += "\n      " + fix
+			}
+			problems = append(problems, problem)
+		}
+
+		switch {
+		case len(problems) > 0:
+			fmt.Printf("%s: %d of %d references broken ✗\n", label, len(problems), len(file.Refs))
+			for _, problem := range problems {
+				fmt.Printf("    %s\n", problem)
+			}
+			broken += len(problems)
+		case verbose || file.Path == file.Target:
+			fmt.Printf("%s: %d references ✓\n", label, len(file.Refs))
+		}
+	}
+
+	over := overBudget(cfg, files, root, homeDir)
+	for _, problem := range over {
+		printError("%s", problem)
+	}
+
+	switch {
+	case broken > 0 && len(over) > 0:
+		return fmt.Errorf("%d broken references, %d budgets exceeded", broken, len(over))
+	case broken > 0:
+		return fmt.Errorf("%d broken references", broken)
+	case len(over) > 0:
+		return fmt.Errorf("%d budgets exceeded", len(over))
+	}
+	printOK("All references resolve from every link, within every tool's budget")
+	return nil
+}
+
+// lintFiles returns the source and compose outputs, then each active link
+// to them, with the references the tools reading them see, and the
+// targets that are missing
+func lintFiles(cfg *config.Config, root, homeDir string) ([]lintFile, []string, error) {
+	var files []lintFile
+	var missing []string
+	contents := make(map[string][]byte)
+	index := make(map[string]int)
+	addTarget := func(path string) error {
+		if _, ok := contents[path]; ok {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			missing = append(missing, path)
+			contents[path] = nil
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		contents[path] = content
+		file := lintFile{Path: path, Target: path, Tools: lintTools(path, root, homeDir, nil), Content: content}
+		file.Refs = refs.Find(filterFor(content, file.Tools))
+		index[path] = len(files)
+		files = append(files, file)
+		return nil
+	}
+	if err := addTarget(cfg.Source); err != nil {
+		return nil, nil, err
+	}
+
+	for _, link := range cfg.AllLinks() {
+		if ok, _ := link.Active(); !ok {
+			continue
+		}
+		// Ignore files have no references
+		if group, ok := cfg.Groups[link.Group]; ok && group.IsIgnore() {
+			continue
+		}
+		if err := addTarget(link.Target); err != nil {
+			return nil, nil, err
+		}
+		if contents[link.Target] == nil {
+			continue
+		}
+		// Configured tools read the target in place
+		if link.Mode == config.ModeConfigure {
+			target := &files[index[link.Target]]
+			for _, tool := range lintTools(link.Path, root, homeDir, link.Tool) {
+				if !hasTool(target.Tools, tool.Name) {
+					target.Tools = append(target.Tools, tool)
+				}
+			}
+			target.Refs = refs.Find(filterFor(target.Content, target.Tools))
+			continue
+		}
+
+		file := lintFile{Path: link.Path, Target: link.Target, Tools: lintTools(link.Path, root, homeDir, link.Tool), Content: contents[link.Target]}
+		if link.IsCopy() {
+			content, err := symlink.CopyContent(link.Path, link.Target, linkOptions(link))
+			if err != nil {
+				return nil, nil, err
+			}
+			file.Refs = refs.Find(content)
+			file.Copied = true
+			file.Content = content
+		} else {
+			file.Refs = refs.Find(filterFor(contents[link.Target], file.Tools))
+		}
+		files = append(files, file)
+	}
+	return files, missing, nil
+}
+
+// lintTools returns the tools that read path: those named, or else those
+// known to read it
+func lintTools(path, root, homeDir string, named []string) []tools.Tool {
+	var list []tools.Tool
+	for _, name := range named {
+		if tool, ok := tools.Lookup(name); ok {
+			list = append(list, tool)
+		}
+	}
+	if len(list) > 0 {
+		return list
+	}
+	return tools.MatchPath(path, root, homeDir)
+}
+
+// filterFor drops the agentlink:only and agentlink:except sections meant
+// for other tools than those given, which the tools read but are not
+// meant to follow. Content with broken markers is returned as it is.
+func filterFor(content []byte, list []tools.Tool) []byte {
+	var names []string
+	for _, tool := range list {
+		names = append(names, tool.Name)
+	}
+	filtered, err := render.Filter(content, names)
+	if err != nil {
+		return content
+	}
+	return filtered
+}
+
+// suggestFix returns how to make a reference that works from the link's
+// target work from the link too: a root-relative markdown link, or the
+// path from the link's directory in a section only the link's tools see
+func suggestFix(file lintFile, ref refs.Ref, root, homeDir string) string {
+	if file.Path == file.Target || !ref.IsRelative() || !resolvesFromTarget(file, ref, root, homeDir) {
+		return ""
+	}
+	resolved := refs.Resolve(ref, filepath.Dir(file.Target), root, homeDir)
+
+	var fixes []string
+	if rel, err := filepath.Rel(root, resolved); err == nil && !strings.HasPrefix(rel, "..") && ref.Kind == refs.KindLink {
+		fixes = append(fixes, "use the root-relative /"+filepath.ToSlash(rel))
+	}
+	if rel, err := filepath.Rel(filepath.Dir(file.Path), resolved); err == nil && len(file.Tools) > 0 {
+		var names []string
+		for _, tool := range file.Tools {
+			names = append(names, tool.Name)
+		}
+		fixes = append(fixes, fmt.Sprintf("give the link mode render and write %s in an <!-- agentlink:only %s --> section", filepath.ToSlash(rel), strings.Join(names, ", ")))
+	}
+	if len(fixes) == 0 {
+		return ""
+	}
+	return "fix: " + strings.Join(fixes, ", or ")
+}
+
+// resolvesFromTarget reports whether a reference points to a file when read
+// from the link's target
+func resolvesFromTarget(file lintFile, ref refs.Ref, root, homeDir string) bool {
+	_, err := os.Stat(refs.Resolve(ref, filepath.Dir(file.Target), root, homeDir))
+	return err == nil
+}
+
+func hasTool(list []tools.Tool, name string) bool {
+	for _, tool := range list {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/state"
+	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/martinmose/agentlink/internal/toolconfig"
+)
+
+// mcpEntry is a server of an mcp group as one tool's MCP config lists it
+type mcpEntry struct {
+	// Config is the tool's MCP config
+	Config toolconfig.MCPConfig
+	// Name is the server's name
+	Name string
+	// Server is the server in the agentlink config
+	Server *config.MCPServer
+	// Entry is the server as the tool writes it, see MCPConfig.Entry
+	Entry []byte
+}
+
+// key is where the state records what was written for the entry, as the
+// config file and the server name
+func (e mcpEntry) key() string {
+	return e.Config.Path + "#" + e.Name
+}
+
+// mcpListings reads each MCP config once, see MCPConfig.Servers
+type mcpListings map[string]*mcpListing
+
+type mcpListing struct {
+	servers map[string][]byte
+	err     error
+}
+
+// servers returns the servers c lists
+func (l mcpListings) servers(c toolconfig.MCPConfig) (map[string][]byte, error) {
+	listing, ok := l[c.Path]
+	if !ok {
+		listing = &mcpListing{}
+		listing.servers, listing.err = c.Servers()
+		l[c.Path] = listing
+	}
+	return listing.servers, listing.err
+}
+
+// mcpEntries returns every server of an mcp group for each of its tools
+func mcpEntries(group *config.Group) ([]mcpEntry, error) {
+	var result []mcpEntry
+	for _, tool := range group.MCPTools() {
+		c, err := toolconfig.MCPConfigFor(tool.Name, group.Root)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range group.Servers.Names() {
+			server := group.Servers[name]
+			result = append(result, mcpEntry{Config: c, Name: name, Server: server, Entry: c.Entry(server)})
+		}
+	}
+	return result, nil
+}
+
+// mcpStatus compares an entry with what its MCP config lists. hash is the
+// hash of what the last sync wrote, so an entry left as written is
+// StatusStale and one edited since StatusModified, as for generated files.
+func mcpStatus(e mcpEntry, servers map[string][]byte, hash string) symlink.LinkStatus {
+	current, ok := servers[e.Name]
+	switch {
+	case !ok:
+		return symlink.StatusMissing
+	case string(current) == string(e.Entry):
+		return symlink.StatusOK
+	case hash != "" && state.Hash(current) == hash:
+		return symlink.StatusStale
+	default:
+		return symlink.StatusModified
+	}
+}
+
+// writeMCPGroups writes the servers of each active mcp group to the MCP
+// config of its tools, and removes the servers agentlink wrote that are no
+// longer listed
+func writeMCPGroups(cfg *config.Config, st *state.State, summary *syncSummary) {
+	current := make(map[string]bool)
+	configs := make(map[string]toolconfig.MCPConfig)
+	writes := make(map[string]config.MCPServers)
+	listings := make(mcpListings)
+
+	for _, group := range cfg.GroupList() {
+		if !group.IsMCP() {
+			continue
+		}
+		list, err := mcpEntries(group)
+		if err != nil {
+			printError("Failed to write group %s: %v", group.Name, err)
+			summary.errors++
+			continue
+		}
+		for _, e := range list {
+			// Servers of inactive groups are left as they are
+			current[e.key()] = true
+		}
+		if ok, _ := group.When.Eval(); !ok {
+			continue
+		}
+
+		for _, e := range list {
+			path := e.Config.Path
+			servers, err := listings.servers(e.Config)
+			if err != nil {
+				printError("Skipped MCP server %s (%v)", e.Name, err)
+				summary.errors++
+				continue
+			}
+			configs[path] = e.Config
+
+			file, managed := st.Get(e.key())
+			status := mcpStatus(e, servers, file.Hash)
+			if !managed && status != symlink.StatusMissing {
+				// A server the user set up under the same name is theirs,
+				// it's neither overwritten nor recorded to be removed later
+				if status == symlink.StatusOK {
+					summary.unchanged++
+					if verbose {
+						printSkip("%s in %s is up to date (not managed by agentlink)", e.Name, path)
+					}
+					continue
+				}
+				printError("MCP server %s in %s is not managed by agentlink (rename or remove it to let agentlink write it)", e.Name, path)
+				summary.errors++
+				continue
+			}
+			switch status {
+			case symlink.StatusOK:
+				summary.unchanged++
+				if verbose {
+					printSkip("%s in %s is up to date", e.Name, path)
+				}
+				if !dryRun {
+					st.AddOwner(e.key(), cfg.Path, state.Hash(e.Entry))
+				}
+				continue
+			case symlink.StatusMissing:
+				summary.created++
+				printCreate("%s (MCP server %s)", path, e.Name)
+			case symlink.StatusStale:
+				summary.fixed++
+				printOK("Updated MCP server %s in %s", e.Name, path)
+			default:
+				if !force {
+					printError("MCP server %s in %s was %v (use --force to overwrite it)", e.Name, path, symlink.ErrModified)
+					summary.errors++
+					continue
+				}
+				summary.fixed++
+				printOK("Replaced MCP server %s in %s", e.Name, path)
+			}
+			if writes[path] == nil {
+				writes[path] = make(config.MCPServers)
+			}
+			writes[path][e.Name] = e.Server
+			if !dryRun {
+				st.AddOwner(e.key(), cfg.Path, state.Hash(e.Entry))
+			}
+		}
+	}
+
+	removes := orphanedMCPServers(cfg, st, current, configs, listings, summary)
+
+	paths := make([]string, 0, len(configs))
+	for path := range configs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if len(writes[path]) == 0 && len(removes[path]) == 0 {
+			continue
+		}
+		if err := configs[path].Write(writes[path], removes[path], dryRun); err != nil {
+			printError("%v", err)
+			summary.errors++
+		}
+	}
+}
+
+// orphanedMCPServers returns, by config file, the servers this config wrote
+// that it no longer lists, and forgets them. Servers edited since are kept,
+// and so are servers other configs also write, as they still list them.
+func orphanedMCPServers(cfg *config.Config, st *state.State, current map[string]bool, configs map[string]toolconfig.MCPConfig, listings mcpListings, summary *syncSummary) map[string][]string {
+	removes := make(map[string][]string)
+	for _, key := range stateMCPKeys(cfg, st) {
+		if current[key] {
+			continue
+		}
+		if sharedMCPServer(st, key) {
+			if !dryRun {
+				st.RemoveOwner(key, cfg.Path)
+			}
+			continue
+		}
+		path, name, _ := strings.Cut(key, "#")
+		c, ok := configs[path]
+		if !ok {
+			if c, ok = toolconfig.MCPConfigAt(path); !ok {
+				continue
+			}
+			configs[path] = c
+		}
+		servers, err := listings.servers(c)
+		if err != nil {
+			printWarning("Kept MCP server %s (%v)", name, err)
+			continue
+		}
+
+		file, _ := st.Get(key)
+		if entry, ok := servers[name]; ok {
+			if state.Hash(entry) != file.Hash {
+				printWarning("Kept MCP server %s in %s (edited since the last sync)", name, path)
+				continue
+			}
+			removes[path] = append(removes[path], name)
+			printOK("Removed MCP server %s from %s", name, path)
+			summary.fixed++
+		}
+		if !dryRun {
+			st.Delete(key)
+		}
+	}
+	return removes
+}
+
+// stateMCPKeys returns the servers the state records as written by cfg
+func stateMCPKeys(cfg *config.Config, st *state.State) []string {
+	var keys []string
+	for key, file := range st.Files {
+		if file.HasOwner(cfg.Path) && strings.Contains(key, "#") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sharedMCPServer reports whether more than one config writes the server
+// recorded at key, e.g. the same server in the global Codex config from two
+// projects
+func sharedMCPServer(st *state.State, key string) bool {
+	file, _ := st.Get(key)
+	return len(file.Owners) > 1
+}
+
+// checkMCP prints the status of each mcp group's servers and reports
+// whether any of them drifted
+func checkMCP(cfg *config.Config, st *state.State) bool {
+	hasProblems := false
+	printed := false
+	listings := make(mcpListings)
+	for _, group := range cfg.GroupList() {
+		if !group.IsMCP() {
+			continue
+		}
+		if !printed {
+			fmt.Printf("MCP servers:\n")
+			printed = true
+		}
+		if ok, _ := group.When.Eval(); !ok {
+			fmt.Printf("  group %s -> skipped (condition false)\n", group.Name)
+			continue
+		}
+
+		list, err := mcpEntries(group)
+		if err != nil {
+			fmt.Printf("  group %s -> %v ✗\n", group.Name, err)
+			hasProblems = true
+			continue
+		}
+		for _, e := range list {
+			fmt.Printf("  %s in %s -> ", e.Name, e.Config.Path)
+			servers, err := listings.servers(e.Config)
+			if err != nil {
+				fmt.Printf("%v ✗\n", err)
+				hasProblems = true
+				continue
+			}
+
+			file, managed := st.Get(e.key())
+			status := mcpStatus(e, servers, file.Hash)
+			switch {
+			case status == symlink.StatusOK:
+				fmt.Printf("%s ✓\n", e.Config.Tool)
+			case !managed && status != symlink.StatusMissing:
+				fmt.Printf("not managed by agentlink ✗\n")
+				hasProblems = true
+			case status == symlink.StatusMissing:
+				fmt.Printf("missing\n")
+				hasProblems = true
+			case status == symlink.StatusStale:
+				fmt.Printf("out of date with group %s ✗\n", group.Name)
+				hasProblems = true
+			default:
+				fmt.Printf("edited since the last sync ✗\n")
+				hasProblems = true
+			}
+		}
+	}
+	return hasProblems
+}
+
+// cleanMCP removes the servers this config wrote that were not edited since
+// the last sync and no other config writes, and returns how many were
+// removed and skipped
+func cleanMCP(cfg *config.Config, st *state.State) (removed, skipped int) {
+	configs := make(map[string]toolconfig.MCPConfig)
+	removes := make(map[string][]string)
+	listings := make(mcpListings)
+	for _, key := range stateMCPKeys(cfg, st) {
+		path, name, _ := strings.Cut(key, "#")
+		if sharedMCPServer(st, key) {
+			if verbose {
+				printSkip("MCP server %s in %s (other configs still write it)", name, path)
+			}
+			st.RemoveOwner(key, cfg.Path)
+			continue
+		}
+		c, ok := toolconfig.MCPConfigAt(path)
+		if !ok {
+			continue
+		}
+		servers, err := listings.servers(c)
+		if err != nil {
+			printWarning("Skipped MCP server %s (%v)", name, err)
+			skipped++
+			continue
+		}
+
+		file, _ := st.Get(key)
+		if entry, ok := servers[name]; ok {
+			if state.Hash(entry) != file.Hash {
+				printWarning("Skipped MCP server %s in %s (edited since the last sync)", name, path)
+				skipped++
+				continue
+			}
+			configs[path] = c
+			removes[path] = append(removes[path], name)
+			printOK("Removed MCP server %s from %s", name, path)
+			removed++
+		}
+		st.Delete(key)
+	}
+
+	for path, names := range removes {
+		if err := configs[path].Write(nil, names, dryRun); err != nil {
+			printWarning("%v", err)
+		}
+	}
+	return removed, skipped
+}
@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/martinmose/agentlink/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -14,9 +18,10 @@ var (
 	date    = "unknown"
 	
 	// Command flags
-	dryRun  bool
-	force   bool
-	verbose bool
+	dryRun      bool
+	force       bool
+	verbose     bool
+	profileName string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -44,6 +49,36 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would be done without making changes")
 	rootCmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "force replacement of conflicting files")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "config profile to use (default $"+config.ProfileEnv+")")
+}
+
+// selectedProfile returns the profile selected by --profile, or else by
+// AGENTLINK_PROFILE, and whether it was given with --profile
+func selectedProfile() (string, bool) {
+	if profileName != "" {
+		return profileName, true
+	}
+	return os.Getenv(config.ProfileEnv), false
+}
+
+// loadConfig loads the config at path with the profile selected by
+// --profile or AGENTLINK_PROFILE. A profile from the environment is ignored
+// by configs that define no profiles, so it can be set once for a machine.
+func loadConfig(path string) (*config.Config, error) {
+	name, explicit := selectedProfile()
+
+	cfg, err := config.LoadConfigProfile(path, name)
+	var unknown *config.UnknownProfileError
+	if errors.As(err, &unknown) && !explicit && len(unknown.Defined) == 0 {
+		cfg, err = config.LoadConfig(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range cfg.Warnings {
+		printWarning("%v", warning)
+	}
+	return cfg, nil
 }
 
 // printInfo prints an info message
@@ -61,6 +96,11 @@ func printCreate(format string, args ...interface{}) {
 	fmt.Printf("[create] "+format+"\n", args...)
 }
 
+// printBootstrap prints a source bootstrap message
+func printBootstrap(format string, args ...interface{}) {
+	fmt.Printf("[bootstrap] "+format+"\n", args...)
+}
+
 // printSkip prints a skip message
 func printSkip(format string, args ...interface{}) {
 	fmt.Printf("[skip] "+format+"\n", args...)
@@ -74,4 +114,17 @@ func printError(format string, args ...interface{}) {
 // printWarning prints a warning message
 func printWarning(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, "[warning] "+format+"\n", args...)
-}
\ No newline at end of file
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) (bool, error) {
+	fmt.Print(question + " (y/N): ")
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes", nil
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/symlink"
+	"github.com/spf13/cobra"
+)
+
+var sourceCmd = &cobra.Command{
+	Use:   "source",
+	Short: "Manage the source file",
+}
+
+var sourceSetCmd = &cobra.Command{
+	Use:   "set <path>",
+	Short: "Make another file the source and retarget every link",
+	Long: `Make another file the source, e.g. switch from CLAUDE.md to AGENTS.md.
+
+Moves the content of the current source into <path>, updates the config
+file (comments are preserved), retargets every link that points to the
+current source and turns the old source into a link.
+
+Fails if <path> already has different content, unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSourceSet,
+}
+
+func init() {
+	sourceCmd.AddCommand(sourceSetCmd)
+	rootCmd.AddCommand(sourceCmd)
+}
+
+func runSourceSet(cmd *cobra.Command, args []string) error {
+	configPath, isProject := config.FindConfigPath()
+	cfg, err := loadSyncConfig(configPath, isProject)
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	newSource, err := config.ExpandPath(args[0], cwd)
+	if err != nil {
+		return err
+	}
+	oldSource := cfg.Source
+
+	if newSource == oldSource {
+		printInfo("%s is already the source", newSource)
+		return nil
+	}
+
+	manager := symlink.NewManager(dryRun, force, verbose)
+
+	if err := manager.ValidateSource(oldSource); err != nil {
+		printError("Current source is not usable: %v", err)
+		return err
+	}
+	if err := checkNewSource(manager, newSource, oldSource); err != nil {
+		printError("%v", err)
+		return err
+	}
+
+	doc, err := sourceDocument(configPath, cfg)
+	if err != nil {
+		printError("Failed to load config: %v", err)
+		return err
+	}
+
+	// Move the content first so the old source and every link keep resolving
+	// until each of them is atomically switched over
+	if err := manager.CopySource(oldSource, newSource); err != nil {
+		printError("Failed to move content: %v", err)
+		return err
+	}
+	printOK("Moved content of %s to %s", oldSource, newSource)
+
+	// Update the config before any link is retargeted, so links never point
+	// to a source the config doesn't name
+	_, definesSource := doc.Values("source")
+	doc.SwapSource(newSource)
+	if !definesSource {
+		// The source came from an extended config, keep the old one linked
+		doc.AddLink(oldSource)
+	}
+	if dryRun {
+		printInfo("Would update %s", doc.Path)
+	} else {
+		if err := doc.Save(); err != nil {
+			printError("Failed to update config: %v", err)
+			return err
+		}
+		printOK("Updated %s", doc.Path)
+	}
+
+	st, err := loadState()
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+
+	hasErrors := false
+	for _, link := range cfg.AllLinks() {
+		linkPath := link.Path
+		if linkPath == newSource || link.Target != oldSource {
+			continue
+		}
+		if link.Mode == config.ModeConfigure {
+			if _, err := unconfigureLink(st, cfg.Path, link, oldSource); err != nil {
+				printError("Failed to reconfigure %s: %v", linkPath, err)
+				hasErrors = true
+				continue
+			}
+			if _, err := configureLink(manager, link, newSource); err != nil {
+				printError("Failed to reconfigure %s: %v", linkPath, err)
+				hasErrors = true
+				continue
+			}
+			recordConfigured(st, cfg.Path, link, newSource)
+			continue
+		}
+		if link.IsFile() {
+			if verbose {
+				printSkip("%s (%s, 'agentlink sync' will refresh it)", linkPath, link.Mode)
+			}
+			continue
+		}
+
+		info := manager.CheckLink(linkPath, oldSource, linkOptions(link))
+		switch info.Status {
+		case symlink.StatusOK, symlink.StatusWrongStyle:
+			if err := manager.ReplaceLink(linkPath, newSource, linkOptions(link)); err != nil {
+				printError("Failed to retarget %s: %v", linkPath, err)
+				hasErrors = true
+				continue
+			}
+			printOK("Retargeted %s -> %s", linkPath, newSource)
+		case symlink.StatusMissing:
+			if verbose {
+				printSkip("%s (missing, 'agentlink sync' will create it)", linkPath)
+			}
+		default:
+			printWarning("Skipped %s (%s), run 'agentlink sync' to fix it", linkPath, info.Status)
+		}
+	}
+
+	if err := manager.ReplaceLink(oldSource, newSource, symlink.Options{}); err != nil {
+		printError("Failed to turn %s into a link: %v", oldSource, err)
+		return err
+	}
+	printOK("Replaced %s with a link -> %s", oldSource, newSource)
+
+	if err := saveState(st); err != nil {
+		printError("%v", err)
+		return err
+	}
+
+	if hasErrors {
+		return fmt.Errorf("source set completed with errors")
+	}
+
+	if dryRun {
+		printInfo("Dry run completed - no changes made")
+	}
+
+	return nil
+}
+
+// checkNewSource makes sure moving the source to newSource loses nothing
+func checkNewSource(manager *symlink.Manager, newSource, oldSource string) error {
+	info, err := os.Lstat(newSource)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", newSource, err)
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		if !manager.CheckLink(newSource, oldSource, symlink.Options{}).LinksToTarget() && !force {
+			return fmt.Errorf("%s is a symlink to another file (use --force to replace it)", newSource)
+		}
+	case info.IsDir():
+		return fmt.Errorf("%s is a directory", newSource)
+	case differsFromSource(newSource, oldSource) && !force:
+		return fmt.Errorf("%s exists with different content (use --force to overwrite it)", newSource)
+	}
+
+	return nil
+}
+
+// sourceDocument loads the config file that defines the source: the local
+// config if it overrides the source, the loaded config otherwise
+func sourceDocument(configPath string, cfg *config.Config) (*config.Document, error) {
+	if cfg.LocalPath != "" {
+		local, err := config.LoadDocument(cfg.LocalPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := local.Values("source"); ok {
+			return local, nil
+		}
+	}
+	return config.LoadDocument(configPath)
+}
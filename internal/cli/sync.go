@@ -3,8 +3,11 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/state"
 	"github.com/martinmose/agentlink/internal/symlink"
 	"github.com/spf13/cobra"
 )
@@ -15,21 +18,64 @@ var syncCmd = &cobra.Command{
 	Long: `Create or fix symlinks to keep instruction files in sync.
 
 Reads .agentlink.yaml in current directory, or falls back to global config
-at ~/.config/agentlink/config.yaml. Creates or fixes symlinks so they point
-to the configured source file.`,
+at ~/.config/agentlink/config.yaml, and makes each link match the source.
+
+Link modes:
+  symlink    a symlink to the source (the default)
+  hardlink   a hardlink, relinked when an editor saves a new file
+  copy       a real copy; one edited since the last sync is left alone,
+             see 'agentlink diff' and 'agentlink absorb', or use --force
+  render     a copy with only the sections meant for the link's tools
+  cursor     a Cursor rule, the filtered source below its frontmatter
+  copilot    a Copilot instructions file, the same way
+  configure  edits the tool's settings to read the source by its name;
+             for Codex that is the global ~/.codex/config.toml
+
+Group types:
+  compose    generates the output from fragments, links point to it
+  agents     translates each definition in dir for the group's tools
+  ignore     links or copies the file to each tool's ignore file
+  mcp        writes the servers into each tool's MCP config
+
+With --recursive, every project config in the current directory and below
+is synced; a link path claimed by more than one config is left alone.
+See the README for details.`,
 	RunE: runSync,
 }
 
+var (
+	syncRecursive bool
+	syncMaxDepth  int
+)
+
 func init() {
+	syncCmd.Flags().BoolVarP(&syncRecursive, "recursive", "r", false, "sync every config in this directory and below")
+	syncCmd.Flags().IntVar(&syncMaxDepth, "max-depth", 5, "how many directory levels --recursive descends")
 	rootCmd.AddCommand(syncCmd)
 }
 
+// syncSummary counts what a sync did to the links
+type syncSummary struct {
+	// configs counts the configs with a usable source
+	configs   int
+	created   int
+	fixed     int
+	unchanged int
+	skipped   int
+	conflicts int
+	errors    int
+}
+
 func runSync(cmd *cobra.Command, args []string) error {
+	if syncRecursive {
+		return runSyncRecursive()
+	}
+
 	// Find config file
 	configPath, isProject := config.FindConfigPath()
 	
-	// Load or create config
-	cfg, err := loadOrCreateConfig(configPath, isProject)
+	// Load config
+	cfg, err := loadSyncConfig(configPath, isProject)
 	if err != nil {
 		return err
 	}
@@ -42,27 +88,291 @@ func runSync(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	st, err := loadState()
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+
 	// Create symlink manager
 	manager := symlink.NewManager(dryRun, force, verbose)
 
-	// Validate source file
-	if err := manager.ValidateSource(cfg.Source); err != nil {
-		printError("Source validation failed: %v", err)
+	summary := &syncSummary{}
+	err = syncConfig(manager, cfg, st, nil, summary)
+	if saveErr := saveState(st); saveErr != nil {
+		printError("%v", saveErr)
+		summary.errors++
+	}
+	if err != nil {
 		return err
 	}
 
+	if summary.errors > 0 {
+		return fmt.Errorf("sync completed with errors")
+	}
+
+	if dryRun {
+		printInfo("Dry run completed - no changes made")
+	}
+
+	return nil
+}
+
+// syncConfig creates a missing source and processes every link of cfg,
+// except the ones in skip. Copies written are recorded in st. Link failures
+// are counted in summary; an error is returned if the source is unusable.
+func syncConfig(manager *symlink.Manager, cfg *config.Config, st *state.State, skip map[string]bool, summary *syncSummary) error {
+	// Bootstrap a missing source file
+	bootstrapped := false
+	if _, err := os.Lstat(cfg.Source); os.IsNotExist(err) {
+		if err := bootstrapSource(manager, cfg); err != nil {
+			printError("Failed to create source: %v", err)
+			return err
+		}
+		bootstrapped = true
+	}
+
+	// Validate source file (a dry-run bootstrap leaves nothing to validate)
+	if !(bootstrapped && dryRun) {
+		if err := manager.ValidateSource(cfg.Source); err != nil {
+			printError("Source validation failed: %v", err)
+			return err
+		}
+	}
+
+	summary.configs++
+
 	printOK("Source: %s", cfg.Source)
+	if verbose && len(cfg.Candidates) > 1 {
+		printInfo("Source chosen among %d candidates: %s", len(cfg.Candidates), cfg.SourceReason)
+	}
+
+	if cfg.ActiveProfile != "" {
+		printInfo("Profile: %s", cfg.ActiveProfile)
+	}
+
+	// Links set up under another profile are retargeted or removed
+	others, err := cfg.OtherProfiles()
+	if err != nil {
+		printWarning("Cannot check links of some other profiles: %v", err)
+	}
+
+	failed := composeGroups(manager, cfg, summary)
+	for name := range bootstrapIgnoreFiles(manager, cfg, summary) {
+		failed[name] = true
+	}
+	translateGroups(manager, cfg, st, summary)
+	writeMCPGroups(cfg, st, summary)
 
 	// Process each link
-	hasErrors := false
-	for _, linkPath := range cfg.Links {
-		if err := processLink(manager, linkPath, cfg.Source); err != nil {
+	for _, link := range cfg.AllLinks() {
+		linkPath := link.Path
+		if skip[linkPath] {
+			printSkip("%s (claimed by more than one config)", linkPath)
+			continue
+		}
+		if ok, reason := link.Active(); !ok {
+			printSkip("%s (condition false: %s)", linkPath, reason)
+			summary.skipped++
+			continue
+		}
+		if failed[link.Group] {
+			printSkip("%s (group %s failed, see above)", linkPath, link.Group)
+			summary.skipped++
+			continue
+		}
+
+		// Symlinks to the source may point to another profile's source
+		if link.Target == cfg.Source && !link.IsFile() && link.Mode != config.ModeConfigure {
+			if previous := profileSource(manager, linkPath, cfg, others); previous != nil {
+				if err := manager.ReplaceLink(linkPath, cfg.Source, linkOptions(link)); err != nil {
+					printError("Failed to retarget %s: %v", linkPath, err)
+					summary.errors++
+					continue
+				}
+				printOK("Retargeted %s -> %s (was %s)", linkPath, cfg.Source, profileLabel(previous.ActiveProfile))
+				summary.fixed++
+				continue
+			}
+		}
+
+		opts := copyOptions(link, st)
+		action, err := processLink(manager, link, link.Target, opts)
+		if err == nil && link.IsFile() {
+			recordCopy(st, link.Path, link.Target, opts)
+		}
+		if err == nil && link.Mode == config.ModeConfigure {
+			recordConfigured(st, cfg.Path, link, link.Target)
+		}
+		switch {
+		case err != nil && link.Optional:
+			printWarning("Skipped optional link %s: %v", linkPath, err)
+			summary.skipped++
+		case err != nil:
 			printError("Failed to process %s: %v", linkPath, err)
-			hasErrors = true
+			summary.errors++
+		case action == "skip":
+			summary.unchanged++
+		case action == "create":
+			summary.created++
+		default:
+			summary.fixed++
 		}
 	}
 
-	if hasErrors {
+	removeProfileLinks(manager, cfg, others, summary)
+
+	return nil
+}
+
+// composeGroups generates the output of each active compose group and
+// returns the groups whose output could not be generated
+func composeGroups(manager *symlink.Manager, cfg *config.Config, summary *syncSummary) map[string]bool {
+	failed := make(map[string]bool)
+	profile, _ := selectedProfile()
+	for _, group := range cfg.GroupList() {
+		if !group.IsCompose() {
+			continue
+		}
+		if ok, _ := group.When.Eval(); !ok {
+			continue
+		}
+
+		fragments, err := cfg.FragmentPaths(group, profile)
+		if err == nil {
+			var action string
+			action, err = manager.Compose(group.Output, fragments)
+			switch action {
+			case "skip":
+				if verbose {
+					printSkip("%s is up to date", group.Output)
+				}
+			case "create":
+				printCreate("%s (composed from %d fragments)", group.Output, len(fragments))
+				summary.created++
+			case "regenerate":
+				printOK("Regenerated %s (fragments changed)", group.Output)
+				summary.fixed++
+			case "replace":
+				printOK("Replaced %s with the composed fragments", group.Output)
+				summary.fixed++
+			}
+		}
+		if err != nil {
+			printError("Failed to generate %s for group %s: %v", group.Output, group.Name, err)
+			summary.errors++
+			failed[group.Name] = true
+		}
+	}
+	return failed
+}
+
+// profileSource returns the other profile whose source the link at linkPath
+// points to, or nil
+func profileSource(manager *symlink.Manager, linkPath string, cfg *config.Config, others []*config.Config) *config.Config {
+	for _, other := range others {
+		if other.Source != cfg.Source && manager.CheckLink(linkPath, other.Source, symlink.Options{}).LinksToTarget() {
+			return other
+		}
+	}
+	return nil
+}
+
+// removeProfileLinks removes the links of other profiles that the active
+// one doesn't have, as long as they still point to that profile's source
+func removeProfileLinks(manager *symlink.Manager, cfg *config.Config, others []*config.Config, summary *syncSummary) {
+	current := map[string]bool{cfg.Source: true}
+	for _, linkPath := range cfg.LinkPaths() {
+		current[linkPath] = true
+	}
+
+	for _, other := range others {
+		for _, linkPath := range other.LinkPaths() {
+			if current[linkPath] || !manager.CheckLink(linkPath, other.Source, symlink.Options{}).LinksToTarget() {
+				continue
+			}
+			if err := manager.RemoveLink(linkPath, other.Source); err != nil {
+				printError("Failed to remove %s: %v", linkPath, err)
+				summary.errors++
+				continue
+			}
+			printOK("Removed %s (only in %s)", linkPath, profileLabel(other.ActiveProfile))
+			summary.fixed++
+			current[linkPath] = true
+		}
+	}
+}
+
+// profileLabel names a profile in messages
+func profileLabel(name string) string {
+	if name == "" {
+		return "no profile"
+	}
+	return "profile " + name
+}
+
+// runSyncRecursive syncs every project config in the current directory and
+// below
+func runSyncRecursive() error {
+	paths, err := config.DiscoverConfigs(".", syncMaxDepth)
+	if err != nil {
+		printError("Failed to search for configs: %v", err)
+		return err
+	}
+	if len(paths) == 0 {
+		printError("No project configs found in this directory or below (max depth %d)", syncMaxDepth)
+		printInfo("Run 'agentlink init' to create one")
+		return fmt.Errorf("no config found")
+	}
+
+	summary := &syncSummary{}
+
+	// Load every config first, conflicts are found across all of them
+	var cfgs []*config.Config
+	for _, path := range paths {
+		cfg, err := loadConfig(path)
+		if err != nil {
+			printError("Failed to load %s: %v", displayPath(path), err)
+			summary.errors++
+			continue
+		}
+		cfgs = append(cfgs, cfg)
+	}
+
+	conflicts := findLinkConflicts(cfgs)
+	for _, conflict := range conflicts {
+		printError("Conflict: %s", conflict.String())
+	}
+	skip := make(map[string]bool, len(conflicts))
+	for _, conflict := range conflicts {
+		skip[conflict.path] = true
+	}
+	summary.conflicts = len(conflicts)
+
+	st, err := loadState()
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+
+	manager := symlink.NewManager(dryRun, force, verbose)
+	for _, cfg := range cfgs {
+		fmt.Println()
+		printInfo("Config: %s", displayPath(cfg.Path))
+		if err := syncConfig(manager, cfg, st, skip, summary); err != nil {
+			summary.errors++
+		}
+	}
+	if err := saveState(st); err != nil {
+		printError("%v", err)
+		summary.errors++
+	}
+
+	fmt.Println()
+	printInfo("Synced %d of %d configs: %d created, %d fixed, %d unchanged, %d skipped, %d conflicts, %d errors",
+		summary.configs, len(paths), summary.created, summary.fixed, summary.unchanged, summary.skipped, summary.conflicts, summary.errors)
+
+	if summary.errors > 0 || summary.conflicts > 0 {
 		return fmt.Errorf("sync completed with errors")
 	}
 
@@ -73,40 +383,174 @@ func runSync(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func loadOrCreateConfig(configPath string, isProject bool) (*config.Config, error) {
-	// Try to load existing config
+// linkConflict is a path claimed by more than one config
+type linkConflict struct {
+	path   string
+	claims []string
+}
+
+func (c linkConflict) String() string {
+	return fmt.Sprintf("%s is %s", displayPath(c.path), strings.Join(c.claims, " and "))
+}
+
+// findLinkConflicts returns the paths that are an active link in more than
+// one config, or a link in one and the source of another
+func findLinkConflicts(cfgs []*config.Config) []linkConflict {
+	type claim struct {
+		config   string
+		isSource bool
+	}
+	claims := make(map[string][]claim)
+	var order []string
+	add := func(path string, c claim) {
+		if _, ok := claims[path]; !ok {
+			order = append(order, path)
+		}
+		claims[path] = append(claims[path], c)
+	}
+
+	for _, cfg := range cfgs {
+		add(cfg.Source, claim{config: cfg.Path, isSource: true})
+		for _, linkPath := range cfg.ActiveLinkPaths() {
+			add(linkPath, claim{config: cfg.Path})
+		}
+	}
+
+	var conflicts []linkConflict
+	for _, path := range order {
+		links, sources := 0, 0
+		for _, c := range claims[path] {
+			if c.isSource {
+				sources++
+			} else {
+				links++
+			}
+		}
+		// Several configs may share a source, but a link has one owner
+		if links == 0 || links+sources < 2 {
+			continue
+		}
+
+		conflict := linkConflict{path: path}
+		for _, c := range claims[path] {
+			role := "a link"
+			if c.isSource {
+				role = "the source"
+			}
+			conflict.claims = append(conflict.claims, fmt.Sprintf("%s in %s", role, displayPath(c.config)))
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// displayPath returns path relative to the current directory when it is
+// below it
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+func loadSyncConfig(configPath string, isProject bool) (*config.Config, error) {
 	if _, err := os.Stat(configPath); err == nil {
-		return config.LoadConfig(configPath)
+		return loadConfig(configPath)
 	}
 
-	// If it's a project config and doesn't exist, error
 	if isProject {
 		printError("No .agentlink.yaml found in current directory")
 		printInfo("Run 'agentlink init' to create one")
 		return nil, fmt.Errorf("no project config found")
 	}
 
-	// Create default global config
-	printInfo("Creating default global config at %s", configPath)
-	if !dryRun {
-		if err := config.CreateDefaultGlobalConfig(configPath); err != nil {
-			printError("Failed to create default config: %v", err)
-			return nil, err
-		}
+	printError("No .agentlink.yaml in current directory and no global config at %s", configPath)
+	printInfo("Run 'agentlink init' to create a project config, or 'agentlink init --global' to set up your personal instruction files")
+	return nil, fmt.Errorf("no config found")
+}
+
+func bootstrapSource(manager *symlink.Manager, cfg *config.Config) error {
+	template, templatePath, err := cfg.ReadTemplate()
+	if err != nil {
+		return err
+	}
+
+	adopted, err := manager.BootstrapSource(cfg.Source, cfg.ActiveLinkPaths(), template)
+	if err != nil {
+		return err
 	}
 
-	printWarning("Please edit %s to configure your source and links", configPath)
-	return nil, fmt.Errorf("created default config - please edit it first")
+	if adopted != "" {
+		printBootstrap("Created source %s from %s", cfg.Source, adopted)
+	} else if templatePath != "" {
+		printBootstrap("Created source %s from template %s", cfg.Source, templatePath)
+	} else {
+		printBootstrap("Created source %s from starter template", cfg.Source)
+	}
+
+	return nil
 }
 
-func processLink(manager *symlink.Manager, linkPath, sourcePath string) error {
+// processLink creates or fixes a single link and returns the action taken
+func processLink(manager *symlink.Manager, link config.Link, sourcePath string, opts symlink.Options) (string, error) {
+	linkPath := link.Path
 	if verbose {
 		printInfo("Processing link: %s", linkPath)
 	}
 
-	action, err := manager.FixLink(linkPath, sourcePath)
+	if link.Mode == config.ModeConfigure {
+		return configureLink(manager, link, sourcePath)
+	}
+
+	action, err := manager.FixLink(linkPath, sourcePath, opts)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if link.IsCopy() {
+		what := "copy"
+		switch link.Mode {
+		case config.ModeRender:
+			what = strings.Join(link.Tool, "/") + " copy"
+		case config.ModeCursor:
+			what = "Cursor rule"
+		case config.ModeCopilot:
+			what = "Copilot instructions file"
+		}
+		switch action {
+		case "skip":
+			if verbose {
+				printSkip("%s is an up to date %s of %s", linkPath, what, sourcePath)
+			}
+		case "create":
+			printCreate("%s (%s of %s)", linkPath, what, sourcePath)
+		case "refresh":
+			printOK("Refreshed %s %s from %s", what, linkPath, sourcePath)
+		case "replace":
+			printOK("Replaced %s with a %s of %s", linkPath, what, sourcePath)
+		}
+		return action, nil
+	}
+
+	if link.Mode == config.ModeHardlink {
+		switch action {
+		case "skip":
+			if verbose {
+				printSkip("%s is already a hardlink to %s", linkPath, sourcePath)
+			}
+		case "create":
+			printCreate("%s (hardlink to %s)", linkPath, sourcePath)
+		case "relink":
+			printOK("Relinked %s to %s (the hardlink was broken)", linkPath, sourcePath)
+		case "replace":
+			printOK("Replaced %s with a hardlink to %s", linkPath, sourcePath)
+		}
+		return action, nil
 	}
 
 	switch action {
@@ -122,7 +566,31 @@ func processLink(manager *symlink.Manager, linkPath, sourcePath string) error {
 		printOK("Replaced %s -> %s", linkPath, sourcePath)
 	case "fix broken":
 		printOK("Fixed broken %s -> %s", linkPath, sourcePath)
+	case "restyle":
+		printOK("Rewrote %s -> %s (%s target)", linkPath, sourcePath, opts.Style)
 	}
 
-	return nil
+	return action, nil
+}
+
+// linkOptions returns the symlink options of a configured link
+func linkOptions(link config.Link) symlink.Options {
+	var opts symlink.Options
+	if link.Style == config.StyleAbsolute {
+		opts.Style = symlink.StyleAbsolute
+	}
+	switch link.Mode {
+	case config.ModeCopy:
+		opts.Mode = symlink.ModeCopy
+		opts.Header = link.Header
+		opts.Append = link.Append
+	case config.ModeRender, config.ModeCursor, config.ModeCopilot:
+		opts.Mode = symlink.ModeRender
+		opts.Header = link.Header
+		opts.Tools = link.RenderTools()
+		opts.Frontmatter = link.FrontmatterText()
+	case config.ModeHardlink:
+		opts.Mode = symlink.ModeHardlink
+	}
+	return opts
 }
\ No newline at end of file
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter holds the values written at the top of a Cursor rule or a
+// Copilot instructions file. The keys are the ones the tools read.
+type Frontmatter struct {
+	Description string     `yaml:"description,omitempty" doc:"What the rule or instructions are about"`
+	Globs       StringList `yaml:"globs,omitempty" doc:"With mode cursor, the files the rule applies to"`
+	AlwaysApply *bool      `yaml:"alwaysApply,omitempty" doc:"With mode cursor, include the rule in every request; defaults to true when there are no globs and no description"`
+	ApplyTo     StringList `yaml:"applyTo,omitempty" doc:"With mode copilot, the files the instructions apply to (default **)"`
+}
+
+// adapterExt is the file name ending each adapter mode writes
+var adapterExt = map[string]string{
+	ModeCursor:  ".mdc",
+	ModeCopilot: ".instructions.md",
+}
+
+// RenderTools returns the tools a link's copy is filtered for by the
+// agentlink:only and agentlink:except markers, or nil if it isn't filtered
+func (l Link) RenderTools() []string {
+	switch l.Mode {
+	case ModeRender:
+		return l.Tool
+	case ModeCursor:
+		return []string{"cursor"}
+	case ModeCopilot:
+		return []string{"copilot"}
+	}
+	return nil
+}
+
+// FrontmatterText returns the YAML frontmatter, without the --- lines, that
+// starts the file written by an adapter mode, or "" for other modes
+func (l Link) FrontmatterText() string {
+	fm := l.Frontmatter
+	if fm == nil {
+		fm = &Frontmatter{}
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value *yaml.Node) {
+		node.Content = append(node.Content, scalarNode(key), value)
+	}
+	switch l.Mode {
+	case ModeCursor:
+		if fm.Description != "" {
+			add("description", scalarNode(fm.Description))
+		}
+		if len(fm.Globs) > 0 {
+			add("globs", scalarNode(strings.Join(fm.Globs, ",")))
+		}
+		alwaysApply := len(fm.Globs) == 0 && fm.Description == ""
+		if fm.AlwaysApply != nil {
+			alwaysApply = *fm.AlwaysApply
+		}
+		add("alwaysApply", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(alwaysApply)})
+	case ModeCopilot:
+		if fm.Description != "" {
+			add("description", scalarNode(fm.Description))
+		}
+		applyTo := "**"
+		if len(fm.ApplyTo) > 0 {
+			applyTo = strings.Join(fm.ApplyTo, ",")
+		}
+		add("applyTo", scalarNode(applyTo))
+	default:
+		return ""
+	}
+
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// checkAdapter returns problems with the frontmatter and path of a link
+func (l Link) checkAdapter() Diagnostics {
+	var diags Diagnostics
+	ext, isAdapter := adapterExt[l.Mode]
+	if !isAdapter {
+		if l.Frontmatter != nil {
+			diags.add(l.Pos, "frontmatter only applies to links with mode %s or %s", ModeCursor, ModeCopilot)
+		}
+		return diags
+	}
+
+	if !strings.HasSuffix(strings.ToLower(filepath.Base(l.Path)), ext) {
+		diags.add(l.Pos, "mode %s writes a %s file, but %s doesn't end in %s", l.Mode, ext, l.Path, ext)
+	}
+	if fm := l.Frontmatter; fm != nil {
+		if l.Mode == ModeCursor && len(fm.ApplyTo) > 0 {
+			diags.add(l.Pos, "applyTo is for mode %s, Cursor rules use globs", ModeCopilot)
+		}
+		if l.Mode == ModeCopilot && (len(fm.Globs) > 0 || fm.AlwaysApply != nil) {
+			diags.add(l.Pos, "globs and alwaysApply are for mode %s, Copilot instructions use applyTo", ModeCursor)
+		}
+	}
+	return diags
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFrontmatterText(t *testing.T) {
+	no := false
+	tests := []struct {
+		name     string
+		link     Link
+		expected string
+	}{
+		{"cursor default", Link{Mode: ModeCursor}, "alwaysApply: true\n"},
+		{
+			"cursor globs",
+			Link{Mode: ModeCursor, Frontmatter: &Frontmatter{Description: "Go rules", Globs: StringList{"**/*.go", "go.mod"}}},
+			"description: Go rules\nglobs: '**/*.go,go.mod'\nalwaysApply: false\n",
+		},
+		{
+			"cursor always apply set",
+			Link{Mode: ModeCursor, Frontmatter: &Frontmatter{AlwaysApply: &no}},
+			"alwaysApply: false\n",
+		},
+		{"copilot default", Link{Mode: ModeCopilot}, "applyTo: '**'\n"},
+		{
+			"copilot apply to",
+			Link{Mode: ModeCopilot, Frontmatter: &Frontmatter{ApplyTo: StringList{"**/*.ts", "**/*.tsx"}}},
+			"applyTo: '**/*.ts,**/*.tsx'\n",
+		},
+		{"other modes", Link{Mode: ModeCopy}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.link.FrontmatterText(); got != tt.expected {
+				t.Errorf("FrontmatterText() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigAdapterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		expected string
+	}{
+		{"cursor path", "  - path: rules.md\n    mode: cursor\n", "mode cursor writes a .mdc file, but "},
+		{"copilot path", "  - path: .github/instructions/go.md\n    mode: copilot\n", "doesn't end in .instructions.md"},
+		{"frontmatter on a symlink", "  - path: CLAUDE.md\n    frontmatter:\n      description: x\n", "frontmatter only applies to links with mode cursor or copilot"},
+		{"applyTo for cursor", "  - path: .cursor/rules/go.mdc\n    mode: cursor\n    frontmatter:\n      applyTo: '**'\n", "applyTo is for mode copilot"},
+		{"globs for copilot", "  - path: go.instructions.md\n    mode: copilot\n    frontmatter:\n      globs: '**'\n", "globs and alwaysApply are for mode cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+			os.WriteFile(configPath, []byte("source: AGENTS.md\nlinks:\n"+tt.link), 0644)
+
+			_, err := LoadConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("LoadConfig() error = %v, expected it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/tools"
+)
+
+// GroupAgents is the type of groups that translate the subagent or command
+// definitions of one tool for others
+const GroupAgents = "agents"
+
+// IsAgents reports whether the group translates definitions
+func (g *Group) IsAgents() bool {
+	return g.Type == GroupAgents
+}
+
+// validateAgents checks that agents groups name a known definitions
+// directory and tools that keep that kind of definition
+func (c *Config) validateAgents() Diagnostics {
+	var diags Diagnostics
+	for _, group := range c.GroupList() {
+		if !group.IsAgents() {
+			continue
+		}
+		if len(group.Tools) == 0 {
+			diags.add(group.Pos, "agents group %s needs tools", group.Name)
+		}
+		if group.Dir == "" {
+			diags.add(group.Pos, "agents group %s needs a dir", group.Name)
+			continue
+		}
+		from, kind, _, ok := tools.MatchDefinitionDir(group.Dir)
+		if !ok {
+			diags.add(group.Pos, "dir of group %s is not a known agents or commands directory (%s)", group.Name, strings.Join(definitionDirs(), ", "))
+			continue
+		}
+
+		for _, name := range group.Tools {
+			tool, ok := diags.checkToolName(group.Pos, name, "for group "+group.Name)
+			switch {
+			case !ok:
+			case tool.Name == from.Name:
+				diags.add(group.Pos, "group %s translates from %s already", group.Name, name)
+			case tool.DefinitionDir(kind) == "":
+				diags.add(group.Pos, "%s has no %s definitions to translate to", name, kind)
+			}
+		}
+	}
+	return diags
+}
+
+// definitionDirs returns the definition directories of the registry
+func definitionDirs() []string {
+	var dirs []string
+	for _, tool := range tools.Registry {
+		for _, dir := range []string{tool.AgentsDir, tool.CommandsDir} {
+			if dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
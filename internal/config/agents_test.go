@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigAgents(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  subagents:
+    type: agents
+    dir: .claude/agents
+    tools: opencode
+`), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	group := cfg.Groups["subagents"]
+	if !group.IsAgents() || group.Dir != filepath.Join(tmpDir, ".claude", "agents") {
+		t.Errorf("group = %+v", group)
+	}
+	if len(cfg.AllLinks()) != 1 {
+		t.Errorf("agents groups should add no links, got %+v", cfg.AllLinks())
+	}
+}
+
+func TestLoadConfigAgentsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   string
+		expected string
+	}{
+		{
+			name:     "unknown dir",
+			groups:   "  subagents:\n    type: agents\n    dir: prompts\n    tools: [opencode]\n",
+			expected: "dir of group subagents is not a known agents or commands directory (.claude/agents, .claude/commands",
+		},
+		{
+			name:     "missing tools",
+			groups:   "  subagents:\n    type: agents\n    dir: .claude/agents\n",
+			expected: "agents group subagents needs tools",
+		},
+		{
+			name:     "tool without definitions",
+			groups:   "  subagents:\n    type: agents\n    dir: .claude/agents\n    tools: [gemini]\n",
+			expected: "gemini has no agent definitions to translate to",
+		},
+		{
+			name:     "unknown tool",
+			groups:   "  subagents:\n    type: agents\n    dir: .claude/agents\n    tools: [opencod]\n",
+			expected: "unknown tool \"opencod\" for group subagents (did you mean \"opencode\"?)",
+		},
+		{
+			name:     "links in an agents group",
+			groups:   "  subagents:\n    type: agents\n    dir: .claude/agents\n    tools: [opencode]\n    links: [GEMINI.md]\n",
+			expected: "group subagents of type agents writes files for its tools and cannot have links",
+		},
+		{
+			name:     "dir without type",
+			groups:   "  subagents:\n    dir: .claude/agents\n    links: [GEMINI.md]\n",
+			expected: "dir only applies to groups with type agents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+			os.WriteFile(configPath, []byte("source: AGENTS.md\nlinks:\n  - CLAUDE.md\ngroups:\n"+tt.groups), 0644)
+
+			_, err := LoadConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("LoadConfig() error = %v, expected it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
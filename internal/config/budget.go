@@ -0,0 +1,102 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/martinmose/agentlink/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// Budget is how much of its instruction file a tool reads, imports
+// included
+type Budget struct {
+	Bytes  int `yaml:"bytes,omitempty" doc:"Most bytes the tool reads, imports included"`
+	Tokens int `yaml:"tokens,omitempty" doc:"Most tokens the tool reads, imports included, as estimated offline"`
+
+	// Pos is where the budget was configured
+	Pos Position `yaml:"-"`
+}
+
+// Budgets maps tool names to budgets
+type Budgets map[string]*Budget
+
+// Names returns the tool names in order
+func (b Budgets) Names() []string {
+	names := make([]string, 0, len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BudgetFor returns the budget of tool: its limits in the registry, with
+// the ones configured under budgets in their place
+func (c *Config) BudgetFor(tool tools.Tool) Budget {
+	budget := Budget{Bytes: tool.MaxBytes, Tokens: tool.MaxTokens}
+	if configured := c.Budgets[tool.Name]; configured != nil {
+		if configured.Bytes > 0 {
+			budget.Bytes = configured.Bytes
+		}
+		if configured.Tokens > 0 {
+			budget.Tokens = configured.Tokens
+		}
+	}
+	return budget
+}
+
+// overlayBudgets merges budgets over c's, each limit replacing the one for
+// the same tool
+func (c *Config) overlayBudgets(budgets Budgets) {
+	for name, budget := range budgets {
+		if budget == nil {
+			continue
+		}
+		if c.Budgets == nil {
+			c.Budgets = make(Budgets)
+		}
+		existing := c.Budgets[name]
+		if existing == nil {
+			copied := *budget
+			c.Budgets[name] = &copied
+			continue
+		}
+		if budget.Bytes > 0 {
+			existing.Bytes = budget.Bytes
+		}
+		if budget.Tokens > 0 {
+			existing.Tokens = budget.Tokens
+		}
+		existing.Pos = budget.Pos
+	}
+}
+
+// budgetPositions records where each budget was configured
+func (c *Config) budgetPositions(mapping *yaml.Node) {
+	budgets := mappingValue(mapping, "budgets")
+	if budgets == nil || budgets.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(budgets.Content); i += 2 {
+		if budget := c.Budgets[budgets.Content[i].Value]; budget != nil {
+			budget.Pos = nodePosition(budgets.Content[i])
+			budget.Pos.File = c.Path
+		}
+	}
+}
+
+// validateBudgets checks that budgets name known tools and positive limits
+func (c *Config) validateBudgets() Diagnostics {
+	var diags Diagnostics
+	for _, name := range c.Budgets.Names() {
+		budget := c.Budgets[name]
+		if budget == nil {
+			continue
+		}
+		diags.checkToolName(budget.Pos, name, "under budgets")
+		if budget.Bytes < 0 || budget.Tokens < 0 {
+			diags.add(budget.Pos, "budget of %s cannot be negative", name)
+		}
+	}
+	return diags
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/martinmose/agentlink/internal/tools"
+)
+
+func TestLoadConfigBudgets(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+budgets:
+  codex:
+    bytes: 65536
+  gemini:
+    tokens: 8000
+`), 0644)
+	// The local config lowers one limit and keeps the other
+	os.WriteFile(LocalConfigPath(configPath), []byte(`budgets:
+  gemini:
+    bytes: 20000
+`), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	tests := []struct {
+		tool     string
+		expected Budget
+	}{
+		{"codex", Budget{Bytes: 65536}},
+		{"gemini", Budget{Bytes: 20000, Tokens: 8000}},
+		{"claude", Budget{Bytes: 40000}},
+		{"opencode", Budget{}},
+	}
+	for _, tt := range tests {
+		tool, _ := tools.Lookup(tt.tool)
+		if got := cfg.BudgetFor(tool); got.Bytes != tt.expected.Bytes || got.Tokens != tt.expected.Tokens {
+			t.Errorf("BudgetFor(%s) = %+v, expected %+v", tt.tool, got, tt.expected)
+		}
+	}
+}
+
+func TestLoadConfigBudgetErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		budgets  string
+		expected string
+	}{
+		{
+			name:     "unknown tool",
+			budgets:  "  codx:\n    bytes: 1000\n",
+			expected: `unknown tool "codx" under budgets (did you mean "codex"?)`,
+		},
+		{
+			name:     "negative limit",
+			budgets:  "  codex:\n    tokens: -1\n",
+			expected: "budget of codex cannot be negative",
+		},
+		{
+			name:     "unknown field",
+			budgets:  "  codex:\n    chars: 1000\n",
+			expected: `unknown field "chars"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+			os.WriteFile(configPath, []byte("source: AGENTS.md\nlinks:\n  - CLAUDE.md\nbudgets:\n"+tt.budgets), 0644)
+
+			_, err := LoadConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("LoadConfig() error = %v, expected it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
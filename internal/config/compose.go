@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GroupCompose is the type of groups that generate their links' target from
+// fragments
+const GroupCompose = "compose"
+
+// Fragments that stand for another config's file rather than a path
+const (
+	// FragmentSource is the source of the config itself
+	FragmentSource = "@source"
+	// FragmentGlobal is the source of the global config
+	FragmentGlobal = "@global"
+)
+
+// IsCompose reports whether the group generates its output from fragments
+func (g *Group) IsCompose() bool {
+	return g.Type == GroupCompose
+}
+
+// FragmentPaths returns the files the output of a compose group is made of,
+// in order, with @source and @global resolved. @global is the source of the
+// global config under profile, the one selected for c, or without a profile
+// if the global config doesn't define it.
+func (c *Config) FragmentPaths(g *Group, profile string) ([]string, error) {
+	paths := make([]string, 0, len(g.Fragments))
+	for _, fragment := range g.Fragments {
+		switch fragment {
+		case FragmentSource:
+			paths = append(paths, c.Source)
+		case FragmentGlobal:
+			if c.Path == GlobalConfigPath() {
+				paths = append(paths, c.Source)
+				continue
+			}
+			global, err := LoadConfigProfile(GlobalConfigPath(), profile)
+			var unknown *UnknownProfileError
+			if errors.As(err, &unknown) {
+				global, err = LoadConfig(GlobalConfigPath())
+			}
+			if err != nil {
+				return nil, fmt.Errorf("fragment %s needs the global config: %w", fragment, err)
+			}
+			paths = append(paths, global.Source)
+		default:
+			paths = append(paths, fragment)
+		}
+	}
+	return paths, nil
+}
+
+// check returns problems with the compose settings of a single file, which
+// may leave some of them to the configs it extends
+func (g *Group) check() Diagnostics {
+	var diags Diagnostics
+	switch g.Type {
+	case "", GroupCompose, GroupAgents, GroupIgnore, GroupMCP:
+	default:
+		diags.add(g.Pos, "unknown type %q for group %s (expected %s, %s, %s or %s)", g.Type, g.Name, GroupCompose, GroupAgents, GroupIgnore, GroupMCP)
+		return diags
+	}
+	if g.Type != GroupCompose && (len(g.Fragments) > 0 || g.Output != "") {
+		diags.add(g.Pos, "fragments and output only apply to groups with type %s", GroupCompose)
+	}
+	if g.Type != GroupAgents && g.Dir != "" {
+		diags.add(g.Pos, "dir only applies to groups with type %s", GroupAgents)
+	}
+	if g.Type != GroupIgnore && (g.File != "" || g.Gitignore) {
+		diags.add(g.Pos, "file and gitignore only apply to groups with type %s", GroupIgnore)
+	}
+	if g.Type != GroupMCP && len(g.Servers) > 0 {
+		diags.add(g.Pos, "servers only apply to groups with type %s", GroupMCP)
+	}
+	if g.Type != GroupAgents && g.Type != GroupIgnore && g.Type != GroupMCP && len(g.Tools) > 0 {
+		diags.add(g.Pos, "tools only applies to groups with type %s, %s or %s", GroupAgents, GroupIgnore, GroupMCP)
+	}
+	if (g.Type == GroupAgents || g.Type == GroupIgnore || g.Type == GroupMCP) && len(g.Links) > 0 {
+		diags.add(g.Pos, "group %s of type %s writes files for its tools and cannot have links", g.Name, g.Type)
+	}
+	for _, fragment := range g.Fragments {
+		switch {
+		case fragment == "":
+			diags.add(g.Pos, "fragments cannot be empty")
+		case strings.HasPrefix(fragment, "@") && fragment != FragmentSource && fragment != FragmentGlobal:
+			diags.add(g.Pos, "unknown fragment %s (expected a path, %s or %s)", fragment, FragmentSource, FragmentGlobal)
+		}
+	}
+	return diags
+}
+
+// validateCompose checks that compose groups are complete and that their
+// outputs don't clash with the source, links or fragments
+func (c *Config) validateCompose() Diagnostics {
+	var diags Diagnostics
+	links := c.AllLinks()
+	for _, group := range c.GroupList() {
+		if !group.IsCompose() {
+			continue
+		}
+		if len(group.Fragments) == 0 {
+			diags.add(group.Pos, "compose group %s needs fragments", group.Name)
+		}
+		if group.Output == "" {
+			diags.add(group.Pos, "compose group %s needs an output", group.Name)
+			continue
+		}
+		for _, source := range c.Sources {
+			if group.Output == source {
+				diags.add(group.Pos, "output of group %s is the source", group.Name)
+			}
+		}
+		for _, fragment := range group.Fragments {
+			if group.Output == fragment {
+				diags.add(group.Pos, "output of group %s is one of its fragments", group.Name)
+			}
+		}
+		for _, link := range links {
+			if group.Output == link.Path {
+				diags.add(link.Pos, "link %s is the output of group %s", link.Path, group.Name)
+			}
+		}
+	}
+	return diags
+}
+
+// expandPaths makes the fragment, output, dir and file paths absolute
+// based on baseDir
+func (g *Group) expandPaths(baseDir string) error {
+	var err error
+	for i, fragment := range g.Fragments {
+		if strings.HasPrefix(fragment, "@") {
+			continue
+		}
+		g.Fragments[i], err = ExpandPath(fragment, baseDir)
+		if err != nil {
+			return fmt.Errorf("failed to expand fragment path %s: %w", fragment, err)
+		}
+	}
+	if g.Output != "" {
+		g.Output, err = ExpandPath(g.Output, baseDir)
+		if err != nil {
+			return fmt.Errorf("failed to expand output path %s: %w", g.Output, err)
+		}
+	}
+	if g.Dir != "" {
+		g.Dir, err = ExpandPath(g.Dir, baseDir)
+		if err != nil {
+			return fmt.Errorf("failed to expand dir path %s: %w", g.Dir, err)
+		}
+	}
+	if g.File != "" {
+		g.File, err = ExpandPath(g.File, baseDir)
+		if err != nil {
+			return fmt.Errorf("failed to expand file path %s: %w", g.File, err)
+		}
+	}
+	g.Root = baseDir
+	return nil
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigCompose(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  combined:
+    type: compose
+    fragments:
+      - ~/team/AGENTS.md
+      - "@source"
+    output: .agentlink/AGENTS.md
+    links:
+      - GEMINI.md
+`), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	output := filepath.Join(tmpDir, ".agentlink", "AGENTS.md")
+	links := cfg.AllLinks()
+	if len(links) != 2 || links[0].Target != cfg.Source || links[1].Target != output {
+		t.Fatalf("AllLinks() targets = %+v", links)
+	}
+
+	home, _ := os.UserHomeDir()
+	fragments, err := cfg.FragmentPaths(cfg.Groups["combined"], "")
+	if err != nil {
+		t.Fatalf("FragmentPaths() failed: %v", err)
+	}
+	expected := []string{filepath.Join(home, "team", "AGENTS.md"), filepath.Join(tmpDir, "AGENTS.md")}
+	if strings.Join(fragments, ",") != strings.Join(expected, ",") {
+		t.Errorf("FragmentPaths() = %v, expected %v", fragments, expected)
+	}
+}
+
+func TestFragmentPathsGlobalProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	globalDir := filepath.Join(home, ".config", "agentlink")
+	os.MkdirAll(globalDir, 0755)
+	os.WriteFile(filepath.Join(globalDir, "config.yaml"), []byte(`source: ~/PERSONAL.md
+links:
+  - ~/.claude/CLAUDE.md
+profiles:
+  work:
+    source: ~/WORK.md
+`), 0644)
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+groups:
+  combined:
+    type: compose
+    fragments: ["@global", "@source"]
+    output: .agentlink/AGENTS.md
+    links: [CLAUDE.md]
+`), 0644)
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	// The global config is resolved with the selected profile, and without
+	// one for a profile it doesn't define
+	for profile, expected := range map[string]string{"work": "WORK.md", "": "PERSONAL.md", "home": "PERSONAL.md"} {
+		fragments, err := cfg.FragmentPaths(cfg.Groups["combined"], profile)
+		if err != nil {
+			t.Fatalf("FragmentPaths(%q) failed: %v", profile, err)
+		}
+		if fragments[0] != filepath.Join(home, expected) {
+			t.Errorf("FragmentPaths(%q) = %v, expected %s first", profile, fragments, expected)
+		}
+	}
+}
+
+func TestLoadConfigComposeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   string
+		expected string
+	}{
+		{
+			name:     "missing output",
+			groups:   "  combined:\n    type: compose\n    fragments: [team.md]\n    links: [GEMINI.md]\n",
+			expected: "compose group combined needs an output",
+		},
+		{
+			name:     "missing fragments",
+			groups:   "  combined:\n    type: compose\n    output: gen.md\n    links: [GEMINI.md]\n",
+			expected: "compose group combined needs fragments",
+		},
+		{
+			name:     "fragments without type",
+			groups:   "  combined:\n    fragments: [team.md]\n    links: [GEMINI.md]\n",
+			expected: "fragments and output only apply to groups with type compose",
+		},
+		{
+			name:     "unknown fragment",
+			groups:   "  combined:\n    type: compose\n    fragments: [\"@team\"]\n    output: gen.md\n    links: [GEMINI.md]\n",
+			expected: "unknown fragment @team",
+		},
+		{
+			name:     "output is the source",
+			groups:   "  combined:\n    type: compose\n    fragments: [team.md]\n    output: AGENTS.md\n    links: [GEMINI.md]\n",
+			expected: "output of group combined is the source",
+		},
+		{
+			name:     "output is a link",
+			groups:   "  combined:\n    type: compose\n    fragments: [team.md]\n    output: CLAUDE.md\n    links: [GEMINI.md]\n",
+			expected: "is the output of group combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+			os.WriteFile(configPath, []byte("source: AGENTS.md\nlinks:\n  - CLAUDE.md\ngroups:\n"+tt.groups), 0644)
+
+			_, err := LoadConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("LoadConfig() error = %v, expected it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
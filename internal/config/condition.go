@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// Condition is a when: clause on a link or group. Every key that is set
+// must hold. Lists under exists, binary and env are all required; lists
+// under hostname and os are alternatives, one of them must match.
+type Condition struct {
+	Exists   StringList `yaml:"exists,omitempty" doc:"Paths that must exist, relative to the project root or starting with ~/"`
+	Binary   StringList `yaml:"binary,omitempty" doc:"Programs that must be on PATH"`
+	Env      StringList `yaml:"env,omitempty" doc:"Environment variables that must be set (NAME) or have a value (NAME=value)"`
+	Hostname StringList `yaml:"hostname,omitempty" doc:"Host name globs such as work-*, one must match"`
+	OS       StringList `yaml:"os,omitempty" doc:"Operating systems as in GOOS (linux, darwin, windows), one must match"`
+}
+
+// Eval reports whether the condition holds. If it doesn't, reason names the
+// part that failed. A nil condition always holds.
+func (c *Condition) Eval() (ok bool, reason string) {
+	if c == nil {
+		return true, ""
+	}
+
+	for _, p := range c.Exists {
+		if _, err := os.Stat(p); err != nil {
+			return false, fmt.Sprintf("%s does not exist", p)
+		}
+	}
+	for _, name := range c.Binary {
+		if _, err := exec.LookPath(name); err != nil {
+			return false, fmt.Sprintf("%s is not on PATH", name)
+		}
+	}
+	for _, env := range c.Env {
+		name, want, hasValue := strings.Cut(env, "=")
+		value, set := os.LookupEnv(name)
+		switch {
+		case !hasValue && (!set || value == ""):
+			return false, fmt.Sprintf("$%s is not set", name)
+		case hasValue && value != want:
+			return false, fmt.Sprintf("$%s is not %q", name, want)
+		}
+	}
+	if len(c.Hostname) > 0 {
+		host, err := os.Hostname()
+		if err != nil {
+			return false, fmt.Sprintf("cannot get the host name: %v", err)
+		}
+		if !matchAny(c.Hostname, host) {
+			return false, fmt.Sprintf("host name %s does not match %s", host, strings.Join(c.Hostname, ", "))
+		}
+	}
+	if len(c.OS) > 0 && !matchAny(c.OS, runtime.GOOS) {
+		return false, fmt.Sprintf("os is %s, not %s", runtime.GOOS, strings.Join(c.OS, ", "))
+	}
+
+	return true, ""
+}
+
+// matchAny reports whether value matches one of the globs
+func matchAny(globs []string, value string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns problems with the condition's values, reported at pos
+func (c *Condition) check(pos Position) Diagnostics {
+	var diags Diagnostics
+	if c == nil {
+		return diags
+	}
+	for _, env := range c.Env {
+		if name, _, _ := strings.Cut(env, "="); name == "" {
+			diags.add(pos, "env condition %q has no variable name", env)
+		}
+	}
+	for _, glob := range c.Hostname {
+		if _, err := path.Match(glob, ""); err != nil {
+			diags.add(pos, "invalid hostname pattern %q", glob)
+		}
+	}
+	for _, value := range append(append(StringList{}, c.Exists...), c.Binary...) {
+		if value == "" {
+			diags.add(pos, "conditions cannot contain empty values")
+		}
+	}
+	return diags
+}
+
+// expandPaths makes the exists paths absolute based on baseDir
+func (c *Condition) expandPaths(baseDir string) error {
+	if c == nil {
+		return nil
+	}
+	for i, p := range c.Exists {
+		expanded, err := ExpandPath(p, baseDir)
+		if err != nil {
+			return fmt.Errorf("failed to expand exists path %s: %w", p, err)
+		}
+		c.Exists[i] = expanded
+	}
+	return nil
+}
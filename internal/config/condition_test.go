@@ -0,0 +1,174 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestConditionEval(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "present"), nil, 0644)
+	t.Setenv("AGENTLINK_TEST_SET", "work")
+	t.Setenv("AGENTLINK_TEST_EMPTY", "")
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		condition *Condition
+		want      bool
+		reason    string
+	}{
+		{"nil", nil, true, ""},
+		{"empty", &Condition{}, true, ""},
+		{"exists", &Condition{Exists: StringList{filepath.Join(tmpDir, "present")}}, true, ""},
+		{"exists missing", &Condition{Exists: StringList{filepath.Join(tmpDir, "present"), filepath.Join(tmpDir, "absent")}}, false, "absent does not exist"},
+		{"binary", &Condition{Binary: StringList{"go"}}, true, ""},
+		{"binary missing", &Condition{Binary: StringList{"agentlink-no-such-binary"}}, false, "agentlink-no-such-binary is not on PATH"},
+		{"env set", &Condition{Env: StringList{"AGENTLINK_TEST_SET"}}, true, ""},
+		{"env empty", &Condition{Env: StringList{"AGENTLINK_TEST_EMPTY"}}, false, "$AGENTLINK_TEST_EMPTY is not set"},
+		{"env unset", &Condition{Env: StringList{"AGENTLINK_TEST_UNSET"}}, false, "$AGENTLINK_TEST_UNSET is not set"},
+		{"env equal", &Condition{Env: StringList{"AGENTLINK_TEST_SET=work"}}, true, ""},
+		{"env not equal", &Condition{Env: StringList{"AGENTLINK_TEST_SET=home"}}, false, `$AGENTLINK_TEST_SET is not "home"`},
+		{"env equal empty", &Condition{Env: StringList{"AGENTLINK_TEST_EMPTY="}}, true, ""},
+		{"hostname glob", &Condition{Hostname: StringList{"no-such-host", host[:1] + "*"}}, true, ""},
+		{"hostname mismatch", &Condition{Hostname: StringList{"no-such-host-*"}}, false, "does not match no-such-host-*"},
+		{"os", &Condition{OS: StringList{"plan9", runtime.GOOS}}, true, ""},
+		{"os mismatch", &Condition{OS: StringList{"plan9"}}, false, "not plan9"},
+		{"all keys must hold", &Condition{OS: StringList{runtime.GOOS}, Env: StringList{"AGENTLINK_TEST_UNSET"}}, false, "not set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.condition.Eval()
+			if got != tt.want {
+				t.Errorf("Eval() = %v, expected %v (reason %q)", got, tt.want, reason)
+			}
+			if !strings.Contains(reason, tt.reason) || (tt.reason == "" && reason != "") {
+				t.Errorf("Eval() reason = %q, expected it to contain %q", reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestLoadConfigGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "team.yaml"), []byte(`source: AGENTS.md
+groups:
+  codex:
+    when:
+      binary: agentlink-no-such-binary
+    links:
+      - ~/.codex/AGENTS.md
+  claude:
+    links:
+      - CLAUDE.md
+      - .claude/CLAUDE.md
+`), 0644)
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`extends: team.yaml
+links:
+  - path: GEMINI.md
+    when:
+      exists: .gemini
+groups:
+  claude:
+    when:
+      os: plan9
+    links:
+      - !remove .claude/CLAUDE.md
+`), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	home, _ := os.UserHomeDir()
+	links := cfg.AllLinks()
+	expected := []struct{ path, group string }{
+		{filepath.Join(tmpDir, "GEMINI.md"), ""},
+		{filepath.Join(home, ".codex", "AGENTS.md"), "codex"},
+		{filepath.Join(tmpDir, "CLAUDE.md"), "claude"},
+	}
+	if len(links) != len(expected) {
+		t.Fatalf("AllLinks() = %v, expected %d links", links, len(expected))
+	}
+	for i, e := range expected {
+		if links[i].Path != e.path || links[i].Group != e.group {
+			t.Errorf("link %d = %s (group %q), expected %s (group %q)", i, links[i].Path, links[i].Group, e.path, e.group)
+		}
+		if ok, _ := links[i].Active(); ok {
+			t.Errorf("link %s should be inactive", links[i].Path)
+		}
+	}
+	if got := links[0].When.Exists[0]; got != filepath.Join(tmpDir, ".gemini") {
+		t.Errorf("exists path = %s, expected it relative to the project", got)
+	}
+	if _, reason := links[1].Active(); reason != "group codex: agentlink-no-such-binary is not on PATH" {
+		t.Errorf("Active() reason = %q", reason)
+	}
+
+	os.Mkdir(filepath.Join(tmpDir, ".gemini"), 0755)
+	if ok, _ := links[0].Active(); !ok {
+		t.Errorf("link %s should be active once .gemini exists", links[0].Path)
+	}
+	if paths := cfg.ActiveLinkPaths(); len(paths) != 1 || paths[0] != links[0].Path {
+		t.Errorf("ActiveLinkPaths() = %v", paths)
+	}
+}
+
+func TestLoadConfigConditionErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  codex:
+    when:
+      binary: codex
+      hostnme: work-*
+    links:
+      - path: ~/.codex/AGENTS.md
+        when:
+          env: "=x"
+`), 0644)
+
+	_, err := LoadConfig(configPath)
+	if err == nil {
+		t.Fatal("LoadConfig() should fail")
+	}
+	for _, want := range []string{
+		`.agentlink.yaml:8:7: unknown field "hostnme" (did you mean "hostname"?)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - path: CLAUDE.md
+    when:
+      env: "=x"
+      hostname: "work-["
+`), 0644)
+	_, err = LoadConfig(configPath)
+	if err == nil {
+		t.Fatal("LoadConfig() should fail")
+	}
+	for _, want := range []string{
+		`.agentlink.yaml:3:5: env condition "=x" has no variable name`,
+		`.agentlink.yaml:3:5: invalid hostname pattern "work-["`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -11,69 +12,757 @@ import (
 
 // Config represents the agentlink configuration
 type Config struct {
-	Source string   `yaml:"source"`
-	Links  []string `yaml:"links"`
+	Version  int        `yaml:"version" doc:"Config format version, 'agentlink config migrate' upgrades older files"` // as written in the file
+	Sources  StringList `yaml:"source" doc:"The file you edit, or an ordered list of candidates: the first existing regular file is the source, the others become links"`
+	Links    []Link     `yaml:"links" doc:"Paths that become symlinks to the source, relative to the project root (or the config file's directory) or starting with ~/"`
+	Groups   Groups     `yaml:"groups" doc:"Named sets of links that share a when: condition, e.g. the files of one tool"`
+	Profiles Profiles   `yaml:"profiles" doc:"Named variants selected with --profile or AGENTLINK_PROFILE, e.g. work and personal: a source replaces the config's, links and groups are merged over it"`
+	Template string     `yaml:"template" doc:"Starter content for a missing source file"`
+	Budgets  Budgets    `yaml:"budgets,omitempty" doc:"Size limits of each tool's instruction file, e.g. codex: {bytes: 65536}, replacing the built-in ones; lint fails and check warns when the source goes over"`
+	Extends  StringList `yaml:"extends" doc:"Configs this one is merged over, e.g. a team baseline: source and template replace theirs, links are added (or removed with !remove)"`
+	Schema   string     `yaml:"$schema,omitempty" doc:"JSON Schema for editors, ignored by agentlink"`
+
+	// Path is the config file this config was loaded from
+	Path string `yaml:"-"`
+	// ExtendsPos is where each entry of Extends was configured
+	ExtendsPos []Position `yaml:"-"`
+	// Files lists every config file merged into this one, bases first
+	Files []string `yaml:"-"`
+	// SourcePos is where the source was configured
+	SourcePos Position `yaml:"-"`
+
+	// Source is the chosen source file, see ResolveSource
+	Source string `yaml:"-"`
+	// SourceReason explains why Source was chosen among the candidates
+	SourceReason string `yaml:"-"`
+	// Candidates describes every source candidate after ResolveSource
+	Candidates []Candidate `yaml:"-"`
+	// LocalPath is the local config merged over this one, if any
+	LocalPath string `yaml:"-"`
+	// ActiveProfile is the profile merged into this config, if any
+	ActiveProfile string `yaml:"-"`
+	// ProfileNames lists every profile defined by the merged files
+	ProfileNames []string `yaml:"-"`
+	// Warnings are problems found by Validate that don't stop the config
+	// from loading
+	Warnings Diagnostics `yaml:"-"`
+
+	// groupOrder lists the group names in the order they were configured
+	groupOrder []string
+	// unmatched holds the !remove links that matched no inherited link,
+	// reported by Validate as warnings
+	unmatched Diagnostics
+	// migrated holds what upgrading older files in memory changed, reported
+	// by Validate as warnings
+	migrated Diagnostics
+	// profileLayers holds each profile of a single file as a layer
+	profileLayers map[string]*Config
+}
+
+// Candidate is a source candidate and what ResolveSource found at its path
+type Candidate struct {
+	Path string
+	Note string
+}
+
+// Link is an entry under links, written as a path or as a mapping with
+// options
+type Link struct {
+	Path        string       `yaml:"path" doc:"Path of the symlink"`
+	When        *Condition   `yaml:"when,omitempty" doc:"Only create the link when this condition holds"`
+	Style       string       `yaml:"style,omitempty" enum:"relative,absolute" doc:"How the symlink's target is written: relative to the link (default) or as an absolute path"`
+	Optional    bool         `yaml:"optional,omitempty" doc:"A link that cannot be created is reported, but doesn't fail sync or check"`
+	Mode        string       `yaml:"mode,omitempty" enum:"symlink,copy,hardlink,render,cursor,copilot,configure" doc:"How the link is made: symlink (default), copy to write a real copy for tools that ignore symlinks, hardlink for tools that refuse symlinks, render to write a copy filtered by the agentlink:only and agentlink:except markers in the source, cursor for a Cursor .mdc rule, copilot for a Copilot .instructions.md file, or configure to set the tool's own setting to read the source instead of linking"`
+	Header      bool         `yaml:"header,omitempty" doc:"With mode copy or render, start the copy with a comment saying it is generated"`
+	Tool        StringList   `yaml:"tool,omitempty" doc:"With mode render, the tools the copy is filtered for, e.g. claude; with mode configure, the tools whose settings are edited; inferred from the path when left out"`
+	Frontmatter *Frontmatter `yaml:"frontmatter,omitempty" doc:"With mode cursor or copilot, the values of the file's frontmatter"`
+	// Remove drops the link from the configs this one extends
+	Remove bool `yaml:"-"`
+
+	// Target is the file the link points to: the source, the output of its
+	// compose group or the file of its ignore group
+	Target string `yaml:"-"`
+	// Append are files whose content a copy gets after the target's, like
+	// the .gitignore of an ignore group
+	Append []string `yaml:"-"`
+	// Group is the group the link belongs to, empty for top-level links
+	Group string `yaml:"-"`
+	// GroupWhen is the condition of the link's group
+	GroupWhen *Condition `yaml:"-"`
+	// Pos is where the link was configured
+	Pos Position `yaml:"-"`
+}
+
+// Group is a named set of links under groups
+type Group struct {
+	When      *Condition `yaml:"when" doc:"Only create the group's links when this condition holds"`
+	Type      string     `yaml:"type,omitempty" enum:"compose,agents,ignore,mcp" doc:"compose generates output from fragments, and the group's links point to output instead of the source; agents translates the subagent or command definitions in dir for other tools; ignore links file to the ignore file of each tool; mcp writes servers to each tool's MCP config"`
+	Fragments StringList `yaml:"fragments,omitempty" doc:"With type compose, the files joined in order into output; @source is this config's source and @global the global config's source"`
+	Output    string     `yaml:"output,omitempty" doc:"With type compose, the generated file"`
+	Dir       string     `yaml:"dir,omitempty" doc:"With type agents, the canonical directory of definitions, e.g. .claude/agents"`
+	File      string     `yaml:"file,omitempty" doc:"With type ignore, the canonical ignore file, linked to each tool's ignore file"`
+	Gitignore bool       `yaml:"gitignore,omitempty" doc:"With type ignore, write copies of file followed by the entries of .gitignore instead of symlinks"`
+	Servers   MCPServers `yaml:"servers,omitempty" doc:"With type mcp, the MCP servers by name, written to each tool's MCP config"`
+	Tools     StringList `yaml:"tools,omitempty" doc:"With type agents, the tools that get a translated copy of each definition, e.g. opencode; with type ignore or mcp, the tools whose files are written (default all that have one)"`
+	Links     []Link     `yaml:"links,omitempty" doc:"Paths that become symlinks to the source"`
+
+	// Name is the group's key under groups
+	Name string `yaml:"-"`
+	// Root is the directory of the config that defined the group, where
+	// the ignore files of an ignore group go
+	Root string `yaml:"-"`
+	// Pos is where the group was configured
+	Pos Position `yaml:"-"`
+}
+
+// Groups maps group names to groups
+type Groups map[string]*Group
+
+// RemoveTag marks a link that removes an inherited link, as in
+// "- !remove AGENTS.md". Formats without tags write "!remove AGENTS.md".
+const RemoveTag = "!remove"
+
+// UnmarshalYAML reads a link written as a plain path or as a mapping
+func (l *Link) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*l = Link{Path: value.Value}
+	case yaml.MappingNode:
+		type plain Link
+		var p plain
+		if err := value.Decode(&p); err != nil {
+			return err
+		}
+		*l = Link(p)
+		if l.Path == "" {
+			return nodeError(value, "a link needs a path")
+		}
+	default:
+		return nodeError(value, "a link must be a path or a mapping with a path")
+	}
+
+	l.Remove = isRemoval(value)
+	if !l.Remove && strings.HasPrefix(l.Path, RemoveTag+" ") {
+		l.Path, l.Remove = strings.TrimSpace(strings.TrimPrefix(l.Path, RemoveTag)), true
+	}
+	l.Pos = nodePosition(value)
+	return nil
 }
 
-// LoadConfig loads configuration from the given path
+// MarshalYAML writes a link as its path, or as a mapping if it has options
+func (l Link) MarshalYAML() (interface{}, error) {
+	if l.Remove {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: RemoveTag, Value: l.Path}, nil
+	}
+	if l.hasOptions() {
+		type plain Link
+		return plain(l), nil
+	}
+	return l.Path, nil
+}
+
+// Link styles and modes
+const (
+	StyleRelative = "relative"
+	StyleAbsolute = "absolute"
+	ModeSymlink   = "symlink"
+	ModeCopy      = "copy"
+	ModeHardlink  = "hardlink"
+	ModeRender    = "render"
+	ModeCursor    = "cursor"
+	ModeCopilot   = "copilot"
+	ModeConfigure = "configure"
+)
+
+// hasOptions reports whether the link needs the mapping form
+func (l Link) hasOptions() bool {
+	return l.When != nil || l.Style != "" || l.Optional || l.Mode != "" || l.Header || len(l.Tool) > 0 || l.Frontmatter != nil
+}
+
+// IsCopy reports whether the link is a copy of its target, made in copy,
+// render or an adapter mode
+func (l Link) IsCopy() bool {
+	switch l.Mode {
+	case ModeCopy, ModeRender, ModeCursor, ModeCopilot:
+		return true
+	}
+	return false
+}
+
+// IsFile reports whether the link is a regular file, made in hardlink mode
+// or as a copy, rather than a symlink
+func (l Link) IsFile() bool {
+	return l.IsCopy() || l.Mode == ModeHardlink
+}
+
+// Active reports whether the link's condition and its group's hold. If
+// not, reason says which part failed.
+func (l Link) Active() (ok bool, reason string) {
+	if ok, reason := l.GroupWhen.Eval(); !ok {
+		return false, "group " + l.Group + ": " + reason
+	}
+	return l.When.Eval()
+}
+
+// isRemoval reports whether node is tagged !remove
+func isRemoval(node *yaml.Node) bool {
+	return node.Tag == RemoveTag
+}
+
+// LinkPaths returns the paths of all links, including the links of groups
+func (c *Config) LinkPaths() []string {
+	links := c.AllLinks()
+	paths := make([]string, len(links))
+	for i, link := range links {
+		paths[i] = link.Path
+	}
+	return paths
+}
+
+// ActiveLinkPaths returns the paths of the links whose conditions hold
+func (c *Config) ActiveLinkPaths() []string {
+	var paths []string
+	for _, link := range c.AllLinks() {
+		if ok, _ := link.Active(); ok {
+			paths = append(paths, link.Path)
+		}
+	}
+	return paths
+}
+
+// AllLinks returns the top-level links followed by the links of each group,
+// in the order they were configured
+func (c *Config) AllLinks() []Link {
+	links := append([]Link{}, c.Links...)
+	for i := range links {
+		links[i].Target = c.Source
+	}
+	for _, group := range c.GroupList() {
+		for _, link := range group.Links {
+			link.Group, link.GroupWhen = group.Name, group.When
+			link.Target = c.Source
+			if group.IsCompose() {
+				link.Target = group.Output
+			}
+			links = append(links, link)
+		}
+		if group.IsIgnore() {
+			links = append(links, group.ignoreLinks()...)
+		}
+	}
+	return links
+}
+
+// GroupList returns the groups in the order they were configured
+func (c *Config) GroupList() []*Group {
+	groups := make([]*Group, 0, len(c.Groups))
+	for _, name := range c.groupOrder {
+		if group, ok := c.Groups[name]; ok {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// StringList is a list of strings that can also be written as a single string
+type StringList []string
+
+// UnmarshalYAML accepts either a scalar or a sequence of scalars
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		var s string
+		if err := value.Decode(&s); err != nil {
+			return err
+		}
+		*l = StringList{s}
+		return nil
+	case yaml.SequenceNode:
+		list := make(StringList, 0, len(value.Content))
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nodeError(item, "expected a string")
+			}
+			list = append(list, item.Value)
+		}
+		*l = list
+		return nil
+	default:
+		return nodeError(value, "expected a string or a list of strings")
+	}
+}
+
+// MarshalYAML writes a single-element list as a plain string
+func (l StringList) MarshalYAML() (interface{}, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+	return []string(l), nil
+}
+
+// DefaultTemplate is the starter content written to a missing source file
+// when neither the config nor the global template file provides one
+const DefaultTemplate = `# Agent Instructions
+
+This file is shared by every AI coding tool in this project through agentlink.
+Edit it here; the other instruction files are links to it.
+
+## Project overview
+
+## Conventions
+
+## Commands
+`
+
+// LocalConfigName is the file name of an uncommitted, per-checkout config
+// that is merged over the project config next to it
+const LocalConfigName = ".agentlink.local.yaml"
+
+// LoadConfig loads configuration from the given path. A project config is
+// merged with the local config next to it, if there is one. Problems in the
+// config are reported as Diagnostics with file:line:col positions.
 func LoadConfig(path string) (*Config, error) {
+	return LoadConfigProfile(path, "")
+}
+
+// LoadConfigProfile loads configuration like LoadConfig, with the named
+// profile merged in. Each file's profile is merged right after the file, so
+// a local config still overrides the project's profile. An empty name
+// selects no profile.
+func LoadConfigProfile(path, profile string) (*Config, error) {
+	layers, err := loadLayers(path, ConfigBaseDir(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	top := layers[len(layers)-1]
+
+	localPath := ""
+	if IsProjectConfig(path) {
+		if _, err := os.Stat(LocalConfigPath(path)); err == nil {
+			localPath = LocalConfigPath(path)
+			localLayers, err := loadLayers(localPath, ConfigBaseDir(path), nil)
+			if err != nil {
+				return nil, err
+			}
+			layers = appendLayers(layers, localLayers)
+		}
+	}
+
+	config := &Config{
+		Version:    top.Version,
+		Extends:    top.Extends,
+		ExtendsPos: top.ExtendsPos,
+		Path:       path,
+		LocalPath:  localPath,
+	}
+	found := false
+	for _, layer := range layers {
+		config.overlay(layer)
+		config.addProfileNames(layer)
+		if p, ok := layer.profileLayers[profile]; ok && profile != "" {
+			config.overlay(p)
+			found = true
+		}
+	}
+	if profile != "" && !found {
+		return nil, &UnknownProfileError{Name: profile, Path: path, Defined: config.ProfileNames}
+	}
+	config.ActiveProfile = profile
+
+	// Validate config
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	config.ResolveSource()
+
+	return config, nil
+}
+
+// readConfig parses a single config file strictly and expands its paths
+// relative to baseDir, except for extends which is relative to the file's
+// own location (see ConfigBaseDir)
+func readConfig(path, baseDir string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	root, err := parseDocument(data, FormatOf(path))
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
 	}
 
-	// Validate config
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid config in %s: %w", path, err)
+	config := Config{Path: path}
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		mapping = root.Content[0]
+		if diags := checkNode(mapping, reflect.TypeOf(config), path); len(diags) > 0 {
+			return nil, diags
+		}
+		// Older files are upgraded in memory, 'agentlink config migrate'
+		// rewrites them
+		version, _, changes, err := migrate(mapping, baseDir)
+		if err != nil {
+			return nil, withFile(err, path)
+		}
+		for _, change := range changes {
+			change.Pos.File = path
+		}
+		config.migrated = changes
+		if err := mapping.Decode(&config); err != nil {
+			return nil, withFile(err, path)
+		}
+		config.Version = version
+		config.ExtendsPos = listPositions(mappingValue(mapping, "extends"))
 	}
 
-	// Expand paths
-	if err := config.ExpandPaths(filepath.Dir(path)); err != nil {
-		return nil, fmt.Errorf("failed to expand paths in %s: %w", path, err)
+	config.Files = []string{path}
+	for i := range config.ExtendsPos {
+		config.ExtendsPos[i].File = path
+	}
+	if err := config.finish(mapping, baseDir); err != nil {
+		return nil, err
+	}
+	for i, base := range config.Extends {
+		if config.Extends[i], err = ExpandPath(base, ConfigBaseDir(path)); err != nil {
+			return nil, fmt.Errorf("failed to expand extends path %s in %s: %w", base, path, err)
+		}
+	}
+
+	// Each profile becomes a layer of its own, merged over this file when
+	// the profile is active
+	if profiles := mappingValue(mapping, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name := profiles.Content[i].Value
+			profile := config.Profiles[name]
+			if profile == nil {
+				continue
+			}
+			layer := &Config{Path: path, Sources: profile.Sources, Links: profile.Links, Groups: profile.Groups}
+			if err := layer.finish(profiles.Content[i+1], baseDir); err != nil {
+				return nil, err
+			}
+			if config.profileLayers == nil {
+				config.profileLayers = make(map[string]*Config)
+			}
+			config.profileLayers[name] = layer
+			config.ProfileNames = append(config.ProfileNames, name)
+		}
 	}
 
 	return &config, nil
 }
 
-// Validate validates the configuration
-func (c *Config) Validate() error {
-	if c.Source == "" {
-		return fmt.Errorf("source cannot be empty")
+// finish completes a config decoded from mapping in the file c.Path: it
+// records positions, checks the entries and expands paths relative to
+// baseDir
+func (c *Config) finish(mapping *yaml.Node, baseDir string) error {
+	path := c.Path
+	if source := mappingValue(mapping, "source"); source != nil {
+		c.SourcePos = nodePosition(source)
+	}
+	c.budgetPositions(mapping)
+	if groups := mappingValue(mapping, "groups"); groups != nil && groups.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(groups.Content); i += 2 {
+			name := groups.Content[i].Value
+			if group := c.Groups[name]; group != nil {
+				group.Name, group.Pos = name, nodePosition(groups.Content[i])
+				c.groupOrder = append(c.groupOrder, name)
+			}
+		}
+	}
+
+	c.SourcePos.File = path
+	for i := range c.Links {
+		c.Links[i].Pos.File = path
+	}
+	for _, group := range c.Groups {
+		group.Pos.File = path
+		for i := range group.Links {
+			group.Links[i].Pos.File = path
+		}
+	}
+
+	if diags := c.validateEntries(); len(diags) > 0 {
+		return diags
+	}
+
+	// Expand paths, remembering how links were written for diagnostics
+	written := make([]string, len(c.Links))
+	for i, link := range c.Links {
+		written[i] = link.Path
+	}
+	if err := c.ExpandPaths(baseDir); err != nil {
+		return fmt.Errorf("failed to expand paths in %s: %w", path, err)
 	}
-	if len(c.Links) == 0 {
-		return fmt.Errorf("links cannot be empty")
+	c.inferTools(baseDir)
+
+	if diags := c.checkDuplicateLinks(written); len(diags) > 0 {
+		return diags
 	}
 	return nil
 }
 
+// overlay merges o over c: a source or template in o replaces c's, links in
+// o are added to c's, or removed from them if marked with !remove. Links to
+// a replacing source are dropped, so a config can make an inherited link its
+// source.
+func (c *Config) overlay(o *Config) {
+	c.Files = append(c.Files, o.Files...)
+	c.migrated = append(c.migrated, o.migrated...)
+
+	if len(o.Sources) > 0 {
+		for _, source := range o.Sources {
+			c.Links = removeLink(c.Links, source)
+			for _, group := range c.Groups {
+				group.Links = removeLink(group.Links, source)
+			}
+		}
+		c.Sources = o.Sources
+		c.SourcePos = o.SourcePos
+	}
+	if o.Template != "" {
+		c.Template = o.Template
+	}
+	c.overlayBudgets(o.Budgets)
+
+	var unmatched []Link
+	c.Links, unmatched = mergeLinks(c.Links, o.Links)
+	c.addUnmatched(unmatched, o.Sources, "")
+
+	// Groups with the same name are merged like the top-level links, a
+	// condition replaces the inherited one
+	for _, group := range o.GroupList() {
+		existing, ok := c.Groups[group.Name]
+		if !ok {
+			if c.Groups == nil {
+				c.Groups = make(Groups)
+			}
+			merged := *group
+			merged.Links, unmatched = mergeLinks(nil, group.Links)
+			c.addUnmatched(unmatched, o.Sources, " of group "+group.Name)
+			merged.Servers = nil
+			for name, server := range group.Servers {
+				if merged.Servers == nil {
+					merged.Servers = make(MCPServers)
+				}
+				merged.Servers[name] = server
+			}
+			c.Groups[group.Name] = &merged
+			c.groupOrder = append(c.groupOrder, group.Name)
+			continue
+		}
+		if group.When != nil {
+			existing.When = group.When
+		}
+		if group.Type != "" {
+			existing.Type = group.Type
+		}
+		if len(group.Fragments) > 0 {
+			existing.Fragments = group.Fragments
+		}
+		if group.Output != "" {
+			existing.Output = group.Output
+		}
+		if group.Dir != "" {
+			existing.Dir = group.Dir
+		}
+		if group.File != "" {
+			existing.File = group.File
+		}
+		if group.Gitignore {
+			existing.Gitignore = true
+		}
+		for name, server := range group.Servers {
+			if existing.Servers == nil {
+				existing.Servers = make(MCPServers)
+			}
+			existing.Servers[name] = server
+		}
+		if group.Root != "" {
+			existing.Root = group.Root
+		}
+		if len(group.Tools) > 0 {
+			existing.Tools = group.Tools
+		}
+		existing.Links, unmatched = mergeLinks(existing.Links, group.Links)
+		c.addUnmatched(unmatched, o.Sources, " of group "+group.Name)
+	}
+}
+
+// mergeLinks adds more to links, or removes the ones marked with !remove.
+// It returns the removals that matched no link too.
+func mergeLinks(links, more []Link) ([]Link, []Link) {
+	existing := make(map[string]bool, len(links))
+	for _, link := range links {
+		existing[link.Path] = true
+	}
+	var unmatched []Link
+	for _, link := range more {
+		switch {
+		case link.Remove && existing[link.Path]:
+			links = removeLink(links, link.Path)
+			existing[link.Path] = false
+		case link.Remove:
+			unmatched = append(unmatched, link)
+		case !existing[link.Path]:
+			links = append(links, link)
+			existing[link.Path] = true
+		}
+	}
+	return links, unmatched
+}
+
+// addUnmatched reports removals that matched no inherited link, leaving
+// out links dropped for a replacing source
+func (c *Config) addUnmatched(unmatched []Link, sources StringList, where string) {
+	for _, link := range unmatched {
+		dropped := false
+		for _, source := range sources {
+			dropped = dropped || link.Path == source
+		}
+		if !dropped {
+			c.unmatched.add(link.Pos, "%s %s matches no inherited link%s", RemoveTag, link.Path, where)
+		}
+	}
+}
+
+// removeLink returns links without the one at path
+func removeLink(links []Link, path string) []Link {
+	kept := make([]Link, 0, len(links))
+	for _, link := range links {
+		if link.Path != path {
+			kept = append(kept, link)
+		}
+	}
+	return kept
+}
+
+// ResolveSource chooses the source among the candidates: the first one that
+// exists as a regular file wins, or the first candidate if none does yet (so
+// sync can bootstrap it). Every other candidate is added to the links, and a
+// link repeating the chosen one is dropped.
+func (c *Config) ResolveSource() {
+	c.Source = ""
+	c.Candidates = make([]Candidate, len(c.Sources))
+
+	for i, path := range c.Sources {
+		c.Candidates[i].Path = path
+
+		if c.Source != "" {
+			c.Candidates[i].Note = "linked to the chosen source"
+			continue
+		}
+
+		info, err := os.Lstat(path)
+		switch {
+		case err != nil:
+			c.Candidates[i].Note = "missing"
+		case info.Mode()&os.ModeSymlink != 0:
+			c.Candidates[i].Note = "symlink"
+		case !info.Mode().IsRegular():
+			c.Candidates[i].Note = "not a regular file"
+		default:
+			c.Source = path
+			if i == 0 {
+				c.SourceReason = "first candidate exists as a regular file"
+			} else {
+				c.SourceReason = fmt.Sprintf("first existing regular file, skipped %d earlier candidate", i)
+				if i > 1 {
+					c.SourceReason += "s"
+				}
+			}
+			c.Candidates[i].Note = "chosen"
+		}
+	}
+
+	if c.Source == "" && len(c.Sources) > 0 {
+		c.Source = c.Sources[0]
+		c.SourceReason = "no candidate is a regular file yet, using the first"
+		c.Candidates[0].Note = "chosen (" + c.Candidates[0].Note + ")"
+	}
+
+	if len(c.Sources) == 1 {
+		c.SourceReason = "only candidate"
+	}
+
+	if len(c.Sources) > 1 {
+		c.Links = removeLink(c.Links, c.Source)
+		for _, group := range c.Groups {
+			group.Links = removeLink(group.Links, c.Source)
+		}
+	}
+
+	// Unchosen candidates become links
+	existing := make(map[string]bool, len(c.Links))
+	for _, link := range c.Links {
+		existing[link.Path] = true
+	}
+	for _, path := range c.Sources {
+		if path != c.Source && !existing[path] {
+			c.Links = append(c.Links, Link{Path: path, Pos: c.SourcePos})
+			existing[path] = true
+		}
+	}
+}
+
 // ExpandPaths expands ~ and makes relative paths absolute based on configDir
 func (c *Config) ExpandPaths(configDir string) error {
 	var err error
 	
-	// Expand source path
-	c.Source, err = expandPath(c.Source, configDir)
-	if err != nil {
-		return fmt.Errorf("failed to expand source path: %w", err)
+	// Expand source candidate paths
+	for i, source := range c.Sources {
+		c.Sources[i], err = ExpandPath(source, configDir)
+		if err != nil {
+			return fmt.Errorf("failed to expand source path %s: %w", source, err)
+		}
 	}
 
-	// Expand link paths
-	for i, link := range c.Links {
-		c.Links[i], err = expandPath(link, configDir)
+	// Expand template path
+	if c.Template != "" {
+		c.Template, err = ExpandPath(c.Template, configDir)
 		if err != nil {
-			return fmt.Errorf("failed to expand link path %s: %w", link, err)
+			return fmt.Errorf("failed to expand template path: %w", err)
 		}
 	}
 
+	// Expand link paths
+	if err := expandLinks(c.Links, configDir); err != nil {
+		return err
+	}
+	for _, group := range c.Groups {
+		if err := group.When.expandPaths(configDir); err != nil {
+			return err
+		}
+		if err := group.expandPaths(configDir); err != nil {
+			return err
+		}
+		if err := expandLinks(group.Links, configDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandLinks expands the paths of links and of their conditions
+func expandLinks(links []Link, configDir string) error {
+	var err error
+	for i, link := range links {
+		links[i].Path, err = ExpandPath(link.Path, configDir)
+		if err != nil {
+			return fmt.Errorf("failed to expand link path %s: %w", link.Path, err)
+		}
+		if err := link.When.expandPaths(configDir); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// expandPath expands ~ and makes relative paths absolute
-func expandPath(path, baseDir string) (string, error) {
+// ExpandPath expands ~ and makes relative paths absolute based on baseDir
+func ExpandPath(path, baseDir string) (string, error) {
 	// Handle ~ expansion
 	if strings.HasPrefix(path, "~/") {
 		homeDir, err := os.UserHomeDir()
@@ -92,61 +781,112 @@ func expandPath(path, baseDir string) (string, error) {
 }
 
 // FindConfigPath finds the appropriate config file path
-// Returns project config (.agentlink.yaml) if exists, otherwise global config path
+// Returns the project config (see ProjectConfigNames) if one exists, otherwise global config path
 func FindConfigPath() (string, bool) {
 	// Check for project config first
-	projectConfig := ".agentlink.yaml"
-	if _, err := os.Stat(projectConfig); err == nil {
-		abs, _ := filepath.Abs(projectConfig)
-		return abs, true
+	if configs := ProjectConfigs("."); len(configs) > 0 {
+		return configs[0], true
 	}
 
 	// Return global config path (may not exist yet)
+	return GlobalConfigPath(), false
+}
+
+// GlobalConfigPath returns the path of the global config file: the first of
+// config.yaml, config.yml, config.toml and config.json that exists in
+// ~/.config/agentlink, config.yaml if none does
+func GlobalConfigPath() string {
+	homeDir, _ := os.UserHomeDir()
+	dir := filepath.Join(homeDir, ".config", "agentlink")
+	for _, name := range []string{"config.yaml", "config.yml", "config.toml", "config.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+// GlobalTemplatePath returns the path of the global starter template, used
+// for new source files when a config sets no template of its own
+func GlobalTemplatePath() string {
 	homeDir, _ := os.UserHomeDir()
-	globalConfig := filepath.Join(homeDir, ".config", "agentlink", "config.yaml")
-	return globalConfig, false
+	return filepath.Join(homeDir, ".config", "agentlink", "template.md")
 }
 
-// CreateDefaultGlobalConfig creates a default global config with examples
-func CreateDefaultGlobalConfig(path string) error {
+// ReadTemplate returns the starter content for a missing source file and the
+// template file it came from. The config's template takes precedence, then
+// the global template file, then DefaultTemplate (with an empty path).
+func (c *Config) ReadTemplate() ([]byte, string, error) {
+	if c.Template != "" {
+		data, err := os.ReadFile(c.Template)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read template %s: %w", c.Template, err)
+		}
+		return data, c.Template, nil
+	}
+
+	globalTemplate := GlobalTemplatePath()
+	if data, err := os.ReadFile(globalTemplate); err == nil {
+		return data, globalTemplate, nil
+	}
+
+	return []byte(DefaultTemplate), "", nil
+}
+
+// CreateGlobalConfig writes a global config with the given source and links.
+// Paths inside the home directory are written in ~/ form.
+func CreateGlobalConfig(path, source string, links []string) error {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	defaultConfig := `# Agentlink global configuration
-# This file was auto-created. Uncomment and modify as needed.
-
-# Example: Use a file in your Claude config as source
-# source: ~/.config/claude/CLAUDE.md
-# links:
-#   - ~/.config/opencode/AGENTS.md
-#   - ~/.config/some-tool/INSTRUCTIONS.md
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
 
-source: ~/.config/agentlink/INSTRUCTIONS.md
-links:
-  - ~/.config/agentlink/CLAUDE.md
-  - ~/.config/agentlink/AGENTS.md
-`
+	var b strings.Builder
+	b.WriteString(SchemaModeline + "\n")
+	b.WriteString("# Agentlink global configuration\n")
+	b.WriteString("# Generated by 'agentlink init --global' from the tools found in your home directory.\n")
+	b.WriteString("# The source is the file you edit; every link becomes a symlink to it.\n")
+	fmt.Fprintf(&b, "version: %d\n", CurrentVersion)
+	fmt.Fprintf(&b, "source: %s\n", tildePath(source, homeDir))
+	b.WriteString("links:\n")
+	for _, link := range links {
+		fmt.Fprintf(&b, "  - %s\n", tildePath(link, homeDir))
+	}
 
-	if err := os.WriteFile(path, []byte(defaultConfig), 0644); err != nil {
-		return fmt.Errorf("failed to write default config: %w", err)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write global config: %w", err)
 	}
 
 	return nil
 }
 
+// tildePath rewrites a path inside homeDir to ~/ form
+func tildePath(path, homeDir string) string {
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || rel == "." || isOutside(rel) {
+		return path
+	}
+	return "~/" + filepath.ToSlash(rel)
+}
+
 // CreateProjectConfig creates a project config file
 func CreateProjectConfig(path string) error {
-	config := `# Choose the file you actually edit as the source:
+	config := fmt.Sprintf(`%s
+version: %d
+
+# Choose the file you actually edit as the source:
 source: CLAUDE.md
 links:
-  - AGENTS.md                    # Root level
-  - OPENCODE.md                  # Root level
-  # - .agent/AGENTS.md           # Inside .agent directory  
-  # - .codex/instructions.md     # Different name and location
-  # - config/ai/GEMINI.md        # Nested directories
-`
+  - AGENTS.md                    # Codex CLI, OpenCode
+  - GEMINI.md                    # Gemini CLI
+  # - .github/copilot-instructions.md  # GitHub Copilot, in a subdirectory
+  # - .windsurfrules             # Windsurf
+`, SchemaModeline, CurrentVersion)
 
 	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
 		return fmt.Errorf("failed to write project config: %w", err)
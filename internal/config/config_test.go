@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -38,38 +39,87 @@ links:
 	}
 	
 	expectedLink1 := filepath.Join(tmpDir, "link1.md")
-	if cfg.Links[0] != expectedLink1 {
-		t.Errorf("Expected first link %s, got %s", expectedLink1, cfg.Links[0])
+	if cfg.Links[0].Path != expectedLink1 {
+		t.Errorf("Expected first link %s, got %s", expectedLink1, cfg.Links[0].Path)
 	}
 }
 
 func TestValidateConfig(t *testing.T) {
 	tests := []struct {
-		name    string
-		config  Config
-		wantErr bool
+		name        string
+		config      Config
+		wantErr     bool
+		wantWarning bool
 	}{
 		{
 			name: "valid config",
 			config: Config{
-				Source: "test.md",
-				Links:  []string{"link1.md", "link2.md"},
+				Sources: StringList{"test.md"},
+				Links:   []Link{{Path: "link1.md"}, {Path: "link2.md"}},
 			},
 			wantErr: false,
 		},
 		{
 			name: "empty source",
 			config: Config{
-				Source: "",
-				Links:  []string{"link1.md"},
+				Sources: StringList{},
+				Links:   []Link{{Path: "link1.md"}},
 			},
 			wantErr: true,
 		},
 		{
 			name: "empty links",
 			config: Config{
-				Source: "test.md",
-				Links:  []string{},
+				Sources: StringList{"test.md"},
+				Links:   []Link{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "candidates without links",
+			config: Config{
+				Sources: StringList{"AGENTS.md", "CLAUDE.md"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty candidate",
+			config: Config{
+				Sources: StringList{"AGENTS.md", ""},
+				Links:   []Link{{Path: "link1.md"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "link is the source",
+			config: Config{
+				Sources: StringList{"/p/AGENTS.md"},
+				Links:   []Link{{Path: "/p/AGENTS.md"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "link is a candidate",
+			config: Config{
+				Sources: StringList{"/p/AGENTS.md", "/p/CLAUDE.md"},
+				Links:   []Link{{Path: "/p/CLAUDE.md"}},
+			},
+			wantErr:     false,
+			wantWarning: true,
+		},
+		{
+			name: "link inside another link",
+			config: Config{
+				Sources: StringList{"/p/AGENTS.md"},
+				Links:   []Link{{Path: "/p/.claude"}, {Path: "/p/.claude/CLAUDE.md"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate link",
+			config: Config{
+				Sources: StringList{"/p/AGENTS.md"},
+				Links:   []Link{{Path: "/p/CLAUDE.md"}, {Path: "/p/CLAUDE.md"}},
 			},
 			wantErr: true,
 		},
@@ -81,6 +131,9 @@ func TestValidateConfig(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if (len(tt.config.Warnings) > 0) != tt.wantWarning {
+				t.Errorf("Validate() warnings = %v, wantWarning %v", tt.config.Warnings, tt.wantWarning)
+			}
 		})
 	}
 }
@@ -94,8 +147,8 @@ func TestExpandPaths(t *testing.T) {
 	tmpDir := t.TempDir()
 	
 	config := Config{
-		Source: "~/test.md",
-		Links:  []string{"relative.md", "~/absolute.md"},
+		Sources: StringList{"~/test.md"},
+		Links:   []Link{{Path: "relative.md"}, {Path: "~/absolute.md"}},
 	}
 	
 	err = config.ExpandPaths(tmpDir)
@@ -104,18 +157,18 @@ func TestExpandPaths(t *testing.T) {
 	}
 	
 	expectedSource := filepath.Join(homeDir, "test.md")
-	if config.Source != expectedSource {
-		t.Errorf("Expected source %s, got %s", expectedSource, config.Source)
+	if config.Sources[0] != expectedSource {
+		t.Errorf("Expected source %s, got %s", expectedSource, config.Sources[0])
 	}
 	
 	expectedRelative := filepath.Join(tmpDir, "relative.md")
-	if config.Links[0] != expectedRelative {
-		t.Errorf("Expected relative link %s, got %s", expectedRelative, config.Links[0])
+	if config.Links[0].Path != expectedRelative {
+		t.Errorf("Expected relative link %s, got %s", expectedRelative, config.Links[0].Path)
 	}
 	
 	expectedAbsolute := filepath.Join(homeDir, "absolute.md")
-	if config.Links[1] != expectedAbsolute {
-		t.Errorf("Expected absolute link %s, got %s", expectedAbsolute, config.Links[1])
+	if config.Links[1].Path != expectedAbsolute {
+		t.Errorf("Expected absolute link %s, got %s", expectedAbsolute, config.Links[1].Path)
 	}
 }
 
@@ -155,4 +208,153 @@ func TestFindConfigPath(t *testing.T) {
 	if path != expectedProjectPath {
 		t.Errorf("Expected project path %s, got %s", expectedProjectPath, path)
 	}
-}
\ No newline at end of file
+}
+
+func TestCreateGlobalConfig(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("Cannot get home directory")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "agentlink", "config.yaml")
+	source := filepath.Join(homeDir, ".claude", "CLAUDE.md")
+	links := []string{filepath.Join(homeDir, ".codex", "AGENTS.md"), "/etc/agents/AGENTS.md"}
+
+	if err := CreateGlobalConfig(configPath, source, links); err != nil {
+		t.Fatalf("CreateGlobalConfig() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"source: ~/.claude/CLAUDE.md", "  - ~/.codex/AGENTS.md", "  - /etc/agents/AGENTS.md"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Global config missing %q:\n%s", expected, data)
+		}
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load generated config: %v", err)
+	}
+	if cfg.Source != source {
+		t.Errorf("Expected source %s, got %s", source, cfg.Source)
+	}
+}
+
+func TestReadTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cfg := Config{}
+	data, path, err := cfg.ReadTemplate()
+	if err != nil {
+		t.Fatalf("ReadTemplate() failed: %v", err)
+	}
+	if string(data) != DefaultTemplate || path != "" {
+		t.Errorf("Expected built-in template, got %q from %q", data, path)
+	}
+
+	globalTemplate := GlobalTemplatePath()
+	os.MkdirAll(filepath.Dir(globalTemplate), 0755)
+	os.WriteFile(globalTemplate, []byte("global"), 0644)
+
+	data, path, err = cfg.ReadTemplate()
+	if err != nil || string(data) != "global" || path != globalTemplate {
+		t.Errorf("Expected global template, got %q from %q (err %v)", data, path, err)
+	}
+
+	projectTemplate := filepath.Join(t.TempDir(), "template.md")
+	os.WriteFile(projectTemplate, []byte("project"), 0644)
+	cfg.Template = projectTemplate
+
+	data, path, err = cfg.ReadTemplate()
+	if err != nil || string(data) != "project" || path != projectTemplate {
+		t.Errorf("Expected project template, got %q from %q (err %v)", data, path, err)
+	}
+
+	cfg.Template = filepath.Join(t.TempDir(), "missing.md")
+	if _, _, err := cfg.ReadTemplate(); err == nil {
+		t.Error("Expected error for missing template")
+	}
+}
+
+func TestResolveSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	agents := filepath.Join(tmpDir, "AGENTS.md")
+	claude := filepath.Join(tmpDir, "CLAUDE.md")
+	gemini := filepath.Join(tmpDir, "GEMINI.md")
+
+	configContent := `source: [AGENTS.md, CLAUDE.md, GEMINI.md]
+links:
+  - OPENCODE.md
+`
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No candidate exists: the first one is used
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Source != agents {
+		t.Errorf("Expected source %s, got %s", agents, cfg.Source)
+	}
+
+	// A symlinked first candidate is skipped in favour of a real file
+	os.WriteFile(claude, []byte("real"), 0644)
+	os.Symlink("CLAUDE.md", agents)
+
+	cfg, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Source != claude {
+		t.Errorf("Expected source %s, got %s", claude, cfg.Source)
+	}
+	if cfg.Candidates[0].Note != "symlink" || cfg.Candidates[1].Note != "chosen" {
+		t.Errorf("Unexpected candidate notes: %+v", cfg.Candidates)
+	}
+
+	// Other candidates become links after the configured ones
+	expectedLinks := []string{filepath.Join(tmpDir, "OPENCODE.md"), agents, gemini}
+	if len(cfg.Links) != len(expectedLinks) {
+		t.Fatalf("Expected links %v, got %v", expectedLinks, cfg.LinkPaths())
+	}
+	for i, link := range expectedLinks {
+		if cfg.Links[i].Path != link {
+			t.Errorf("Expected link %d to be %s, got %s", i, link, cfg.Links[i].Path)
+		}
+	}
+}
+
+func TestLoadConfigLocalOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte("source: CLAUDE.md\nlinks:\n  - AGENTS.md\n"), 0644)
+
+	localContent := "source: ~/CLAUDE.md\nlinks:\n  - AGENTS.md\n  - GEMINI.md\n"
+	os.WriteFile(filepath.Join(tmpDir, LocalConfigName), []byte(localContent), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	if expected := filepath.Join(homeDir, "CLAUDE.md"); cfg.Source != expected {
+		t.Errorf("Expected local source %s, got %s", expected, cfg.Source)
+	}
+
+	expectedLinks := []string{filepath.Join(tmpDir, "AGENTS.md"), filepath.Join(tmpDir, "GEMINI.md")}
+	if links := cfg.LinkPaths(); len(links) != len(expectedLinks) || links[0] != expectedLinks[0] || links[1] != expectedLinks[1] {
+		t.Errorf("Expected links %v, got %v", expectedLinks, links)
+	}
+
+	if cfg.LocalPath != filepath.Join(tmpDir, LocalConfigName) {
+		t.Errorf("Expected LocalPath to be set, got %q", cfg.LocalPath)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/martinmose/agentlink/internal/ignore"
+)
+
+// DiscoverConfigs finds the project configs in root and the directories
+// below it, down to maxDepth levels, skipping .git and everything ignored
+// by .gitignore. Each directory contributes its first config in order of
+// precedence (see ProjectConfigs). Configs are returned in walk order, so
+// the root comes first.
+func DiscoverConfigs(root string, maxDepth int) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := ignore.New(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." {
+			if entry.Name() == ".git" || matcher.Ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			if depth(rel) > maxDepth {
+				return filepath.SkipDir
+			}
+			if err := matcher.AddDir(filepath.ToSlash(rel)); err != nil {
+				return err
+			}
+		}
+
+		if found := ProjectConfigs(path); len(found) > 0 {
+			configs = append(configs, found[0])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+// depth returns the number of directories in a relative path
+func depth(rel string) int {
+	n := 1
+	for _, c := range filepath.ToSlash(rel) {
+		if c == '/' {
+			n++
+		}
+	}
+	return n
+}
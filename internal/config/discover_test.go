@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscoverConfigs(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		".agentlink.yaml":                     "source: AGENTS.md\n",
+		".gitignore":                          "node_modules/\n",
+		"packages/api/.agentlink.yaml":        "source: AGENTS.md\n",
+		"packages/web/.config/agentlink.toml": "source = \"AGENTS.md\"\n",
+		"node_modules/dep/.agentlink.yaml":    "source: AGENTS.md\n",
+		"a/b/c/.agentlink.yaml":               "source: AGENTS.md\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	configs, err := DiscoverConfigs(tmpDir, 2)
+	if err != nil {
+		t.Fatalf("DiscoverConfigs() failed: %v", err)
+	}
+	expected := []string{
+		filepath.Join(tmpDir, ".agentlink.yaml"),
+		filepath.Join(tmpDir, "packages", "api", ".agentlink.yaml"),
+		filepath.Join(tmpDir, "packages", "web", ".config", "agentlink.toml"),
+	}
+	if !reflect.DeepEqual(configs, expected) {
+		t.Errorf("DiscoverConfigs() = %v, expected %v", configs, expected)
+	}
+
+	configs, err = DiscoverConfigs(tmpDir, 3)
+	if err != nil {
+		t.Fatalf("DiscoverConfigs() failed: %v", err)
+	}
+	if len(configs) != 4 || configs[1] != filepath.Join(tmpDir, "a", "b", "c", ".agentlink.yaml") {
+		t.Errorf("DiscoverConfigs() with depth 3 = %v", configs)
+	}
+}
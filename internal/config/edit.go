@@ -0,0 +1,504 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a config file loaded for editing. Changes are made on the
+// yaml.v3 node tree so comments and key order survive a rewrite. JSON and
+// TOML files are edited the same way, but keep no comments.
+type Document struct {
+	Path    string
+	baseDir string
+	format  Format
+	root    yaml.Node
+}
+
+// LoadDocument loads the config file at path for editing
+func LoadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	doc := &Document{Path: path, baseDir: ConfigBaseDir(path), format: FormatOf(path)}
+	root, err := parseDocument(data, doc.format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	doc.root = *root
+
+	// Empty or comment-only files have no mapping yet
+	if doc.root.Kind == 0 {
+		doc.root = NewDocument(path).root
+	}
+	if doc.root.Kind == yaml.DocumentNode && len(doc.root.Content) == 0 {
+		doc.root.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	if doc.root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %s is not a mapping", path)
+	}
+
+	return doc, nil
+}
+
+// NewDocument returns a document to be saved at path, with only the schema
+// hint and the current version set
+func NewDocument(path string) *Document {
+	version := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}
+	doc := &Document{
+		Path:    path,
+		baseDir: ConfigBaseDir(path),
+		format:  FormatOf(path),
+		root: yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{
+			{Kind: yaml.MappingNode, Content: []*yaml.Node{scalarNode("version"), version}},
+		}},
+	}
+	doc.addSchemaHint()
+	return doc
+}
+
+// ResolvedDocument returns the effective config, with the configs it extends
+// and the local config merged in, as a document in the config's format
+func (c *Config) ResolvedDocument() *Document {
+	doc := NewDocument(c.Path)
+	doc.removeSchemaHint()
+
+	files := make([]string, len(c.Files))
+	for i, file := range c.Files {
+		files[i] = doc.ConfigPath(file)
+	}
+	doc.root.HeadComment = "# Resolved from " + strings.Join(files, ", ")
+	if c.ActiveProfile != "" {
+		doc.root.HeadComment += " with profile " + c.ActiveProfile
+	}
+
+	if len(c.Sources) == 1 {
+		doc.set("source", scalarNode(doc.ConfigPath(c.Sources[0])))
+	} else {
+		sources := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, source := range c.Sources {
+			sources.Content = append(sources.Content, scalarNode(doc.ConfigPath(source)))
+		}
+		sources.LineComment = "# chosen: " + doc.ConfigPath(c.Source)
+		doc.set("source", sources)
+	}
+
+	// Candidates linked by ResolveSource are implied by the source list
+	links := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, link := range c.Links {
+		isCandidate := false
+		for _, source := range c.Sources {
+			isCandidate = isCandidate || link.Path == source
+		}
+		if !isCandidate {
+			links.Content = append(links.Content, doc.linkNode(link))
+		}
+	}
+	doc.set("links", links)
+
+	if len(c.Groups) > 0 {
+		groups := &yaml.Node{Kind: yaml.MappingNode}
+		for _, group := range c.GroupList() {
+			node := &yaml.Node{Kind: yaml.MappingNode}
+			if group.When != nil {
+				node.Content = append(node.Content, scalarNode("when"), doc.conditionNode(group.When))
+			}
+			if group.IsCompose() {
+				fragments := &yaml.Node{Kind: yaml.SequenceNode}
+				for _, fragment := range group.Fragments {
+					if !strings.HasPrefix(fragment, "@") {
+						fragment = doc.ConfigPath(fragment)
+					}
+					fragments.Content = append(fragments.Content, scalarNode(fragment))
+				}
+				node.Content = append(node.Content,
+					scalarNode("type"), scalarNode(group.Type),
+					scalarNode("fragments"), fragments,
+					scalarNode("output"), scalarNode(doc.ConfigPath(group.Output)))
+			}
+			if group.IsIgnore() {
+				node.Content = append(node.Content,
+					scalarNode("type"), scalarNode(group.Type),
+					scalarNode("file"), scalarNode(doc.ConfigPath(group.File)))
+				if group.Gitignore {
+					node.Content = append(node.Content, scalarNode("gitignore"), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+				}
+				if len(group.Tools) > 0 {
+					groupTools := &yaml.Node{Kind: yaml.SequenceNode}
+					for _, tool := range group.Tools {
+						groupTools.Content = append(groupTools.Content, scalarNode(tool))
+					}
+					node.Content = append(node.Content, scalarNode("tools"), groupTools)
+				}
+			} else if group.IsMCP() {
+				servers := &yaml.Node{}
+				if err := servers.Encode(group.Servers); err != nil {
+					servers = scalarNode(err.Error())
+				}
+				node.Content = append(node.Content,
+					scalarNode("type"), scalarNode(group.Type),
+					scalarNode("servers"), servers)
+				if len(group.Tools) > 0 {
+					groupTools := &yaml.Node{Kind: yaml.SequenceNode}
+					for _, tool := range group.Tools {
+						groupTools.Content = append(groupTools.Content, scalarNode(tool))
+					}
+					node.Content = append(node.Content, scalarNode("tools"), groupTools)
+				}
+			} else if group.IsAgents() {
+				groupTools := &yaml.Node{Kind: yaml.SequenceNode}
+				for _, tool := range group.Tools {
+					groupTools.Content = append(groupTools.Content, scalarNode(tool))
+				}
+				node.Content = append(node.Content,
+					scalarNode("type"), scalarNode(group.Type),
+					scalarNode("dir"), scalarNode(doc.ConfigPath(group.Dir)),
+					scalarNode("tools"), groupTools)
+			} else {
+				groupLinks := &yaml.Node{Kind: yaml.SequenceNode}
+				for _, link := range group.Links {
+					groupLinks.Content = append(groupLinks.Content, doc.linkNode(link))
+				}
+				node.Content = append(node.Content, scalarNode("links"), groupLinks)
+			}
+			groups.Content = append(groups.Content, scalarNode(group.Name), node)
+		}
+		doc.set("groups", groups)
+	}
+
+	if c.Template != "" {
+		doc.set("template", scalarNode(doc.ConfigPath(c.Template)))
+	}
+
+	if len(c.Budgets) > 0 {
+		budgets := &yaml.Node{Kind: yaml.MappingNode}
+		for _, name := range c.Budgets.Names() {
+			node := &yaml.Node{}
+			if err := node.Encode(c.Budgets[name]); err != nil {
+				continue
+			}
+			budgets.Content = append(budgets.Content, scalarNode(name), node)
+		}
+		doc.set("budgets", budgets)
+	}
+
+	return doc
+}
+
+// linkNode returns the node for an expanded link, in the short form unless
+// it has options
+func (d *Document) linkNode(link Link) *yaml.Node {
+	if !link.hasOptions() {
+		return scalarNode(d.ConfigPath(link.Path))
+	}
+
+	type plain Link
+	options := plain(link)
+	options.Path, options.When = d.ConfigPath(link.Path), nil
+	node := &yaml.Node{}
+	if err := node.Encode(options); err != nil {
+		return scalarNode(err.Error())
+	}
+	// The condition goes right after the path
+	if link.When != nil {
+		when := []*yaml.Node{scalarNode("when"), d.conditionNode(link.When)}
+		node.Content = append(node.Content[:2], append(when, node.Content[2:]...)...)
+	}
+	return node
+}
+
+// conditionNode returns the node for an expanded condition
+func (d *Document) conditionNode(condition *Condition) *yaml.Node {
+	c := *condition
+	c.Exists = make(StringList, len(condition.Exists))
+	for i, path := range condition.Exists {
+		c.Exists[i] = d.ConfigPath(path)
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(c); err != nil {
+		return scalarNode(err.Error())
+	}
+	return node
+}
+
+// Format returns the format the document is saved in
+func (d *Document) Format() Format {
+	return d.format
+}
+
+// Bytes returns the encoded document
+func (d *Document) Bytes() ([]byte, error) {
+	data, err := encodeDocument(&d.root, d.format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return data, nil
+}
+
+// HasComments reports whether the document has comments, which a format
+// without comments would lose
+func (d *Document) HasComments() bool {
+	return hasComments(&d.root)
+}
+
+// Convert changes the document to another format, to be saved next to the
+// original file with the format's extension. The editor schema hint is
+// replaced by the one for the new format.
+func (d *Document) Convert(format Format) {
+	d.removeSchemaHint()
+	d.Path = strings.TrimSuffix(d.Path, filepath.Ext(d.Path)) + format.Ext()
+	d.format = format
+	d.addSchemaHint()
+	// JSON comes in flow style and quoted, use the block style of new files
+	if format == FormatYAML {
+		clearStyle(&d.root)
+	}
+}
+
+// clearStyle resets the style of node and every node below it
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// Save writes the document back to its file, replacing it atomically
+func (d *Document) Save() error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	tmp := d.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", d.Path, err)
+	}
+	if err := os.Rename(tmp, d.Path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace config file %s: %w", d.Path, err)
+	}
+
+	return nil
+}
+
+// ConfigPath returns path in the form it should be written to the config:
+// relative to the config's directory when inside it, ~/ form when inside the
+// home directory, absolute otherwise
+func (d *Document) ConfigPath(path string) string {
+	if rel, err := filepath.Rel(d.baseDir, path); err == nil && !isOutside(rel) {
+		return filepath.ToSlash(rel)
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		return tildePath(path, homeDir)
+	}
+	return path
+}
+
+// Values returns the string values of a top-level key, which may be a
+// scalar or a sequence
+func (d *Document) Values(key string) ([]string, bool) {
+	node := d.value(key)
+	if node == nil {
+		return nil, false
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, true
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if isRemoval(item) {
+				values = append(values, RemoveTag+" "+linkPathNode(item).Value)
+			} else {
+				values = append(values, linkPathNode(item).Value)
+			}
+		}
+		return values, true
+	default:
+		return nil, true
+	}
+}
+
+// SetSource sets the source to path (absolute), replacing any candidate
+// list, and removes path from links
+func (d *Document) SetSource(path string) {
+	sourceNode := d.value("source")
+	if sourceNode != nil && sourceNode.Kind == yaml.ScalarNode {
+		sourceNode.Value = d.ConfigPath(path)
+		sourceNode.Tag = "!!str"
+		sourceNode.Style = 0
+	} else {
+		d.set("source", scalarNode(d.ConfigPath(path)))
+	}
+	d.RemoveLink(path)
+}
+
+// SwapSource makes path (absolute) the source in place of the current one.
+// A single source is replaced and the old source becomes a link; in a
+// candidate list path is moved to the front, so the remaining candidates
+// keep being linked automatically.
+func (d *Document) SwapSource(path string) {
+	sourceNode := d.value("source")
+	if sourceNode != nil && sourceNode.Kind == yaml.SequenceNode {
+		d.removeMatching(sourceNode, path)
+		sourceNode.Content = append([]*yaml.Node{scalarNode(d.ConfigPath(path))}, sourceNode.Content...)
+		d.RemoveLink(path)
+		return
+	}
+
+	if sourceNode == nil {
+		d.set("source", scalarNode(d.ConfigPath(path)))
+		d.RemoveLink(path)
+		return
+	}
+
+	oldPath, err := ExpandPath(sourceNode.Value, d.baseDir)
+	sourceNode.Value = d.ConfigPath(path)
+	sourceNode.Tag = "!!str"
+	sourceNode.Style = 0
+	if err != nil || oldPath == path {
+		d.RemoveLink(path)
+		return
+	}
+
+	// The old source takes the new source's place in links, keeping its
+	// position and comments
+	if linksNode := d.value("links"); linksNode != nil && linksNode.Kind == yaml.SequenceNode {
+		for _, item := range linksNode.Content {
+			if d.matches(item, path) {
+				linkPathNode(item).Value = d.ConfigPath(oldPath)
+				d.RemoveLink(path)
+				return
+			}
+		}
+	}
+	d.AddLink(oldPath)
+}
+
+// AddLink appends path (absolute) to links unless it is already there.
+// Returns whether the document changed.
+func (d *Document) AddLink(path string) bool {
+	linksNode := d.value("links")
+	if linksNode == nil || linksNode.Kind != yaml.SequenceNode {
+		linksNode = &yaml.Node{Kind: yaml.SequenceNode}
+		d.set("links", linksNode)
+	}
+
+	for _, item := range linksNode.Content {
+		if d.matches(item, path) {
+			return false
+		}
+	}
+
+	item := scalarNode(d.ConfigPath(path))
+	// Keep trailing comments (e.g. commented-out examples) at the end
+	if n := len(linksNode.Content); n > 0 {
+		last := linksNode.Content[n-1]
+		item.FootComment, last.FootComment = last.FootComment, ""
+	}
+	linksNode.Content = append(linksNode.Content, item)
+	return true
+}
+
+// RemoveLink removes path (absolute) from links. Returns whether the
+// document changed.
+func (d *Document) RemoveLink(path string) bool {
+	linksNode := d.value("links")
+	if linksNode == nil || linksNode.Kind != yaml.SequenceNode {
+		return false
+	}
+	return d.removeMatching(linksNode, path)
+}
+
+// removeMatching removes the items of a sequence node that expand to path
+func (d *Document) removeMatching(seq *yaml.Node, path string) bool {
+	kept := seq.Content[:0]
+	removed := false
+	footComment := ""
+	for _, item := range seq.Content {
+		if d.matches(item, path) {
+			removed = true
+			footComment = item.FootComment
+			continue
+		}
+		kept = append(kept, item)
+		footComment = ""
+	}
+	seq.Content = kept
+	// Keep trailing comments when the last item was removed
+	if n := len(kept); n > 0 && footComment != "" {
+		kept[n-1].FootComment = footComment
+	}
+	return removed
+}
+
+// matches reports whether a path or link node expands to path; removals
+// never match
+func (d *Document) matches(node *yaml.Node, path string) bool {
+	if isRemoval(node) {
+		return false
+	}
+	node = linkPathNode(node)
+	if node.Kind != yaml.ScalarNode || strings.HasPrefix(node.Value, RemoveTag+" ") {
+		return false
+	}
+	expanded, err := ExpandPath(node.Value, d.baseDir)
+	return err == nil && expanded == filepath.Clean(path)
+}
+
+// linkPathNode returns the node holding the path of a link written as a
+// mapping, or the node itself
+func linkPathNode(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		if path := mappingValue(node, "path"); path != nil {
+			return path
+		}
+	}
+	return node
+}
+
+// mapping returns the top-level mapping node
+func (d *Document) mapping() *yaml.Node {
+	return d.root.Content[0]
+}
+
+// value returns the value node of a top-level key, or nil
+func (d *Document) value(key string) *yaml.Node {
+	m := d.mapping()
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// set replaces or appends a top-level key
+func (d *Document) set(key string, value *yaml.Node) {
+	m := d.mapping()
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, scalarNode(key), value)
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// isOutside reports whether a relative path leaves its base directory
+func isOutside(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel)
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDocumentSwapSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name: "old source takes the new source's place in links",
+			content: `# Choose the source
+source: CLAUDE.md # the file we edit
+links:
+  - AGENTS.md # Codex
+  - GEMINI.md
+`,
+			expected: `# Choose the source
+source: AGENTS.md # the file we edit
+links:
+  - CLAUDE.md # Codex
+  - GEMINI.md
+`,
+		},
+		{
+			name: "old source is appended when new source was not linked",
+			content: `source: CLAUDE.md
+links:
+  - GEMINI.md
+  # - OPENCODE.md
+`,
+			expected: `source: AGENTS.md
+links:
+  - GEMINI.md
+  - CLAUDE.md
+  # - OPENCODE.md
+`,
+		},
+		{
+			name: "candidate list moves the new source first",
+			content: `source: [CLAUDE.md, AGENTS.md]
+links:
+  - GEMINI.md
+`,
+			expected: `source: [AGENTS.md, CLAUDE.md]
+links:
+  - GEMINI.md
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			doc, err := LoadDocument(configPath)
+			if err != nil {
+				t.Fatalf("LoadDocument() failed: %v", err)
+			}
+			doc.SwapSource(filepath.Join(tmpDir, "AGENTS.md"))
+			if err := doc.Save(); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Unexpected config:\n%s\nexpected:\n%s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDocumentConfigPath(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("Cannot get home directory")
+	}
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte("source: CLAUDE.md\n"), 0644)
+
+	doc, err := LoadDocument(configPath)
+	if err != nil {
+		t.Fatalf("LoadDocument() failed: %v", err)
+	}
+
+	tests := map[string]string{
+		filepath.Join(tmpDir, ".github", "copilot-instructions.md"): ".github/copilot-instructions.md",
+		filepath.Join(homeDir, ".codex", "AGENTS.md"):               "~/.codex/AGENTS.md",
+		"/opt/agents/AGENTS.md":                                     "/opt/agents/AGENTS.md",
+	}
+
+	for path, expected := range tests {
+		if got := doc.ConfigPath(path); got != expected {
+			t.Errorf("ConfigPath(%s) = %s, expected %s", path, got, expected)
+		}
+	}
+}
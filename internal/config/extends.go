@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadLayers reads the config file at path and, first, every config it
+// extends, recursively. The result is in merge order: bases before the
+// configs extending them, each file once. Paths in every layer are relative
+// to baseDir, the project that extends them. chain holds the files
+// currently being loaded, to detect cycles.
+func loadLayers(path, baseDir string, chain []string) ([]*Config, error) {
+	config, err := readConfig(path, baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	chain = append(chain, path)
+	var layers []*Config
+	for i, base := range config.Extends {
+		pos := config.ExtendsPos[i]
+
+		for j, file := range chain {
+			if file == base {
+				cycle := append(chain[j:], base)
+				return nil, Diagnostics{{Pos: pos, Message: "extends cycle: " + strings.Join(cycle, " -> ")}}
+			}
+		}
+		if _, err := os.Stat(base); err != nil {
+			return nil, Diagnostics{{Pos: pos, Message: "cannot extend " + base + ": " + statReason(err)}}
+		}
+
+		baseLayers, err := loadLayers(base, baseDir, chain)
+		if err != nil {
+			return nil, err
+		}
+		layers = appendLayers(layers, baseLayers)
+	}
+
+	return appendLayers(layers, []*Config{config}), nil
+}
+
+// appendLayers appends the layers not in layers yet. A baseline extended
+// through two paths is merged once, where it is first needed.
+func appendLayers(layers, more []*Config) []*Config {
+	for _, layer := range more {
+		seen := false
+		for _, existing := range layers {
+			if existing.Path == layer.Path {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			layers = append(layers, layer)
+		}
+	}
+	return layers
+}
+
+func statReason(err error) string {
+	if os.IsNotExist(err) {
+		return "no such file"
+	}
+	return err.Error()
+}
+
+// listPositions returns the positions of the entries of a scalar or
+// sequence node
+func listPositions(node *yaml.Node) []Position {
+	switch {
+	case node == nil:
+		return nil
+	case node.Kind == yaml.SequenceNode:
+		positions := make([]Position, len(node.Content))
+		for i, item := range node.Content {
+			positions[i] = nodePosition(item)
+		}
+		return positions
+	default:
+		return []Position{nodePosition(node)}
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigExtends(t *testing.T) {
+	tmpDir := t.TempDir()
+	teamDir := filepath.Join(tmpDir, "team")
+	repoDir := filepath.Join(tmpDir, "repo")
+	os.MkdirAll(teamDir, 0755)
+	os.MkdirAll(repoDir, 0755)
+
+	os.WriteFile(filepath.Join(teamDir, "base.yaml"), []byte("source: AGENTS.md\ntemplate: template.md\nlinks:\n  - CLAUDE.md\n  - GEMINI.md\n  - OPENCODE.md\n"), 0644)
+	os.WriteFile(filepath.Join(teamDir, "extra.json"), []byte(`{"extends": "base.yaml", "links": [".cursorrules", "!remove GEMINI.md"]}`), 0644)
+
+	config := "extends: [../team/base.yaml, ../team/extra.json]\nsource: CLAUDE.md\nlinks:\n  - !remove OPENCODE.md\n  - .windsurfrules\n"
+	configPath := filepath.Join(repoDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(config), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	// Paths from every layer are relative to the project
+	if cfg.Source != filepath.Join(repoDir, "CLAUDE.md") {
+		t.Errorf("Expected the repo's source, got %s", cfg.Source)
+	}
+	if cfg.Template != filepath.Join(repoDir, "template.md") {
+		t.Errorf("Expected the inherited template in the repo, got %s", cfg.Template)
+	}
+
+	// CLAUDE.md is now the source, GEMINI.md and OPENCODE.md were removed
+	expectedLinks := []string{
+		filepath.Join(repoDir, ".cursorrules"),
+		filepath.Join(repoDir, ".windsurfrules"),
+	}
+	if !reflect.DeepEqual(cfg.LinkPaths(), expectedLinks) {
+		t.Errorf("Expected links %v, got %v", expectedLinks, cfg.LinkPaths())
+	}
+
+	// base.yaml is extended twice but merged once
+	expectedFiles := []string{filepath.Join(teamDir, "base.yaml"), filepath.Join(teamDir, "extra.json"), configPath}
+	if !reflect.DeepEqual(cfg.Files, expectedFiles) {
+		t.Errorf("Expected files %v, got %v", expectedFiles, cfg.Files)
+	}
+}
+
+func TestLoadConfigExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a.yaml": "extends: b.yaml\nsource: AGENTS.md\nlinks: [CLAUDE.md]\n",
+				"b.yaml": "extends:\n  - c.yaml\n",
+				"c.yaml": "extends: [a.yaml]\n",
+			},
+			expected: "c.yaml:1:11: extends cycle: ",
+		},
+		{
+			name: "missing base",
+			files: map[string]string{
+				"a.yaml": "source: AGENTS.md\nextends: nope.yaml\n",
+			},
+			expected: "a.yaml:2:10: cannot extend ",
+		},
+		{
+			name: "remove outside links",
+			files: map[string]string{
+				"a.yaml": "source: !remove AGENTS.md\n",
+			},
+			expected: "a.yaml:1:9: !remove is only allowed on links",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for name, content := range tt.files {
+				os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+			}
+
+			_, err := LoadConfig(filepath.Join(tmpDir, "a.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExtendsUnmatched(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name: "remove without a match",
+			files: map[string]string{
+				"a.yaml": "extends: b.yaml\nlinks:\n  - !remove GEMNI.md\n",
+				"b.yaml": "source: AGENTS.md\nlinks: [GEMINI.md]\n",
+			},
+			expected: "a.yaml:3:5: !remove ",
+		},
+		{
+			name: "group remove without a match",
+			files: map[string]string{
+				"a.yaml": "extends: b.yaml\ngroups:\n  claude:\n    links:\n      - !remove .claude/CLAUDE.md\n",
+				"b.yaml": "source: AGENTS.md\nlinks: [GEMINI.md]\ngroups:\n  claude:\n    links: [CLAUDE.md]\n",
+			},
+			expected: "CLAUDE.md matches no inherited link of group claude",
+		},
+	}
+
+	// A baseline that drops a link doesn't stop configs removing it from
+	// loading, they are warned
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for name, content := range tt.files {
+				os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+			}
+
+			cfg, err := LoadConfig(filepath.Join(tmpDir, "a.yaml"))
+			if err != nil {
+				t.Fatalf("LoadConfig() failed: %v", err)
+			}
+			if !strings.Contains(cfg.Warnings.Error(), tt.expected) {
+				t.Errorf("Expected a warning containing %q, got %v", tt.expected, cfg.Warnings)
+			}
+		})
+	}
+}
+
+func TestResolvedDocument(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "base.yaml"), []byte("source: AGENTS.md\nlinks:\n  - CLAUDE.md\n  - GEMINI.md\n"), 0644)
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte("extends: base.yaml\nsource: [AGENTS.md, CLAUDE.md]\nlinks:\n  - !remove GEMINI.md\n  - OPENCODE.md\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "AGENTS.md"), []byte("instructions"), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	data, err := cfg.ResolvedDocument().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Resolved from base.yaml, .agentlink.yaml
+
+version: 1
+source: [AGENTS.md, CLAUDE.md] # chosen: AGENTS.md
+links:
+  - OPENCODE.md
+`
+	if string(data) != expected {
+		t.Errorf("Unexpected resolved config:\n%s\nexpected:\n%s", data, expected)
+	}
+}
@@ -0,0 +1,591 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a config file format
+type Format string
+
+// Supported config file formats
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+	FormatTOML Format = "toml"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatYAML, FormatJSON, FormatTOML}
+
+// FormatOf returns the format of a config file from its extension; anything
+// unknown is read as YAML
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// ParseFormat parses a format name as given on the command line
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	case "toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (use yaml, json or toml)", name)
+	}
+}
+
+// Ext returns the file extension for the format
+func (f Format) Ext() string {
+	return "." + string(f)
+}
+
+// ProjectConfigNames lists the project config files relative to the project
+// root, in order of precedence. Files under .config/ still take their paths
+// relative to the project root.
+var ProjectConfigNames = []string{
+	".agentlink.yaml",
+	".agentlink.yml",
+	".agentlink.toml",
+	".agentlink.json",
+	".config/agentlink.yaml",
+	".config/agentlink.yml",
+	".config/agentlink.toml",
+	".config/agentlink.json",
+}
+
+// ProjectConfigs returns the project config files that exist in dir, in
+// order of precedence; the first one is used
+func ProjectConfigs(dir string) []string {
+	var found []string
+	for _, name := range ProjectConfigNames {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				abs = path
+			}
+			found = append(found, abs)
+		}
+	}
+	return found
+}
+
+// IsProjectConfig reports whether path is one of the project config files
+func IsProjectConfig(path string) bool {
+	root := ConfigBaseDir(path)
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	for _, name := range ProjectConfigNames {
+		if filepath.ToSlash(rel) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigBaseDir returns the directory relative paths in a config file are
+// based on: the project root for configs under .config/, the config file's
+// directory otherwise
+func ConfigBaseDir(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == ".config" && strings.HasPrefix(filepath.Base(path), "agentlink.") {
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+// LocalConfigPath returns the local config merged over the project config
+// at path; it always lives in the project root
+func LocalConfigPath(path string) string {
+	return filepath.Join(ConfigBaseDir(path), LocalConfigName)
+}
+
+// parseDocument parses a config file in the given format into a YAML
+// document node. JSON is parsed as YAML (which it is a subset of) so it
+// keeps line and column positions; TOML values have none.
+func parseDocument(data []byte, format Format) (*yaml.Node, error) {
+	if format != FormatTOML {
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, err
+		}
+		return &root, nil
+	}
+
+	var value map[string]interface{}
+	md, err := toml.Decode(string(data), &value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys come in document order, use it to order mappings
+	order := make(map[string]int)
+	for i, key := range md.Keys() {
+		if _, ok := order[key.String()]; !ok {
+			order[key.String()] = i
+		}
+	}
+
+	mapping, err := tomlNode(value, nil, order)
+	if err != nil {
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}, nil
+}
+
+// EditJSON applies edit to the top-level object of a JSON document and
+// returns the result, keeping the order of keys. Empty data is read as an
+// empty object. It lets agentlink change other tools' settings files, which
+// may have // and /* */ comments (JSONC); those are not written back.
+func EditJSON(data []byte, edit func(object *yaml.Node) error) ([]byte, error) {
+	root := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	if data = stripJSONComments(data); len(bytes.TrimSpace(data)) > 0 {
+		var err error
+		if root, err = parseDocument(data, FormatJSON); err != nil {
+			return nil, err
+		}
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	if err := edit(root.Content[0]); err != nil {
+		return nil, err
+	}
+	return encodeDocument(root, FormatJSON)
+}
+
+// stripJSONComments blanks out the comments in JSONC, outside strings,
+// keeping newlines so errors point at the right line
+func stripJSONComments(data []byte) []byte {
+	out := append([]byte(nil), data...)
+	for i := 0; i < len(out); i++ {
+		switch {
+		case out[i] == '"':
+			for i++; i < len(out) && out[i] != '"' && out[i] != '\n'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case bytes.HasPrefix(out[i:], []byte("//")):
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case bytes.HasPrefix(out[i:], []byte("/*")):
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(out)
+			} else {
+				end += i + 4
+			}
+			for ; i < end; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		}
+	}
+	return out
+}
+
+// tomlNode converts a decoded TOML value at key path to a YAML node
+func tomlNode(value interface{}, path toml.Key, order map[string]int) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		position := func(key string) int {
+			if i, ok := order[append(path[:len(path):len(path)], key).String()]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			if pi, pj := position(keys[i]), position(keys[j]); pi != pj {
+				return pi < pj
+			}
+			return keys[i] < keys[j]
+		})
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			child, err := tomlNode(v[key], append(path[:len(path):len(path)], key), order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, scalarNode(key), child)
+		}
+		return node, nil
+
+	case []map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			child, err := tomlNode(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			child, err := tomlNode(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+
+	case string:
+		return scalarNode(v), nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(v)}, nil
+	case int64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(v)}, nil
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: fmt.Sprint(v)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported TOML value %v at %s", value, path)
+	}
+}
+
+// encodeDocument encodes a YAML document node in the given format. JSON
+// drops comments, TOML keeps the ones on keys and tables.
+func encodeDocument(root *yaml.Node, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatJSON:
+		if err := writeJSON(&buf, root.Content[0], ""); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+
+	case FormatTOML:
+		if root.HeadComment != "" {
+			buf.WriteString(root.HeadComment + "\n\n")
+		}
+		if err := writeTOMLTable(&buf, root.Content[0], nil); err != nil {
+			return nil, err
+		}
+
+	default:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(root); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes node as indented JSON, keeping key order
+func writeJSON(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			buf.WriteString(indent + "  ")
+			writeJSONString(buf, node.Content[i].Value)
+			buf.WriteString(": ")
+			if err := writeJSON(buf, node.Content[i+1], indent+"  "); err != nil {
+				return err
+			}
+			if i+2 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range node.Content {
+			buf.WriteString(indent + "  ")
+			if err := writeJSON(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i+1 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!null":
+			buf.WriteString("null")
+		default:
+			writeJSONString(buf, taggedValue(node))
+		}
+
+	default:
+		return fmt.Errorf("cannot encode YAML node kind %d as JSON", node.Kind)
+	}
+
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	// Encode ends with a newline
+	buf.Truncate(buf.Len() - 1)
+}
+
+// taggedValue returns the value of a string node for formats without tags,
+// keeping a !remove tag as a prefix
+func taggedValue(node *yaml.Node) string {
+	if isRemoval(node) {
+		return RemoveTag + " " + node.Value
+	}
+	return node.Value
+}
+
+// writeTOMLTable writes the entries of a mapping node as a TOML table at key
+// path: plain values first, then sub-tables and arrays of tables, which
+// TOML requires to come after them
+func writeTOMLTable(buf *bytes.Buffer, node *yaml.Node, path toml.Key) error {
+	var tables []int
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if isTOMLTable(value) {
+			tables = append(tables, i)
+			continue
+		}
+		if value.ShortTag() == "!!null" {
+			continue
+		}
+		if key.HeadComment != "" && i > 0 {
+			buf.WriteString("\n")
+		}
+		writeTOMLComment(buf, key.HeadComment)
+		buf.WriteString(toml.Key{key.Value}.String() + " = ")
+		if value.Kind == yaml.SequenceNode && len(value.Content) > 1 {
+			// One item per line keeps the comments on items
+			if err := writeTOMLList(buf, value); err != nil {
+				return err
+			}
+		} else if err := writeTOMLValue(buf, value); err != nil {
+			return err
+		}
+		if comment := key.LineComment + value.LineComment; comment != "" {
+			buf.WriteString(" " + comment)
+		}
+		buf.WriteString("\n")
+	}
+
+	for _, i := range tables {
+		key := append(path[:len(path):len(path)], node.Content[i].Value)
+		value := node.Content[i+1]
+		// A table holding only tables needs no header of its own
+		if value.Kind == yaml.MappingNode && node.Content[i].HeadComment == "" && onlyTOMLTables(value) {
+			if err := writeTOMLTable(buf, value, key); err != nil {
+				return err
+			}
+			continue
+		}
+		buf.WriteString("\n")
+		writeTOMLComment(buf, node.Content[i].HeadComment)
+		if value.Kind == yaml.MappingNode {
+			fmt.Fprintf(buf, "[%s]\n", key)
+			if err := writeTOMLTable(buf, value, key); err != nil {
+				return err
+			}
+			continue
+		}
+		for j, item := range value.Content {
+			if j > 0 {
+				buf.WriteString("\n")
+			}
+			fmt.Fprintf(buf, "[[%s]]\n", key)
+			if err := writeTOMLTable(buf, item, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeTOMLList writes a sequence node as a multi-line TOML array, with the
+// comments of its items
+func writeTOMLList(buf *bytes.Buffer, node *yaml.Node) error {
+	buf.WriteString("[\n")
+	for _, item := range node.Content {
+		for _, line := range commentLines(item.HeadComment) {
+			buf.WriteString("  " + line + "\n")
+		}
+		buf.WriteString("  ")
+		if err := writeTOMLValue(buf, item); err != nil {
+			return err
+		}
+		buf.WriteString(",")
+		if item.LineComment != "" {
+			buf.WriteString(" " + item.LineComment)
+		}
+		buf.WriteString("\n")
+		for _, line := range commentLines(item.FootComment) {
+			buf.WriteString("  " + line + "\n")
+		}
+	}
+	buf.WriteString("]")
+	return nil
+}
+
+func commentLines(comment string) []string {
+	if comment == "" {
+		return nil
+	}
+	return strings.Split(comment, "\n")
+}
+
+// writeTOMLComment writes a YAML head comment, which is valid TOML as is
+func writeTOMLComment(buf *bytes.Buffer, comment string) {
+	if comment != "" {
+		buf.WriteString(comment + "\n")
+	}
+}
+
+// hasComments reports whether node or any node below it has a comment
+func hasComments(node *yaml.Node) bool {
+	if node.HeadComment != "" || node.LineComment != "" || node.FootComment != "" {
+		return true
+	}
+	for _, child := range node.Content {
+		if hasComments(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTOMLTable reports whether node is written as a table (or array of
+// tables) rather than inline
+func isTOMLTable(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return true
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			return false
+		}
+		for _, item := range node.Content {
+			if item.Kind != yaml.MappingNode {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// onlyTOMLTables reports whether every value of a non-empty mapping node is
+// written as a table
+func onlyTOMLTables(node *yaml.Node) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for i := 1; i < len(node.Content); i += 2 {
+		if !isTOMLTable(node.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeTOMLValue writes node as an inline TOML value
+func writeTOMLValue(buf *bytes.Buffer, node *yaml.Node) error {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		buf.WriteString("[")
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeTOMLValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+
+	case yaml.MappingNode:
+		buf.WriteString("{ ")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(toml.Key{node.Content[i].Value}.String() + " = ")
+			if err := writeTOMLValue(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(" }")
+
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!null":
+			return fmt.Errorf("TOML has no null value (line %d)", node.Line)
+		default:
+			writeJSONString(buf, taggedValue(node))
+		}
+
+	default:
+		return fmt.Errorf("cannot encode YAML node kind %d as TOML", node.Kind)
+	}
+
+	return nil
+}
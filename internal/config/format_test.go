@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProjectConfigs(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ".config"), 0755)
+	for _, name := range []string{".config/agentlink.yaml", ".agentlink.json", ".agentlink.yml"} {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte("{}"), 0644)
+	}
+
+	configs := ProjectConfigs(tmpDir)
+	expected := []string{
+		filepath.Join(tmpDir, ".agentlink.yml"),
+		filepath.Join(tmpDir, ".agentlink.json"),
+		filepath.Join(tmpDir, ".config", "agentlink.yaml"),
+	}
+	if !reflect.DeepEqual(configs, expected) {
+		t.Errorf("ProjectConfigs() = %v, expected %v", configs, expected)
+	}
+}
+
+func TestConfigBaseDir(t *testing.T) {
+	tests := map[string]string{
+		"/repo/.agentlink.yaml":                  "/repo",
+		"/repo/.config/agentlink.toml":           "/repo",
+		"/home/me/.config/agentlink/config.yaml": "/home/me/.config/agentlink",
+	}
+
+	for path, expected := range tests {
+		if got := ConfigBaseDir(path); got != expected {
+			t.Errorf("ConfigBaseDir(%s) = %s, expected %s", path, got, expected)
+		}
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	files := map[string]string{
+		".agentlink.yaml":        "source: [AGENTS.md, CLAUDE.md]\nlinks:\n  - GEMINI.md\n",
+		".agentlink.json":        `{"source": ["AGENTS.md", "CLAUDE.md"], "links": ["GEMINI.md"]}`,
+		".agentlink.toml":        "source = [\"AGENTS.md\", \"CLAUDE.md\"]\nlinks = [\"GEMINI.md\"]\n",
+		".config/agentlink.yaml": "source: [AGENTS.md, CLAUDE.md]\nlinks:\n  - GEMINI.md\n",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, name)
+			os.MkdirAll(filepath.Dir(configPath), 0755)
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("LoadConfig() failed: %v", err)
+			}
+
+			// Paths are relative to the project root in every location
+			if config.Source != filepath.Join(tmpDir, "AGENTS.md") {
+				t.Errorf("Expected source in %s, got %s", tmpDir, config.Source)
+			}
+			expectedLinks := []string{filepath.Join(tmpDir, "GEMINI.md"), filepath.Join(tmpDir, "CLAUDE.md")}
+			if !reflect.DeepEqual(config.LinkPaths(), expectedLinks) {
+				t.Errorf("Expected links %v, got %v", expectedLinks, config.LinkPaths())
+			}
+		})
+	}
+}
+
+func TestLoadConfigJSONPositions(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".agentlink.json")
+	os.WriteFile(configPath, []byte("{\n  \"source\": \"AGENTS.md\",\n  \"link\": [\"CLAUDE.md\"]\n}\n"), 0644)
+
+	_, err := LoadConfig(configPath)
+	expected := configPath + `:3:3: unknown field "link" (did you mean "links"?)`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestDocumentConvert(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	content := SchemaModeline + `
+version: 1
+
+# The file we edit
+source: CLAUDE.md
+links:
+  - AGENTS.md # Codex
+  - GEMINI.md
+  # - OPENCODE.md
+`
+	os.WriteFile(configPath, []byte(content), 0644)
+
+	doc, err := LoadDocument(configPath)
+	if err != nil {
+		t.Fatalf("LoadDocument() failed: %v", err)
+	}
+
+	doc.Convert(FormatTOML)
+	if doc.Path != filepath.Join(tmpDir, ".agentlink.toml") {
+		t.Errorf("Unexpected path %s", doc.Path)
+	}
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := tomlSchemaDirective + `
+
+version = 1
+
+# The file we edit
+source = "CLAUDE.md"
+links = [
+  "AGENTS.md", # Codex
+  "GEMINI.md",
+  # - OPENCODE.md
+]
+`
+	if string(data) != expected {
+		t.Errorf("Unexpected TOML:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	doc.Convert(FormatJSON)
+	data, err = doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{
+  "$schema": "` + SchemaURL + `",
+  "version": 1,
+  "source": "CLAUDE.md",
+  "links": [
+    "AGENTS.md",
+    "GEMINI.md"
+  ]
+}
+`
+	if string(data) != expected {
+		t.Errorf("Unexpected JSON:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	// The written TOML reads back the same
+	tomlPath := filepath.Join(tmpDir, "converted.toml")
+	doc.Convert(FormatTOML)
+	data, _ = doc.Bytes()
+	os.WriteFile(tomlPath, data, 0644)
+	config, err := readConfig(tomlPath, tmpDir)
+	if err != nil {
+		t.Fatalf("Converted TOML does not load: %v", err)
+	}
+	if len(config.Links) != 2 || config.Version != 1 {
+		t.Errorf("Unexpected config from TOML: %+v", config)
+	}
+}
+
+func TestDocumentConvertRemoval(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+	os.WriteFile(configPath, []byte("extends: base.yaml\nlinks:\n  - !remove GEMINI.md\n"), 0644)
+
+	doc, err := LoadDocument(configPath)
+	if err != nil {
+		t.Fatalf("LoadDocument() failed: %v", err)
+	}
+
+	// Formats without tags keep the removal as a prefix
+	doc.Convert(FormatTOML)
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `links = ["!remove GEMINI.md"]`) {
+		t.Errorf("Removal lost in TOML:\n%s", data)
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/martinmose/agentlink/internal/tools"
+)
+
+// GroupIgnore is the type of groups that link one ignore file to the
+// ignore file of each tool
+const GroupIgnore = "ignore"
+
+// IsIgnore reports whether the group links an ignore file
+func (g *Group) IsIgnore() bool {
+	return g.Type == GroupIgnore
+}
+
+// IgnoreTools returns the tools whose ignore file the group links: its
+// tools, or every tool with an ignore file other than the group's file
+func (g *Group) IgnoreTools() []tools.Tool {
+	var list []tools.Tool
+	if len(g.Tools) > 0 {
+		for _, name := range g.Tools {
+			if tool, ok := tools.Lookup(name); ok && tool.IgnoreFile != "" {
+				list = append(list, tool)
+			}
+		}
+		return list
+	}
+	for _, tool := range tools.Registry {
+		if tool.IgnoreFile != "" && filepath.Join(g.Root, tool.IgnoreFile) != g.File {
+			list = append(list, tool)
+		}
+	}
+	return list
+}
+
+// GitignorePath returns the .gitignore whose entries an ignore group with
+// gitignore appends
+func (g *Group) GitignorePath() string {
+	return filepath.Join(g.Root, ".gitignore")
+}
+
+// ignoreLinks returns the links of an ignore group: a symlink to its file
+// at each tool's ignore file, or a copy with .gitignore appended
+func (g *Group) ignoreLinks() []Link {
+	var links []Link
+	for _, tool := range g.IgnoreTools() {
+		link := Link{
+			Path:      filepath.Join(g.Root, tool.IgnoreFile),
+			Target:    g.File,
+			Group:     g.Name,
+			GroupWhen: g.When,
+			Pos:       g.Pos,
+		}
+		if g.Gitignore {
+			link.Mode = ModeCopy
+			link.Append = []string{g.GitignorePath()}
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// validateIgnore checks that ignore groups have a file and tools that have
+// an ignore file
+func (c *Config) validateIgnore() Diagnostics {
+	var diags Diagnostics
+	for _, group := range c.GroupList() {
+		if !group.IsIgnore() {
+			continue
+		}
+		if group.File == "" {
+			diags.add(group.Pos, "ignore group %s needs a file", group.Name)
+		}
+		for _, name := range group.Tools {
+			tool, ok := diags.checkToolName(group.Pos, name, "for group "+group.Name)
+			switch {
+			case !ok:
+			case tool.IgnoreFile == "":
+				diags.add(group.Pos, "%s has no ignore file", name)
+			case filepath.Join(group.Root, tool.IgnoreFile) == group.File:
+				diags.add(group.Pos, "file of group %s is the ignore file of %s", group.Name, name)
+			}
+		}
+	}
+	return diags
+}
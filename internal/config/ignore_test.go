@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigIgnore(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  ignore:
+    type: ignore
+    file: .cursorignore
+    gitignore: true
+`), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	// Every tool with an ignore file but cursor, whose file is the canonical one
+	var paths []string
+	for _, link := range cfg.AllLinks()[1:] {
+		paths = append(paths, filepath.Base(link.Path))
+		if link.Target != filepath.Join(tmpDir, ".cursorignore") || link.Mode != ModeCopy {
+			t.Errorf("link = %+v", link)
+		}
+		if len(link.Append) != 1 || link.Append[0] != filepath.Join(tmpDir, ".gitignore") {
+			t.Errorf("link.Append = %v", link.Append)
+		}
+	}
+	if strings.Join(paths, ",") != ".geminiignore,.codeiumignore,.aiderignore" {
+		t.Errorf("ignore links = %v", paths)
+	}
+}
+
+func TestLoadConfigIgnoreErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   string
+		expected string
+	}{
+		{
+			name:     "missing file",
+			groups:   "  ignore:\n    type: ignore\n",
+			expected: "ignore group ignore needs a file",
+		},
+		{
+			name:     "tool without an ignore file",
+			groups:   "  ignore:\n    type: ignore\n    file: .aiignore\n    tools: [claude]\n",
+			expected: "claude has no ignore file",
+		},
+		{
+			name:     "file is a tool's ignore file",
+			groups:   "  ignore:\n    type: ignore\n    file: .aiderignore\n    tools: [aider, cursor]\n",
+			expected: "file of group ignore is the ignore file of aider",
+		},
+		{
+			name:     "gitignore without type",
+			groups:   "  ignore:\n    gitignore: true\n    links: [GEMINI.md]\n",
+			expected: "file and gitignore only apply to groups with type ignore",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+			os.WriteFile(configPath, []byte("source: AGENTS.md\nlinks:\n  - CLAUDE.md\ngroups:\n"+tt.groups), 0644)
+
+			_, err := LoadConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("LoadConfig() error = %v, expected it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
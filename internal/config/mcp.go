@@ -0,0 +1,93 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/martinmose/agentlink/internal/tools"
+)
+
+// GroupMCP is the type of groups that write one list of MCP servers to the
+// MCP config of each tool
+const GroupMCP = "mcp"
+
+// MCPServer is an MCP server, started with a command or reached at a URL
+type MCPServer struct {
+	Command string            `yaml:"command,omitempty" doc:"Command that starts a local server"`
+	Args    []string          `yaml:"args,omitempty" doc:"Arguments of command"`
+	Env     map[string]string `yaml:"env,omitempty" doc:"Environment variables of command"`
+	URL     string            `yaml:"url,omitempty" doc:"URL of a remote server, instead of command"`
+	Headers map[string]string `yaml:"headers,omitempty" doc:"HTTP headers sent to url"`
+}
+
+// MCPServers maps server names to servers
+type MCPServers map[string]*MCPServer
+
+// Names returns the server names in order
+func (s MCPServers) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsMCP reports whether the group writes MCP servers
+func (g *Group) IsMCP() bool {
+	return g.Type == GroupMCP
+}
+
+// MCPTools returns the tools the group writes its servers for: its tools,
+// or every tool with an MCP config
+func (g *Group) MCPTools() []tools.Tool {
+	var list []tools.Tool
+	for _, tool := range tools.Registry {
+		if tool.MCPKey == "" {
+			continue
+		}
+		listed := len(g.Tools) == 0
+		for _, name := range g.Tools {
+			listed = listed || name == tool.Name
+		}
+		if listed {
+			list = append(list, tool)
+		}
+	}
+	return list
+}
+
+// validateMCP checks that mcp groups have servers that can be started and
+// tools that have an MCP config
+func (c *Config) validateMCP() Diagnostics {
+	var diags Diagnostics
+	for _, group := range c.GroupList() {
+		if !group.IsMCP() {
+			continue
+		}
+		if len(group.Servers) == 0 {
+			diags.add(group.Pos, "mcp group %s needs servers", group.Name)
+		}
+		for _, name := range group.Servers.Names() {
+			server := group.Servers[name]
+			switch {
+			case server == nil || (server.Command == "" && server.URL == ""):
+				diags.add(group.Pos, "server %s of group %s needs a command or a url", name, group.Name)
+			case server.Command != "" && server.URL != "":
+				diags.add(group.Pos, "server %s of group %s has both a command and a url", name, group.Name)
+			case server.URL != "" && (len(server.Args) > 0 || len(server.Env) > 0):
+				diags.add(group.Pos, "args and env of server %s only apply to a command", name)
+			case server.Command != "" && len(server.Headers) > 0:
+				diags.add(group.Pos, "headers of server %s only apply to a url", name)
+			}
+		}
+		for _, name := range group.Tools {
+			tool, ok := diags.checkToolName(group.Pos, name, "for group "+group.Name)
+			switch {
+			case !ok:
+			case tool.MCPKey == "":
+				diags.add(group.Pos, "%s has no MCP config agentlink can write", name)
+			}
+		}
+	}
+	return diags
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigMCP(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+groups:
+  mcp:
+    type: mcp
+    servers:
+      files:
+        command: npx
+        args: ["-y", "@modelcontextprotocol/server-filesystem", "."]
+      github:
+        url: https://api.githubcopilot.com/mcp/
+        headers:
+          Authorization: Bearer ${GITHUB_TOKEN}
+`), 0644)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	group := cfg.Groups["mcp"]
+	if names := strings.Join(group.Servers.Names(), ","); names != "files,github" {
+		t.Errorf("Servers.Names() = %s", names)
+	}
+	if group.Root != tmpDir {
+		t.Errorf("Root = %s, expected %s", group.Root, tmpDir)
+	}
+
+	// Every tool with an MCP config
+	var names []string
+	for _, tool := range group.MCPTools() {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "claude,codex,copilot,cursor" {
+		t.Errorf("MCPTools() = %v", names)
+	}
+}
+
+func TestLoadConfigMCPErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		groups   string
+		expected string
+	}{
+		{
+			name:     "no servers",
+			groups:   "  mcp:\n    type: mcp\n",
+			expected: "mcp group mcp needs servers",
+		},
+		{
+			name:     "command and url",
+			groups:   "  mcp:\n    type: mcp\n    servers:\n      files:\n        command: npx\n        url: http://localhost\n",
+			expected: "server files of group mcp has both a command and a url",
+		},
+		{
+			name:     "headers of a command",
+			groups:   "  mcp:\n    type: mcp\n    servers:\n      files:\n        command: npx\n        headers:\n          X-Key: value\n",
+			expected: "headers of server files only apply to a url",
+		},
+		{
+			name:     "tool without an MCP config",
+			groups:   "  mcp:\n    type: mcp\n    tools: [aider]\n    servers:\n      files:\n        command: npx\n",
+			expected: "aider has no MCP config agentlink can write",
+		},
+		{
+			name:     "servers without type",
+			groups:   "  mcp:\n    links: [GEMINI.md]\n    servers:\n      files:\n        command: npx\n",
+			expected: "servers only apply to groups with type mcp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+			os.WriteFile(configPath, []byte("source: AGENTS.md\nlinks:\n  - CLAUDE.md\ngroups:\n"+tt.groups), 0644)
+
+			_, err := LoadConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("LoadConfig() error = %v, expected it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config schema version this agentlink reads and
+// writes. Files without a version key predate versioning and are version 0.
+const CurrentVersion = 1
+
+// migration upgrades a config mapping node by one version in place,
+// returning what it changed that the file's author should know about
+type migration struct {
+	description string
+	apply       func(mapping *yaml.Node, baseDir string) Diagnostics
+}
+
+// migrations[i] upgrades a version i config to version i+1
+var migrations = []migration{
+	{
+		description: "remove links that repeat a source candidate, candidates are linked automatically",
+		apply:       dropSourceLinks,
+	},
+}
+
+// configVersion returns the schema version of a config mapping node
+func configVersion(mapping *yaml.Node) (int, error) {
+	node := mappingValue(mapping, "version")
+	if node == nil {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(node.Value)
+	if node.Kind != yaml.ScalarNode || err != nil || version < 0 {
+		return 0, nodeError(node, "version must be a whole number")
+	}
+	if version > CurrentVersion {
+		return 0, nodeError(node, fmt.Sprintf("config version %d is newer than this agentlink supports (up to %d), please upgrade agentlink", version, CurrentVersion))
+	}
+	return version, nil
+}
+
+// migrate upgrades a config mapping node to CurrentVersion in place, keeping
+// comments. Returns the version it had, the descriptions of the steps
+// applied and what they changed.
+func migrate(mapping *yaml.Node, baseDir string) (int, []string, Diagnostics, error) {
+	from, err := configVersion(mapping)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if from == CurrentVersion {
+		return from, nil, nil, nil
+	}
+
+	var steps []string
+	var changes Diagnostics
+	for _, m := range migrations[from:] {
+		changes = append(changes, m.apply(mapping, baseDir)...)
+		steps = append(steps, m.description)
+	}
+
+	// The version goes first so it is the first thing a reader sees, below
+	// the comment heading the file, such as the schema modeline
+	versionNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}
+	if node := mappingValue(mapping, "version"); node != nil {
+		*node = *versionNode
+	} else {
+		versionKey := scalarNode("version")
+		if len(mapping.Content) > 0 {
+			versionKey.HeadComment = mapping.Content[0].HeadComment
+			mapping.Content[0].HeadComment = ""
+		}
+		mapping.Content = append([]*yaml.Node{versionKey, versionNode}, mapping.Content...)
+	}
+
+	return from, steps, changes, nil
+}
+
+// dropSourceLinks removes links that expand to a source candidate, which
+// are linked automatically. Since version 1 they are warned about.
+func dropSourceLinks(mapping *yaml.Node, baseDir string) Diagnostics {
+	sourceNode := mappingValue(mapping, "source")
+	linksNode := mappingValue(mapping, "links")
+	if sourceNode == nil || linksNode == nil || linksNode.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	sources := []*yaml.Node{sourceNode}
+	if sourceNode.Kind == yaml.SequenceNode {
+		sources = sourceNode.Content
+	}
+
+	var changes Diagnostics
+	doc := &Document{baseDir: baseDir}
+	for _, source := range sources {
+		if source.Kind != yaml.ScalarNode || source.Value == "" {
+			continue
+		}
+		path, err := ExpandPath(source.Value, baseDir)
+		if err != nil {
+			continue
+		}
+		for _, item := range linksNode.Content {
+			if doc.matches(item, path) {
+				changes.add(nodePosition(item), "link %s repeats the source %s, ignoring it (remove it or run 'agentlink config migrate')", linkPathNode(item).Value, source.Value)
+			}
+		}
+		doc.removeMatching(linksNode, path)
+	}
+	return changes
+}
+
+// Migrate upgrades the document to CurrentVersion, keeping comments. Returns
+// the version it had and the descriptions of the steps applied, none if it
+// is up to date.
+func (d *Document) Migrate() (int, []string, error) {
+	from, steps, _, err := migrate(d.mapping(), d.baseDir)
+	if err != nil {
+		return 0, nil, withFile(err, d.Path)
+	}
+	return from, steps, nil
+}
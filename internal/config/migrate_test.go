@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDocumentMigrate(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedFrom  int
+		expectedSteps int
+		expected      string
+	}{
+		{
+			name: "unversioned config drops links to candidates",
+			content: `# Our agent setup
+source: [AGENTS.md, CLAUDE.md]
+links:
+  - GEMINI.md # gemini
+  - ./CLAUDE.md
+  # - OPENCODE.md
+`,
+			expectedFrom:  0,
+			expectedSteps: 1,
+			expected: `# Our agent setup
+version: 1
+source: [AGENTS.md, CLAUDE.md]
+links:
+  - GEMINI.md # gemini
+  # - OPENCODE.md
+`,
+		},
+		{
+			name: "version goes below the schema modeline",
+			content: `# yaml-language-server: $schema=https://example.com/agentlink.schema.json
+
+# Our agent setup
+source: AGENTS.md
+links:
+  - CLAUDE.md
+`,
+			expectedFrom:  0,
+			expectedSteps: 1,
+			expected: `# yaml-language-server: $schema=https://example.com/agentlink.schema.json
+
+# Our agent setup
+version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+`,
+		},
+		{
+			name: "current config is unchanged",
+			content: `version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+`,
+			expectedFrom:  1,
+			expectedSteps: 0,
+			expected: `version: 1
+source: AGENTS.md
+links:
+  - CLAUDE.md
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			doc, err := LoadDocument(configPath)
+			if err != nil {
+				t.Fatalf("LoadDocument() failed: %v", err)
+			}
+			from, steps, err := doc.Migrate()
+			if err != nil {
+				t.Fatalf("Migrate() failed: %v", err)
+			}
+			if from != tt.expectedFrom || len(steps) != tt.expectedSteps {
+				t.Errorf("Migrate() = %d, %v, expected %d with %d steps", from, steps, tt.expectedFrom, tt.expectedSteps)
+			}
+
+			data, err := doc.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Unexpected config:\n%s\nexpected:\n%s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	// An unversioned config loads, migrated in memory
+	os.WriteFile(configPath, []byte("source: [AGENTS.md, CLAUDE.md]\nlinks:\n  - CLAUDE.md\n"), 0644)
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	if config.Version != 0 {
+		t.Errorf("Expected version 0 as written, got %d", config.Version)
+	}
+	if len(config.Warnings) != 1 || !strings.Contains(config.Warnings.Error(), ":3:5: link CLAUDE.md repeats the source CLAUDE.md, ignoring it") {
+		t.Errorf("Expected a warning about the dropped link, got %v", config.Warnings)
+	}
+
+	// So does one whose only link is the source, with a warning rather than
+	// the error a versioned file gets
+	os.WriteFile(configPath, []byte("source: AGENTS.md\nlinks:\n  - AGENTS.md\n  - CLAUDE.md\n"), 0644)
+	config, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	if len(config.Warnings) != 1 || !strings.Contains(config.Warnings.Error(), ":3:5: link AGENTS.md repeats the source AGENTS.md") {
+		t.Errorf("Expected a warning about the dropped link, got %v", config.Warnings)
+	}
+
+	// A newer version is rejected
+	os.WriteFile(configPath, []byte("version: 99\nsource: AGENTS.md\nlinks:\n  - CLAUDE.md\n"), 0644)
+	_, err = LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), ":1:10: config version 99 is newer") {
+		t.Errorf("Expected a newer version error, got %v", err)
+	}
+}
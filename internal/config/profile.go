@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProfileEnv is the environment variable that selects a profile when
+// --profile is not given
+const ProfileEnv = "AGENTLINK_PROFILE"
+
+// Profile is an entry under profiles, merged over the config when it is
+// active
+type Profile struct {
+	Sources StringList `yaml:"source" doc:"The source while the profile is active, or an ordered list of candidates"`
+	Links   []Link     `yaml:"links" doc:"Links added while the profile is active (or removed with !remove)"`
+	Groups  Groups     `yaml:"groups" doc:"Groups merged over the config's groups of the same name while the profile is active"`
+}
+
+// Profiles maps profile names to profiles
+type Profiles map[string]*Profile
+
+// UnknownProfileError is returned when the selected profile is not defined
+// by any of the merged config files
+type UnknownProfileError struct {
+	Name    string
+	Path    string
+	Defined []string
+}
+
+func (e *UnknownProfileError) Error() string {
+	if len(e.Defined) == 0 {
+		return fmt.Sprintf("unknown profile %q: %s defines no profiles", e.Name, e.Path)
+	}
+	return fmt.Sprintf("unknown profile %q (%s defines %s)", e.Name, e.Path, strings.Join(e.Defined, ", "))
+}
+
+// OtherProfiles loads the config as it is under every profile but the
+// active one, including no profile at all. Sync uses them to retarget links
+// set up by a previous profile. The config without a profile is left out
+// when it is not valid on its own, e.g. when only the profiles define a
+// source. Profiles that fail to load are left out too, and reported in the
+// error returned with the others.
+func (c *Config) OtherProfiles() ([]*Config, error) {
+	if len(c.ProfileNames) == 0 {
+		return nil, nil
+	}
+
+	var others []*Config
+	var failed []string
+	for _, name := range append([]string{""}, c.ProfileNames...) {
+		if name == c.ActiveProfile {
+			continue
+		}
+		other, err := LoadConfigProfile(c.Path, name)
+		if err != nil {
+			if name != "" {
+				failed = append(failed, fmt.Sprintf("profile %q: %v", name, err))
+			}
+			continue
+		}
+		others = append(others, other)
+	}
+	if len(failed) > 0 {
+		return others, fmt.Errorf("failed to load %s", strings.Join(failed, "; "))
+	}
+	return others, nil
+}
+
+// addProfileNames adds the profiles of a layer to c.ProfileNames
+func (c *Config) addProfileNames(layer *Config) {
+	for _, name := range layer.ProfileNames {
+		known := false
+		for _, existing := range c.ProfileNames {
+			known = known || existing == name
+		}
+		if !known {
+			c.ProfileNames = append(c.ProfileNames, name)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+  - GEMINI.md
+groups:
+  codex:
+    links:
+      - .codex/AGENTS.md
+profiles:
+  work:
+    source: work/INSTRUCTIONS.md
+    links:
+      - !remove GEMINI.md
+      - .github/copilot-instructions.md
+    groups:
+      codex:
+        when:
+          env: AGENTLINK_TEST_WORK_CODEX
+  personal:
+    links:
+      - OPENCODE.md
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, LocalConfigName), []byte("source: LOCAL.md\n"), 0644)
+
+	cfg, err := LoadConfigProfile(configPath, "work")
+	if err != nil {
+		t.Fatalf("LoadConfigProfile() failed: %v", err)
+	}
+	if cfg.ActiveProfile != "work" {
+		t.Errorf("ActiveProfile = %q, expected work", cfg.ActiveProfile)
+	}
+	if !reflect.DeepEqual(cfg.ProfileNames, []string{"work", "personal"}) {
+		t.Errorf("ProfileNames = %v", cfg.ProfileNames)
+	}
+	// The local config is merged after the profile and still wins
+	if cfg.Source != filepath.Join(tmpDir, "LOCAL.md") {
+		t.Errorf("Source = %s, expected the local config's", cfg.Source)
+	}
+
+	var paths []string
+	for _, path := range cfg.LinkPaths() {
+		rel, _ := filepath.Rel(tmpDir, path)
+		paths = append(paths, rel)
+	}
+	expected := []string{"CLAUDE.md", ".github/copilot-instructions.md", ".codex/AGENTS.md"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("LinkPaths() = %v, expected %v", paths, expected)
+	}
+	if cfg.Groups["codex"].When == nil {
+		t.Errorf("The profile's group condition was not merged")
+	}
+
+	os.Remove(filepath.Join(tmpDir, LocalConfigName))
+	cfg, err = LoadConfigProfile(configPath, "work")
+	if err != nil {
+		t.Fatalf("LoadConfigProfile() failed: %v", err)
+	}
+	if cfg.Source != filepath.Join(tmpDir, "work", "INSTRUCTIONS.md") {
+		t.Errorf("Source = %s, expected the profile's", cfg.Source)
+	}
+
+	others, err := cfg.OtherProfiles()
+	if err != nil {
+		t.Fatalf("OtherProfiles() failed: %v", err)
+	}
+	if len(others) != 2 || others[0].ActiveProfile != "" || others[1].ActiveProfile != "personal" {
+		t.Errorf("OtherProfiles() returned the wrong profiles")
+	}
+
+	_, err = LoadConfigProfile(configPath, "home")
+	var unknown *UnknownProfileError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Defined, []string{"work", "personal"}) {
+		t.Errorf("LoadConfigProfile() with an unknown profile = %v", err)
+	}
+}
+
+func TestLoadConfigProfileFromBase(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "team.yaml"), []byte(`source: AGENTS.md
+links:
+  - CLAUDE.md
+profiles:
+  work:
+    source: ~/work/INSTRUCTIONS.md
+`), 0644)
+	configPath := filepath.Join(tmpDir, ".agentlink.yaml")
+	os.WriteFile(configPath, []byte("extends: team.yaml\nlinks:\n  - GEMINI.md\n"), 0644)
+
+	cfg, err := LoadConfigProfile(configPath, "work")
+	if err != nil {
+		t.Fatalf("LoadConfigProfile() failed: %v", err)
+	}
+	home, _ := os.UserHomeDir()
+	if cfg.Source != filepath.Join(home, "work", "INSTRUCTIONS.md") {
+		t.Errorf("Source = %s, expected the inherited profile's", cfg.Source)
+	}
+	if len(cfg.Links) != 2 {
+		t.Errorf("Links = %v, expected CLAUDE.md and GEMINI.md", cfg.Links)
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/tools"
+)
+
+// inferTools sets the tools of render and configure links that name none to
+// the tools known to read the link's path, with root as the project root.
+// Configure links only get tools that have a setting agentlink can edit.
+func (c *Config) inferTools(root string) {
+	homeDir, _ := os.UserHomeDir()
+	infer := func(links []Link) {
+		for i, link := range links {
+			if (link.Mode != ModeRender && link.Mode != ModeConfigure) || len(link.Tool) > 0 {
+				continue
+			}
+			for _, tool := range tools.MatchPath(link.Path, root, homeDir) {
+				if link.Mode == ModeConfigure && tool.SettingsKey == "" {
+					continue
+				}
+				links[i].Tool = append(links[i].Tool, tool.Name)
+			}
+		}
+	}
+
+	infer(c.Links)
+	for _, group := range c.Groups {
+		infer(group.Links)
+	}
+}
+
+// checkConfigure reports tools of a configure link that have no setting
+// naming their instruction file
+func (l Link) checkConfigure() Diagnostics {
+	var diags Diagnostics
+	if l.Mode != ModeConfigure {
+		return diags
+	}
+	for _, name := range l.Tool {
+		if tool, ok := tools.Lookup(name); ok && tool.SettingsKey == "" {
+			diags.add(l.Pos, "%s cannot be configured to read another file (configure supports %s)", name, strings.Join(configurableTools(), ", "))
+		}
+	}
+	return diags
+}
+
+// configurableTools returns the tools that configure mode can point at the
+// source
+func configurableTools() []string {
+	var names []string
+	for _, tool := range tools.Registry {
+		if tool.SettingsKey != "" {
+			names = append(names, tool.Name)
+		}
+	}
+	return names
+}
+
+// checkTools reports tool names of a render link missing from the registry
+func (l Link) checkTools() Diagnostics {
+	var diags Diagnostics
+	for _, name := range l.Tool {
+		diags.checkToolName(l.Pos, name, "for link "+l.Path)
+	}
+	return diags
+}
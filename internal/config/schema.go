@@ -0,0 +1,196 @@
+package config
+
+import (
+	_ "embed" // for the generated schema
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:generate go run ./schemagen
+
+// SchemaURL is where the JSON Schema for config files is published, used in
+// the yaml-language-server modeline of new configs
+const SchemaURL = "https://raw.githubusercontent.com/martinmose/agentlink/main/internal/config/agentlink.schema.json"
+
+// SchemaModeline is the comment that points yaml-language-server at the schema
+const SchemaModeline = "# yaml-language-server: $schema=" + SchemaURL
+
+// SchemaJSON is the JSON Schema for config files, generated from the Config
+// type by 'go generate'
+//
+//go:embed agentlink.schema.json
+var SchemaJSON []byte
+
+// Schema is a JSON Schema (draft-07) node
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // false or a *Schema
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+// schemaProvider is implemented by types whose YAML form differs from their
+// Go shape, e.g. because of a custom UnmarshalYAML
+type schemaProvider interface {
+	jsonSchema() *Schema
+}
+
+var schemaProviderType = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+
+// GenerateSchema builds the JSON Schema for config files from the Config
+// type: yaml tags give the property names, doc tags the descriptions and
+// enum tags the allowed values.
+// Nothing is required, a local config only adds to the project config.
+func GenerateSchema() ([]byte, error) {
+	root := typeSchema(reflect.TypeOf(Config{}))
+	root.Schema = "http://json-schema.org/draft-07/schema#"
+	root.ID = SchemaURL
+	root.Title = "agentlink config"
+	root.Description = "Configuration for agentlink (.agentlink.yaml or ~/.config/agentlink/config.yaml)"
+
+	version := root.Properties["version"]
+	version.Minimum, version.Maximum = intPtr(0), intPtr(CurrentVersion)
+	root.Properties["links"].UniqueItems = true
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// typeSchema returns the schema for values of type t
+func typeSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).jsonSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int:
+		return &Schema{Type: "integer"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// structSchema returns the schema of the mapping form of struct type t
+func structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" || name == "" || !field.IsExported() {
+			continue
+		}
+		property := typeSchema(field.Type)
+		property.Description = field.Tag.Get("doc")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property.Enum = strings.Split(enum, ",")
+		}
+		s.Properties[name] = property
+	}
+	return s
+}
+
+// pathSchema is the schema of a non-empty path
+func pathSchema() *Schema {
+	return &Schema{Type: "string", MinLength: intPtr(1)}
+}
+
+func (StringList) jsonSchema() *Schema {
+	return &Schema{OneOf: []*Schema{
+		pathSchema(),
+		{Type: "array", Items: pathSchema(), MinItems: intPtr(1)},
+	}}
+}
+
+func (Link) jsonSchema() *Schema {
+	options := structSchema(reflect.TypeOf(Link{}))
+	options.Properties["path"].MinLength = intPtr(1)
+	options.Required = []string{"path"}
+	return &Schema{OneOf: []*Schema{pathSchema(), options}}
+}
+
+func intPtr(i int) *int { return &i }
+
+// tomlSchemaDirective points TOML language servers (taplo) at the schema
+const tomlSchemaDirective = "#:schema " + SchemaURL
+
+// addSchemaHint points editors at the schema in the way the document's
+// format supports: a modeline comment for YAML, a directive comment for
+// TOML, a $schema key for JSON
+func (d *Document) addSchemaHint() {
+	switch d.format {
+	case FormatJSON:
+		m := d.mapping()
+		m.Content = append([]*yaml.Node{scalarNode("$schema"), scalarNode(SchemaURL)}, m.Content...)
+	case FormatTOML:
+		d.root.HeadComment = joinComments(tomlSchemaDirective, d.root.HeadComment)
+	default:
+		d.root.HeadComment = joinComments(SchemaModeline, d.root.HeadComment)
+	}
+}
+
+// removeSchemaHint removes any schema hint addSchemaHint may have added
+func (d *Document) removeSchemaHint() {
+	d.root.HeadComment = withoutSchemaHint(d.root.HeadComment)
+
+	m := d.mapping()
+	if len(m.Content) > 0 {
+		m.Content[0].HeadComment = withoutSchemaHint(m.Content[0].HeadComment)
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == "$schema" {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			break
+		}
+	}
+}
+
+// withoutSchemaHint drops schema modeline and directive lines from a comment
+func withoutSchemaHint(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	var kept []string
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.Contains(line, "yaml-language-server: $schema=") || strings.HasPrefix(line, "#:schema") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+func joinComments(first, second string) string {
+	if second == "" {
+		return first
+	}
+	return first + "\n" + second
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaUpToDate(t *testing.T) {
+	generated, err := GenerateSchema()
+	if err != nil {
+		t.Fatalf("GenerateSchema() failed: %v", err)
+	}
+	if string(generated) != string(SchemaJSON) {
+		t.Error("agentlink.schema.json is out of date, run 'go generate ./internal/config'")
+	}
+}
+
+func TestSchemaProperties(t *testing.T) {
+	var schema Schema
+	if err := json.Unmarshal(SchemaJSON, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	for _, key := range []string{"version", "source", "links", "groups", "template"} {
+		property, ok := schema.Properties[key]
+		if !ok {
+			t.Errorf("Schema has no property %s", key)
+			continue
+		}
+		if property.Description == "" {
+			t.Errorf("Property %s has no description", key)
+		}
+	}
+
+	if len(schema.Properties["source"].OneOf) != 2 {
+		t.Errorf("source should be a string or a list of strings")
+	}
+	if schema.AdditionalProperties != false {
+		t.Errorf("Unknown keys should not be allowed")
+	}
+}
+
+func TestCreateProjectConfigModeline(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".agentlink.yaml")
+	if err := CreateProjectConfig(configPath); err != nil {
+		t.Fatalf("CreateProjectConfig() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), SchemaModeline+"\n") {
+		t.Errorf("Expected the config to start with the schema modeline:\n%s", data)
+	}
+
+	// The modeline must not get in the way of loading
+	if _, err := readConfig(configPath, filepath.Dir(configPath)); err != nil {
+		t.Errorf("Created config does not load: %v", err)
+	}
+}
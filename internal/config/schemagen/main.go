@@ -0,0 +1,20 @@
+// Command schemagen writes agentlink.schema.json from the config types. It
+// is run by 'go generate' in internal/config.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/martinmose/agentlink/internal/config"
+)
+
+func main() {
+	data, err := config.GenerateSchema()
+	if err != nil {
+		log.Fatalf("failed to generate schema: %v", err)
+	}
+	if err := os.WriteFile("agentlink.schema.json", data, 0644); err != nil {
+		log.Fatalf("failed to write schema: %v", err)
+	}
+}
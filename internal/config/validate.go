@@ -0,0 +1,354 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/suggest"
+	"github.com/martinmose/agentlink/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a location in a config file
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String formats the position as file:line:col, leaving out unknown parts
+func (p Position) String() string {
+	switch {
+	case p.Line == 0:
+		return p.File
+	case p.File == "":
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	default:
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+}
+
+// Diagnostic is a problem at a position in a config file
+type Diagnostic struct {
+	Pos     Position
+	Message string
+}
+
+func (d *Diagnostic) Error() string {
+	if pos := d.Pos.String(); pos != "" {
+		return pos + ": " + d.Message
+	}
+	return d.Message
+}
+
+// Diagnostics is a list of problems, reported together as one error with
+// one problem per line
+type Diagnostics []*Diagnostic
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d *Diagnostics) add(pos Position, format string, args ...interface{}) {
+	*d = append(*d, &Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkToolName looks name up in the registry and reports it at pos if no
+// tool has it, suggesting the closest name. context says where the name is
+// used, e.g. "for group mcp".
+func (d *Diagnostics) checkToolName(pos Position, name, context string) (tools.Tool, bool) {
+	tool, ok := tools.Lookup(name)
+	if !ok {
+		if suggestion := suggest.Closest(name, tools.Names(), 2); suggestion != "" {
+			d.add(pos, "unknown tool %q %s (did you mean %q?)", name, context, suggestion)
+		} else {
+			d.add(pos, "unknown tool %q %s", name, context)
+		}
+	}
+	return tool, ok
+}
+
+// nodePosition returns the position of a node (without the file)
+func nodePosition(node *yaml.Node) Position {
+	return Position{Line: node.Line, Column: node.Column}
+}
+
+// nodeError returns a diagnostic at node
+func nodeError(node *yaml.Node, message string) error {
+	return &Diagnostic{Pos: nodePosition(node), Message: message}
+}
+
+// withFile adds the config file to a decoding error
+func withFile(err error, path string) error {
+	var diag *Diagnostic
+	if errors.As(err, &diag) {
+		diag.Pos.File = path
+		return Diagnostics{diag}
+	}
+	return fmt.Errorf("failed to parse config file %s: %w", path, err)
+}
+
+// mappingValue returns the value node of key in a mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// checkNode strictly checks node against the type it will be decoded into:
+// mapping keys must match a field's yaml tag, and values must have the
+// right shape. Types with their own UnmarshalYAML check their scalar and
+// sequence forms themselves.
+func checkNode(node *yaml.Node, t reflect.Type, file string) Diagnostics {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	pos := nodePosition(node)
+	pos.File = file
+
+	if isRemoval(node) && t != reflect.TypeOf(Link{}) {
+		return Diagnostics{{Pos: pos, Message: RemoveTag + " is only allowed on links"}}
+	}
+
+	var diags Diagnostics
+	custom := reflect.PointerTo(t).Implements(unmarshalerType)
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			if !custom {
+				diags.add(pos, "expected a mapping")
+			}
+			return diags
+		}
+
+		fields := yamlFields(t)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				keyPos := nodePosition(key)
+				keyPos.File = file
+				if suggestion := suggest.Closest(key.Value, names, 2); suggestion != "" {
+					diags.add(keyPos, "unknown field %q (did you mean %q?)", key.Value, suggestion)
+				} else {
+					diags.add(keyPos, "unknown field %q", key.Value)
+				}
+				continue
+			}
+			diags = append(diags, checkNode(value, fieldType, file)...)
+		}
+
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			if !custom {
+				diags.add(pos, "expected a list")
+			}
+			return diags
+		}
+		for _, item := range node.Content {
+			diags = append(diags, checkNode(item, t.Elem(), file)...)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			if !custom {
+				diags.add(pos, "expected a mapping")
+			}
+			return diags
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			diags = append(diags, checkNode(node.Content[i], t.Elem(), file)...)
+		}
+
+	case reflect.String, reflect.Bool, reflect.Int:
+		if node.Kind != yaml.ScalarNode && !custom {
+			diags.add(pos, "expected a %s", scalarName(t.Kind()))
+		}
+	}
+
+	return diags
+}
+
+// yamlFields maps the yaml keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" || name == "" || !field.IsExported() {
+			continue
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func scalarName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// Validate validates the configuration
+func (c *Config) Validate() error {
+	var diags Diagnostics
+	filePos := Position{File: c.Path}
+	c.Warnings = append(Diagnostics(nil), c.migrated...)
+
+	if len(c.Sources) == 0 {
+		diags.add(filePos, "source cannot be empty")
+	}
+	diags = append(diags, c.validateEntries()...)
+	c.Warnings = append(c.Warnings, c.unmatched...)
+	// With several candidates the unchosen ones are links already
+	links := c.AllLinks()
+	if len(links) == 0 && len(c.Sources) < 2 {
+		diags.add(filePos, "links cannot be empty")
+	}
+
+	diags = append(diags, c.validateCompose()...)
+	diags = append(diags, c.validateAgents()...)
+	diags = append(diags, c.validateIgnore()...)
+	diags = append(diags, c.validateMCP()...)
+	diags = append(diags, c.validateBudgets()...)
+	for _, link := range c.AllLinks() {
+		switch {
+		case link.Mode == ModeRender && len(link.Tool) == 0:
+			diags.add(link.Pos, "cannot tell which tool reads %s, add tool: to render it", link.Path)
+		case link.Mode == ModeConfigure && len(link.Tool) == 0:
+			diags.add(link.Pos, "no tool that reads %s can be configured, add tool: %s", link.Path, strings.Join(configurableTools(), " or "))
+		}
+	}
+
+	for i, link := range links {
+		for _, source := range c.Sources {
+			switch {
+			case link.Path == source && len(c.Sources) > 1:
+				c.Warnings.add(link.Pos, "link %s is a source candidate, candidates are linked automatically", link.Path)
+			case link.Path == source:
+				diags.add(link.Pos, "link %s is the source", link.Path)
+			case isInside(link.Path, source):
+				diags.add(link.Pos, "link %s is inside the source %s", link.Path, source)
+			case isInside(source, link.Path):
+				diags.add(link.Pos, "source %s is inside link %s", source, link.Path)
+			}
+		}
+
+		for _, other := range links[:i] {
+			switch {
+			case link.Path == other.Path:
+				diags.add(link.Pos, "duplicate link %s (already listed at %s)", link.Path, other.Pos)
+			case isInside(link.Path, other.Path):
+				diags.add(link.Pos, "link %s is inside link %s (at %s)", link.Path, other.Path, other.Pos)
+			case isInside(other.Path, link.Path):
+				diags.add(link.Pos, "link %s contains link %s (at %s)", link.Path, other.Path, other.Pos)
+			}
+		}
+	}
+
+	if len(diags) > 0 {
+		return diags
+	}
+	return nil
+}
+
+// validateEntries checks the entries present in a single config file, which
+// may be incomplete on its own
+func (c *Config) validateEntries() Diagnostics {
+	var diags Diagnostics
+	for _, source := range c.Sources {
+		if source == "" {
+			diags.add(c.SourcePos, "source candidates cannot be empty")
+		}
+	}
+	for _, link := range c.AllLinks() {
+		if link.Path == "" {
+			diags.add(link.Pos, "links cannot contain empty paths")
+		}
+		diags = append(diags, link.When.check(link.Pos)...)
+		if link.Style != "" && link.Style != StyleRelative && link.Style != StyleAbsolute {
+			diags.add(link.Pos, "unknown style %q for link %s (expected %s or %s)", link.Style, link.Path, StyleRelative, StyleAbsolute)
+		}
+		switch link.Mode {
+		case "", ModeSymlink, ModeCopy, ModeHardlink, ModeRender, ModeCursor, ModeCopilot, ModeConfigure:
+		default:
+			diags.add(link.Pos, "unknown mode %q for link %s (expected %s)", link.Mode, link.Path,
+				strings.Join([]string{ModeSymlink, ModeCopy, ModeHardlink, ModeRender, ModeCursor, ModeCopilot, ModeConfigure}, ", "))
+			continue
+		}
+		if link.Style != "" && link.Mode != "" && link.Mode != ModeSymlink {
+			diags.add(link.Pos, "style only applies to symlinks, not to mode %s", link.Mode)
+		}
+		if link.Header && !link.IsCopy() {
+			diags.add(link.Pos, "header only applies to links with mode %s or %s", ModeCopy, ModeRender)
+		}
+		if len(link.Tool) > 0 && link.Mode != ModeRender && link.Mode != ModeConfigure {
+			diags.add(link.Pos, "tool only applies to links with mode %s or %s", ModeRender, ModeConfigure)
+		}
+		diags = append(diags, link.checkTools()...)
+		diags = append(diags, link.checkConfigure()...)
+		diags = append(diags, link.checkAdapter()...)
+	}
+	for _, group := range c.GroupList() {
+		diags = append(diags, group.When.check(group.Pos)...)
+		diags = append(diags, group.check()...)
+	}
+	return diags
+}
+
+// checkDuplicateLinks reports links of a single file that point to the
+// same path after expansion; written holds the paths as they were written
+func (c *Config) checkDuplicateLinks(written []string) Diagnostics {
+	var diags Diagnostics
+	for i, link := range c.Links {
+		for j, other := range c.Links[:i] {
+			if link.Path != other.Path {
+				continue
+			}
+			if written[i] == written[j] {
+				diags.add(link.Pos, "duplicate link %s (already listed at line %d)", written[i], other.Pos.Line)
+			} else {
+				diags.add(link.Pos, "link %s resolves to the same path as %s (line %d)", written[i], written[j], other.Pos.Line)
+			}
+			break
+		}
+	}
+	return diags
+}
+
+// isInside reports whether path is strictly inside the directory dir
+func isInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && !isOutside(rel)
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "unknown field with suggestion",
+			content:  "source: AGENTS.md\nlink:\n  - CLAUDE.md\n",
+			expected: []string{":2:1: unknown field \"link\" (did you mean \"links\"?)"},
+		},
+		{
+			name:     "links is not a list",
+			content:  "source: AGENTS.md\nlinks:\n  path: CLAUDE.md\n",
+			expected: []string{":3:3: expected a list"},
+		},
+		{
+			name:     "link is not a path",
+			content:  "source: AGENTS.md\nlinks:\n  - [CLAUDE.md]\n",
+			expected: []string{":3:5: a link must be a path"},
+		},
+		{
+			name:     "duplicate link",
+			content:  "source: AGENTS.md\nlinks:\n  - CLAUDE.md\n  - CLAUDE.md\n",
+			expected: []string{":4:5: duplicate link CLAUDE.md (already listed at line 3)"},
+		},
+		{
+			name:     "links resolving to the same path",
+			content:  "source: AGENTS.md\nlinks:\n  - CLAUDE.md\n  - ./CLAUDE.md\n",
+			expected: []string{":4:5: link ./CLAUDE.md resolves to the same path as CLAUDE.md (line 3)"},
+		},
+		{
+			name:     "unknown link style",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    style: hard\n",
+			expected: []string{":3:5: unknown style \"hard\" for link ", "(expected relative or absolute)"},
+		},
+		{
+			name:     "unknown link mode",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    mode: magic\n",
+			expected: []string{":3:5: unknown mode \"magic\" for link "},
+		},
+		{
+			name:     "header without copy mode",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    header: true\n",
+			expected: []string{":3:5: header only applies to links with mode copy or render"},
+		},
+		{
+			name:     "style with copy mode",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    mode: copy\n    style: absolute\n",
+			expected: []string{":3:5: style only applies to symlinks, not to mode copy"},
+		},
+		{
+			name:     "style with hardlink mode",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    mode: hardlink\n    style: relative\n",
+			expected: []string{":3:5: style only applies to symlinks, not to mode hardlink"},
+		},
+		{
+			name:     "tool without render mode",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    tool: claude\n",
+			expected: []string{":3:5: tool only applies to links with mode render or configure"},
+		},
+		{
+			name:     "configure a tool without settings",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    mode: configure\n    tool: claude\n",
+			expected: []string{":3:5: claude cannot be configured to read another file (configure supports codex, gemini)"},
+		},
+		{
+			name:     "configure without a configurable tool",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    mode: configure\n",
+			expected: []string{":3:5: no tool that reads ", "CLAUDE.md can be configured, add tool: codex or gemini"},
+		},
+		{
+			name:     "unknown render tool",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    mode: render\n    tool: claud\n",
+			expected: []string{":3:5: unknown tool \"claud\" for link CLAUDE.md (did you mean \"claude\"?)"},
+		},
+		{
+			name:     "render without a known tool",
+			content:  "source: AGENTS.md\nlinks:\n  - path: NOTES.md\n    mode: render\n",
+			expected: []string{":3:5: cannot tell which tool reads ", "NOTES.md, add tool: to render it"},
+		},
+		{
+			name:     "unknown link option",
+			content:  "source: AGENTS.md\nlinks:\n  - path: CLAUDE.md\n    optinal: true\n",
+			expected: []string{":4:5: unknown field \"optinal\" (did you mean \"optional\"?)"},
+		},
+		{
+			name:     "link without a path",
+			content:  "source: AGENTS.md\nlinks:\n  - optional: true\n",
+			expected: []string{":3:5: a link needs a path"},
+		},
+		{
+			name:     "link is the source",
+			content:  "version: 1\nsource: AGENTS.md\nlinks:\n  - CLAUDE.md\n  - AGENTS.md\n",
+			expected: []string{":5:5: link ", "AGENTS.md is the source"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			_, err := LoadConfig(configPath)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), configPath+":") {
+				t.Errorf("Expected error to start with %s, got %q", configPath, err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigReportsAllProblems(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := "sorce: AGENTS.md\ntemplte: t.md\nlinks: CLAUDE.md\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadConfig(configPath)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 problems, got %d: %q", len(lines), err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package ignore matches paths against .gitignore files
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matcher decides whether paths below a root directory are ignored by the
+// .gitignore files read so far. Paths are relative to the root and use
+// forward slashes.
+type Matcher struct {
+	root  string
+	rules []rule
+}
+
+type rule struct {
+	// base is the directory of the .gitignore file, relative to the root
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// New returns a matcher for root with the rules of .git/info/exclude and
+// the root .gitignore
+func New(root string) (*Matcher, error) {
+	m := &Matcher{root: root}
+	if err := m.addFile(filepath.Join(root, ".git", "info", "exclude"), ""); err != nil {
+		return nil, err
+	}
+	if err := m.AddDir(""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AddDir reads the .gitignore file in dir (relative to the root), if any.
+// Call it for each directory on the way down, so deeper rules take
+// precedence.
+func (m *Matcher) AddDir(dir string) error {
+	return m.addFile(filepath.Join(m.root, filepath.FromSlash(dir), ".gitignore"), dir)
+}
+
+func (m *Matcher) addFile(file, base string) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.AddPattern(scanner.Text(), base)
+	}
+	return scanner.Err()
+}
+
+// AddPattern adds a single .gitignore line as if it was read from a
+// .gitignore file in base
+func (m *Matcher) AddPattern(line, base string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// A slash anywhere but at the end anchors the pattern to its base,
+	// otherwise it matches a name at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+}
+
+// Ignored reports whether the path (relative to the root) is ignored. The
+// last matching rule wins. Directories above the path are not checked, a
+// walker skips ignored directories instead of descending into them.
+func (m *Matcher) Ignored(relPath string, isDir bool) bool {
+	relPath = path.Clean(filepath.ToSlash(relPath))
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+
+		rel := relPath
+		if r.base != "" {
+			if !strings.HasPrefix(relPath, r.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(relPath, r.base+"/")
+		}
+
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates a gitignore glob to a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
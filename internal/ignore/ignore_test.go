@@ -0,0 +1,79 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	m := &Matcher{}
+	for _, pattern := range []string{
+		"# comment",
+		"node_modules/",
+		"*.log",
+		"!keep.log",
+		"/build",
+		"docs/**/generated",
+		"vendor/**",
+		`\#hash`,
+	} {
+		m.AddPattern(pattern, "")
+	}
+	m.AddPattern("local", "packages/a")
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"node_modules", true, true},
+		{"packages/a/node_modules", true, true},
+		{"node_modules", false, false},
+		{"debug.log", false, true},
+		{"packages/a/debug.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"packages/build", true, false},
+		{"docs/generated", true, true},
+		{"docs/api/v1/generated", true, true},
+		{"vendor/github.com", true, true},
+		{"#hash", false, true},
+		{"packages/a/local", true, true},
+		{"packages/b/local", true, false},
+		{"local", true, false},
+		{"src", true, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path, tt.isDir); got != tt.expected {
+			t.Errorf("Ignored(%s, %v) = %v, expected %v", tt.path, tt.isDir, got, tt.expected)
+		}
+	}
+}
+
+func TestNewReadsGitignore(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("dist/\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "pkg"), 0755)
+	os.WriteFile(filepath.Join(root, "pkg", ".gitignore"), []byte("tmp\n!dist\n"), 0644)
+
+	m, err := New(root)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if !m.Ignored("dist", true) || m.Ignored("pkg/tmp", true) {
+		t.Errorf("Unexpected matches before reading pkg/.gitignore")
+	}
+
+	if err := m.AddDir("pkg"); err != nil {
+		t.Fatalf("AddDir() failed: %v", err)
+	}
+	if !m.Ignored("pkg/tmp", true) {
+		t.Errorf("pkg/tmp should be ignored by pkg/.gitignore")
+	}
+	// Deeper files take precedence
+	if m.Ignored("pkg/dist", true) {
+		t.Errorf("pkg/dist should be re-included by pkg/.gitignore")
+	}
+}
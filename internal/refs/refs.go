@@ -0,0 +1,178 @@
+// Package refs finds the references to other files in an instruction file:
+// @imports and relative markdown links, which tools resolve from the
+// directory of the file they read
+package refs
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Kinds of references
+const (
+	// KindImport is an @path import, which Claude Code and Gemini CLI
+	// inline into the instructions
+	KindImport = "import"
+	// KindLink is a markdown link or image
+	KindLink = "link"
+)
+
+// Ref is a reference to a file
+type Ref struct {
+	// Line is the 1-based line number
+	Line int
+	Kind string
+	// Text is the reference as written, e.g. @docs/style.md or
+	// [guide](docs/guide.md)
+	Text string
+	// Path is the file referenced, without anchor or query
+	Path string
+}
+
+var (
+	// inlineLink matches [text](path "title"), ![alt](path) and
+	// [text](<path with spaces>)
+	inlineLink = regexp.MustCompile(`!?\[[^\]]*\]\(\s*(?:<([^>]+)>|([^)\s]+))(?:\s+["'(][^)]*)?\)`)
+	// definition matches a link reference definition, [label]: path
+	definition = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	// importRef matches @path at the start of a line or after a space
+	importRef = regexp.MustCompile(`(?:^|\s)(@([^\s@]+))`)
+	// fence matches the start or end of a fenced code block
+	fence = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+	// codeSpan matches inline code, which holds no references
+	codeSpan = regexp.MustCompile("`[^`]*`")
+)
+
+// Find returns the references to local files in content. URLs, anchors
+// and everything in code is left out, and so are @words that don't look
+// like a path, such as @mentions.
+func Find(content []byte) []Ref {
+	var refs []Ref
+	inFence := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if match := fence.FindStringSubmatch(line); match != nil {
+			switch inFence {
+			case "":
+				inFence = match[1]
+			case match[1]:
+				inFence = ""
+			}
+			continue
+		}
+		if inFence != "" {
+			continue
+		}
+		line = codeSpan.ReplaceAllStringFunc(line, func(span string) string {
+			return strings.Repeat(" ", len(span))
+		})
+
+		for _, match := range inlineLink.FindAllStringSubmatch(line, -1) {
+			if path, ok := localPath(match[1] + match[2]); ok {
+				refs = append(refs, Ref{Line: n, Kind: KindLink, Text: match[0], Path: path})
+			}
+		}
+		if match := definition.FindStringSubmatch(line); match != nil {
+			if path, ok := localPath(match[1]); ok {
+				refs = append(refs, Ref{Line: n, Kind: KindLink, Text: strings.TrimSpace(match[0]), Path: path})
+			}
+		}
+		for _, match := range importRef.FindAllStringSubmatch(line, -1) {
+			text := strings.TrimRight(match[1], ".,;:!?)]")
+			path := strings.TrimPrefix(text, "@")
+			// Plain words are mentions, paths have a directory or extension
+			if !strings.ContainsAny(path, "/.") {
+				continue
+			}
+			if path, ok := localPath(path); ok {
+				refs = append(refs, Ref{Line: n, Kind: KindImport, Text: text, Path: path})
+			}
+		}
+	}
+	return refs
+}
+
+// localPath returns the file a reference target names, or false if it is
+// not a local file
+func localPath(target string) (string, bool) {
+	if strings.HasPrefix(target, "#") || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+		return "", false
+	}
+	if i := strings.IndexAny(target, "#?"); i >= 0 {
+		target = target[:i]
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	return target, target != ""
+}
+
+// Resolve returns the file a reference points to when read from a file in
+// dir. Root-relative links (/docs/guide.md) resolve from root, the project
+// root, while an @import starting with / is an absolute path. Paths
+// starting with ~/ resolve from homeDir.
+func Resolve(ref Ref, dir, root, homeDir string) string {
+	path := filepath.FromSlash(ref.Path)
+	switch {
+	case strings.HasPrefix(ref.Path, "~/"):
+		return filepath.Join(homeDir, path[2:])
+	case strings.HasPrefix(ref.Path, "/") && ref.Kind == KindLink:
+		return filepath.Join(root, path)
+	case filepath.IsAbs(path):
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// IsRelative reports whether the reference resolves from the directory of
+// the file it is in
+func (r Ref) IsRelative() bool {
+	return !strings.HasPrefix(r.Path, "/") && !strings.HasPrefix(r.Path, "~/")
+}
+
+// MaxImportDepth is how many hops of nested @imports are followed, as in
+// Claude Code
+const MaxImportDepth = 5
+
+// Expand returns content, the file at path, followed by the content of the
+// files its @imports name, and theirs in turn. Each file is added once,
+// and imports that don't resolve are left out. It returns the imported
+// files too.
+func Expand(path string, content []byte, root, homeDir string) ([]byte, []string) {
+	expanded := append([]byte(nil), content...)
+	seen := map[string]bool{filepath.Clean(path): true}
+	var files []string
+
+	var expand func(dir string, content []byte, depth int)
+	expand = func(dir string, content []byte, depth int) {
+		if depth > MaxImportDepth {
+			return
+		}
+		for _, ref := range Find(content) {
+			if ref.Kind != KindImport {
+				continue
+			}
+			file := Resolve(ref, dir, root, homeDir)
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			imported, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			files = append(files, file)
+			expanded = append(expanded, imported...)
+			expand(filepath.Dir(file), imported, depth+1)
+		}
+	}
+	expand(filepath.Dir(path), content, 1)
+	return expanded, files
+}
@@ -0,0 +1,78 @@
+package refs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	content := []byte(`# Instructions
+
+See @docs/style.md and [the guide](docs/guide.md#setup "Guide").
+Ask @alice, mail me@example.com or read [the site](https://example.com).
+![diagram](<images/flow chart.png>) and [top](#instructions)
+Home notes: @~/.claude/notes.md, root link [api](/docs/api.md).
+
+` + "`@not/this.md` and:\n```\n[skip](skip.md)\n@skip/too.md\n```\n" + `
+[ref]: docs/ref%20file.md
+`)
+
+	expected := []Ref{
+		{Line: 3, Kind: KindLink, Text: `[the guide](docs/guide.md#setup "Guide")`, Path: "docs/guide.md"},
+		{Line: 3, Kind: KindImport, Text: "@docs/style.md", Path: "docs/style.md"},
+		{Line: 5, Kind: KindLink, Text: "![diagram](<images/flow chart.png>)", Path: "images/flow chart.png"},
+		{Line: 6, Kind: KindLink, Text: "[api](/docs/api.md)", Path: "/docs/api.md"},
+		{Line: 6, Kind: KindImport, Text: "@~/.claude/notes.md", Path: "~/.claude/notes.md"},
+		{Line: 14, Kind: KindLink, Text: "[ref]: docs/ref%20file.md", Path: "docs/ref file.md"},
+	}
+
+	refs := Find(content)
+	if len(refs) != len(expected) {
+		t.Fatalf("Find() = %+v, expected %d references", refs, len(expected))
+	}
+	for i, ref := range refs {
+		if ref != expected[i] {
+			t.Errorf("Find()[%d] = %+v, expected %+v", i, ref, expected[i])
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	home := filepath.FromSlash("/home/me")
+	dir := filepath.Join(root, ".github")
+
+	tests := []struct {
+		ref      Ref
+		expected string
+	}{
+		{Ref{Kind: KindImport, Path: "docs/style.md"}, "/project/.github/docs/style.md"},
+		{Ref{Kind: KindLink, Path: "../docs/guide.md"}, "/project/docs/guide.md"},
+		{Ref{Kind: KindLink, Path: "/docs/guide.md"}, "/project/docs/guide.md"},
+		{Ref{Kind: KindImport, Path: "/etc/style.md"}, "/etc/style.md"},
+		{Ref{Kind: KindImport, Path: "~/notes.md"}, "/home/me/notes.md"},
+	}
+	for _, tt := range tests {
+		if got := Resolve(tt.ref, dir, root, home); got != filepath.FromSlash(tt.expected) {
+			t.Errorf("Resolve(%s) = %s, expected %s", tt.ref.Path, got, tt.expected)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "docs"), 0755)
+	os.WriteFile(filepath.Join(root, "docs", "style.md"), []byte("Style, see @extra.md and @../AGENTS.md\n"), 0644)
+	os.WriteFile(filepath.Join(root, "docs", "extra.md"), []byte("Extra\n"), 0644)
+	content := []byte("Rules: @docs/style.md @docs/missing.md\n")
+
+	expanded, files := Expand(filepath.Join(root, "AGENTS.md"), content, root, root)
+	if string(expanded) != string(content)+"Style, see @extra.md and @../AGENTS.md\nExtra\n" {
+		t.Errorf("Expand() = %q", expanded)
+	}
+	// The file itself is not imported again, and missing files are left out
+	if len(files) != 2 || files[0] != filepath.Join(root, "docs", "style.md") || files[1] != filepath.Join(root, "docs", "extra.md") {
+		t.Errorf("Expand() files = %v", files)
+	}
+}
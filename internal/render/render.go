@@ -0,0 +1,127 @@
+// Package render filters a source file for one tool, following the
+// agentlink marker comments in it
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// marker matches a marker comment on a line of its own, such as
+// <!-- agentlink:only claude, gemini --> or <!-- agentlink:end -->
+var marker = regexp.MustCompile(`^\s*<!--\s*agentlink:(\S+)\s*(.*?)\s*-->\s*$`)
+
+// section is an open only or except block
+type section struct {
+	line    int
+	include bool
+}
+
+// Filter returns content as the given tools should see it. Lines between
+// <!-- agentlink:only a, b --> and <!-- agentlink:end --> are kept only for
+// tools a and b; lines between <!-- agentlink:except a --> and the end
+// marker are dropped for tool a. Sections can be nested. The marker lines
+// themselves are dropped.
+func Filter(content []byte, tools []string) ([]byte, error) {
+	var out bytes.Buffer
+	var open []section
+	included := true
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	scanner.Split(scanLines)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		match := marker.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if match == nil {
+			if included {
+				out.WriteString(line)
+			}
+			continue
+		}
+
+		keyword, args := match[1], match[2]
+		switch keyword {
+		case "only", "except":
+			names := splitNames(args)
+			if len(names) == 0 {
+				return nil, fmt.Errorf("line %d: agentlink:%s needs tool names", lineNo, keyword)
+			}
+			include := matchesAny(names, tools) == (keyword == "only")
+			open = append(open, section{line: lineNo, include: included})
+			included = included && include
+		case "end":
+			if len(open) == 0 {
+				return nil, fmt.Errorf("line %d: agentlink:end without agentlink:only or agentlink:except", lineNo)
+			}
+			included = open[len(open)-1].include
+			open = open[:len(open)-1]
+		default:
+			return nil, fmt.Errorf("line %d: unknown marker agentlink:%s (expected only, except or end)", lineNo, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("line %d: section is not closed with <!-- agentlink:end -->", open[len(open)-1].line)
+	}
+	return out.Bytes(), nil
+}
+
+// Tools returns the tool names named by markers in content, in the order
+// they first appear
+func Tools(content []byte) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		match := marker.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil || (match[1] != "only" && match[1] != "except") {
+			continue
+		}
+		for _, name := range splitNames(match[2]) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// splitNames splits a list of tool names separated by commas or spaces
+func splitNames(args string) []string {
+	return strings.FieldsFunc(args, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// matchesAny reports whether one of tools is among names
+func matchesAny(names, tools []string) bool {
+	for _, name := range names {
+		for _, tool := range tools {
+			if strings.EqualFold(name, tool) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scanLines splits like bufio.ScanLines but keeps the line endings, so the
+// output keeps the source's
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
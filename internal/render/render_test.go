@@ -0,0 +1,73 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+const source = `# Instructions
+
+Shared line.
+<!-- agentlink:only claude -->
+@docs/architecture.md
+<!-- agentlink:end -->
+<!-- agentlink:except copilot, cursor -->
+Run the tests before committing.
+<!-- agentlink:only gemini -->
+Gemini only, nested.
+<!-- agentlink:end -->
+<!-- agentlink:end -->
+Last line.
+`
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		tools    []string
+		expected string
+	}{
+		{[]string{"claude"}, "# Instructions\n\nShared line.\n@docs/architecture.md\nRun the tests before committing.\nLast line.\n"},
+		{[]string{"copilot"}, "# Instructions\n\nShared line.\nLast line.\n"},
+		{[]string{"gemini"}, "# Instructions\n\nShared line.\nRun the tests before committing.\nGemini only, nested.\nLast line.\n"},
+		{[]string{"codex", "opencode"}, "# Instructions\n\nShared line.\nRun the tests before committing.\nLast line.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.tools, ","), func(t *testing.T) {
+			got, err := Filter([]byte(source), tt.tools)
+			if err != nil {
+				t.Fatalf("Filter() failed: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Filter() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"unclosed", "a\n<!-- agentlink:only claude -->\nb\n", "line 2: section is not closed"},
+		{"stray end", "a\n<!-- agentlink:end -->\n", "line 2: agentlink:end without"},
+		{"no tools", "<!-- agentlink:only -->\n<!-- agentlink:end -->\n", "line 1: agentlink:only needs tool names"},
+		{"unknown", "<!-- agentlink:onyl claude -->\n", "unknown marker agentlink:onyl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Filter([]byte(tt.content), []string{"claude"})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Filter() error = %v, expected it to contain %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTools(t *testing.T) {
+	if got := strings.Join(Tools([]byte(source)), ","); got != "claude,copilot,cursor,gemini" {
+		t.Errorf("Tools() = %s", got)
+	}
+}
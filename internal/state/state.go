@@ -0,0 +1,168 @@
+// Package state records what agentlink wrote, so files it generated can be
+// told apart from files edited since
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// File is what agentlink last wrote to a path
+type File struct {
+	// Source is the file the content was generated from
+	Source string `json:"source"`
+	// Hash is the hash of the content written, see Hash
+	Hash string `json:"hash"`
+	// Owners are the configs that write an entry several of them can share,
+	// such as an MCP server in a tool's global config, see AddOwner
+	Owners []string `json:"owners,omitempty"`
+}
+
+// owners returns the configs that own the entry, Source for one recorded
+// before entries had owners
+func (f File) owners() []string {
+	if len(f.Owners) == 0 && f.Source != "" {
+		return []string{f.Source}
+	}
+	return f.Owners
+}
+
+// HasOwner reports whether owner writes the entry
+func (f File) HasOwner(owner string) bool {
+	return slices.Contains(f.owners(), owner)
+}
+
+func (f File) equal(other File) bool {
+	return f.Source == other.Source && f.Hash == other.Hash && slices.Equal(f.Owners, other.Owners)
+}
+
+// State maps absolute paths to what was written there
+type State struct {
+	Files map[string]File `json:"files"`
+
+	path    string
+	changed bool
+}
+
+// DefaultPath returns the state file: $XDG_STATE_HOME/agentlink/state.json,
+// or ~/.local/state/agentlink/state.json
+func DefaultPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "agentlink", "state.json"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "state", "agentlink", "state.json"), nil
+}
+
+// Load reads the state file at path. A missing file is an empty state.
+func Load(path string) (*State, error) {
+	s := &State{Files: make(map[string]File), path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]File)
+	}
+	return s, nil
+}
+
+// Get returns what was last written to path
+func (s *State) Get(path string) (File, bool) {
+	file, ok := s.Files[path]
+	return file, ok
+}
+
+// Set records what was written to path
+func (s *State) Set(path string, file File) {
+	if current, ok := s.Files[path]; !ok || !current.equal(file) {
+		s.Files[path] = file
+		s.changed = true
+	}
+}
+
+// Delete forgets path
+func (s *State) Delete(path string) {
+	if _, ok := s.Files[path]; ok {
+		delete(s.Files, path)
+		s.changed = true
+	}
+}
+
+// AddOwner records that owner wrote the entry at key with hash, keeping
+// the other configs that own it
+func (s *State) AddOwner(key, owner, hash string) {
+	file := File{Hash: hash, Owners: []string{owner}}
+	if current, ok := s.Files[key]; ok {
+		file.Owners = current.owners()
+		if !slices.Contains(file.Owners, owner) {
+			file.Owners = append(slices.Clone(file.Owners), owner)
+			slices.Sort(file.Owners)
+		}
+	}
+	s.Set(key, file)
+}
+
+// RemoveOwner forgets that owner writes the entry at key, and the entry
+// once no config owns it. It reports whether other configs still own it.
+func (s *State) RemoveOwner(key, owner string) bool {
+	file, ok := s.Files[key]
+	if !ok {
+		return false
+	}
+	owners := slices.DeleteFunc(slices.Clone(file.owners()), func(o string) bool { return o == owner })
+	if len(owners) == 0 {
+		s.Delete(key)
+		return false
+	}
+	s.Set(key, File{Hash: file.Hash, Owners: owners})
+	return true
+}
+
+// Save writes the state back to its file if it changed
+func (s *State) Save() error {
+	if !s.changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace state file %s: %w", s.path, err)
+	}
+
+	s.changed = false
+	return nil
+}
+
+// Hash returns the hash of content as recorded in the state
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
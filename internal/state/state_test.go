@@ -0,0 +1,80 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agentlink", "state.json")
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of a missing file failed: %v", err)
+	}
+	if len(s.Files) != 0 {
+		t.Errorf("Load() of a missing file = %v, expected an empty state", s.Files)
+	}
+
+	file := File{Source: "/p/AGENTS.md", Hash: Hash([]byte("content"))}
+	s.Set("/p/CLAUDE.md", file)
+	s.Set("/p/GEMINI.md", file)
+	s.Delete("/p/GEMINI.md")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got, ok := s.Get("/p/CLAUDE.md"); !ok || !got.equal(file) {
+		t.Errorf("Get() = %v, %v, expected %v", got, ok, file)
+	}
+	if _, ok := s.Get("/p/GEMINI.md"); ok {
+		t.Errorf("Deleted path is still recorded")
+	}
+}
+
+func TestStateOwners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, _ := Load(path)
+
+	// An entry recorded before entries had owners is owned by its source
+	s.Set("/home/.codex/config.toml#github", File{Source: "/a/.agentlink.yaml", Hash: "h1"})
+	s.AddOwner("/home/.codex/config.toml#github", "/b/.agentlink.yaml", "h2")
+	s.AddOwner("/home/.codex/config.toml#github", "/b/.agentlink.yaml", "h2")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ = Load(path)
+	file, _ := s.Get("/home/.codex/config.toml#github")
+	if !file.HasOwner("/a/.agentlink.yaml") || !file.HasOwner("/b/.agentlink.yaml") || len(file.Owners) != 2 || file.Hash != "h2" {
+		t.Errorf("after AddOwner() = %+v", file)
+	}
+
+	if !s.RemoveOwner("/home/.codex/config.toml#github", "/a/.agentlink.yaml") {
+		t.Errorf("RemoveOwner() should report the other owner")
+	}
+	if file, _ := s.Get("/home/.codex/config.toml#github"); file.HasOwner("/a/.agentlink.yaml") || !file.HasOwner("/b/.agentlink.yaml") {
+		t.Errorf("after RemoveOwner() = %+v", file)
+	}
+	if s.RemoveOwner("/home/.codex/config.toml#github", "/b/.agentlink.yaml") {
+		t.Errorf("RemoveOwner() of the last owner should report none left")
+	}
+	if _, ok := s.Get("/home/.codex/config.toml#github"); ok {
+		t.Errorf("an entry without owners is still recorded")
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join("/state", "agentlink", "state.json") {
+		t.Errorf("DefaultPath() = %s", path)
+	}
+}
@@ -0,0 +1,41 @@
+// Package suggest finds likely intended words for typos
+package suggest
+
+import "strings"
+
+// Closest returns the option closest to word (ignoring case), or "" if none
+// is within maxDistance edits
+func Closest(word string, options []string, maxDistance int) string {
+	word = strings.ToLower(word)
+
+	best, bestDistance := "", maxDistance+1
+	for _, option := range options {
+		if d := Distance(word, strings.ToLower(option)); d < bestDistance {
+			best, bestDistance = option, d
+		}
+	}
+	return best
+}
+
+// Distance returns the Levenshtein edit distance between a and b
+func Distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,40 @@
+package suggest
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"links", "links", 0},
+		{"link", "links", 1},
+		{"sources", "source", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Distance(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	options := []string{"source", "links", "template"}
+
+	tests := map[string]string{
+		"link":     "links",
+		"Sources":  "source",
+		"tempalte": "template",
+		"version":  "",
+	}
+
+	for word, expected := range tests {
+		if got := Closest(word, options, 2); got != expected {
+			t.Errorf("Closest(%q) = %q, expected %q", word, got, expected)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package symlink
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/state"
+)
+
+// bannerHash finds the hash of the composed content in a banner
+var bannerHash = regexp.MustCompile(`\((sha256:[0-9a-f]+)\)`)
+
+// ComposeContent returns the file generated at outputPath from fragments:
+// a do-not-edit banner, then the content of each fragment in order,
+// separated by blank lines. The banner holds the hash of the content, so an
+// edited file can be told from one whose fragments changed.
+func ComposeContent(outputPath string, fragments []string) ([]byte, error) {
+	var body bytes.Buffer
+	names := make([]string, len(fragments))
+	for i, fragment := range fragments {
+		content, err := os.ReadFile(fragment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fragment %s: %w", fragment, err)
+		}
+		if i > 0 {
+			body.WriteString("\n")
+		}
+		body.Write(content)
+		if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+			body.WriteString("\n")
+		}
+
+		names[i] = fragment
+		if rel, err := filepath.Rel(filepath.Dir(outputPath), fragment); err == nil {
+			names[i] = filepath.ToSlash(rel)
+		}
+	}
+
+	banner := comment(outputPath, fmt.Sprintf("Generated by agentlink from %s. Do not edit, edit the fragments and run 'agentlink sync' (%s)",
+		strings.Join(names, ", "), state.Hash(body.Bytes())))
+	return append([]byte(banner+"\n\n"), body.Bytes()...), nil
+}
+
+// CheckCompose checks the file generated at outputPath. A file that is no
+// longer what the fragments make has StatusStale if it is as generated, and
+// StatusModified if it was edited.
+func (m *Manager) CheckCompose(outputPath string, fragments []string) *LinkInfo {
+	info := &LinkInfo{Path: outputPath}
+
+	fileInfo, err := os.Lstat(outputPath)
+	switch {
+	case os.IsNotExist(err):
+		info.Status = StatusMissing
+		return info
+	case err != nil:
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	case !fileInfo.Mode().IsRegular():
+		info.Status = StatusWrongMode
+		return info
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	}
+	expected, err := ComposeContent(outputPath, fragments)
+	if err != nil {
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	}
+
+	switch {
+	case bytes.Equal(content, expected):
+		info.Status = StatusOK
+	case isGenerated(content):
+		info.Status = StatusStale
+	default:
+		info.Status = StatusModified
+	}
+	return info
+}
+
+// isGenerated reports whether content is unchanged since it was composed,
+// going by the hash in its banner
+func isGenerated(content []byte) bool {
+	banner, body, ok := bytes.Cut(content, []byte("\n\n"))
+	if !ok {
+		return false
+	}
+	match := bannerHash.FindSubmatch(banner)
+	return match != nil && string(match[1]) == state.Hash(body)
+}
+
+// Compose writes the file generated at outputPath from fragments unless it
+// is up to date. A generated file edited by hand is only overwritten with
+// --force.
+func (m *Manager) Compose(outputPath string, fragments []string) (string, error) {
+	info := m.CheckCompose(outputPath, fragments)
+
+	action := ""
+	switch info.Status {
+	case StatusOK:
+		return "skip", nil
+	case StatusMissing:
+		action = "create"
+	case StatusStale:
+		action = "regenerate"
+	case StatusWrongMode:
+		if !m.force {
+			return "", fmt.Errorf("%s exists and is not a regular file, use --force to replace it", outputPath)
+		}
+		action = "replace"
+	case StatusModified:
+		if !m.force {
+			return "", fmt.Errorf("%s was %w, edit the fragments instead (or use --force to overwrite it)", outputPath, ErrModified)
+		}
+		action = "replace"
+	default:
+		return "", info.Error
+	}
+
+	content, err := ComposeContent(outputPath, fragments)
+	if err != nil {
+		return "", err
+	}
+	if err := m.WriteFile(outputPath, content); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+// RemoveComposed removes a generated file, unless it was edited
+func (m *Manager) RemoveComposed(outputPath string) error {
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", outputPath, err)
+	}
+	if !isGenerated(content) {
+		return fmt.Errorf("%s was edited, not removing it", outputPath)
+	}
+	if m.dryRun {
+		return nil
+	}
+	if err := os.Remove(outputPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", outputPath, err)
+	}
+	return nil
+}
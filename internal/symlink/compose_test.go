@@ -0,0 +1,67 @@
+package symlink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	team := filepath.Join(tmpDir, "team.md")
+	local := filepath.Join(tmpDir, "local.md")
+	os.WriteFile(team, []byte("team rules"), 0644)
+	os.WriteFile(local, []byte("repo rules\n"), 0644)
+	output := filepath.Join(tmpDir, "gen", "AGENTS.md")
+	fragments := []string{team, local}
+
+	action, err := manager.Compose(output, fragments)
+	if err != nil || action != "create" {
+		t.Fatalf("Compose() = %s, %v, expected create", action, err)
+	}
+	content, _ := os.ReadFile(output)
+	banner, body, _ := strings.Cut(string(content), "\n\n")
+	if !strings.HasPrefix(banner, "<!-- Generated by agentlink from ../team.md, ../local.md. Do not edit") {
+		t.Errorf("unexpected banner %q", banner)
+	}
+	if body != "team rules\n\nrepo rules\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if status := manager.CheckCompose(output, fragments).Status; status != StatusOK {
+		t.Errorf("CheckCompose() = %v, expected OK", status)
+	}
+
+	// A changed fragment makes the output stale
+	os.WriteFile(local, []byte("new repo rules\n"), 0644)
+	if status := manager.CheckCompose(output, fragments).Status; status != StatusStale {
+		t.Errorf("CheckCompose() = %v, expected out of date", status)
+	}
+	if action, err := manager.Compose(output, fragments); err != nil || action != "regenerate" {
+		t.Fatalf("Compose() = %s, %v, expected regenerate", action, err)
+	}
+
+	// A hand edit is detected and kept
+	content, _ = os.ReadFile(output)
+	os.WriteFile(output, append(content, []byte("edit\n")...), 0644)
+	if status := manager.CheckCompose(output, fragments).Status; status != StatusModified {
+		t.Errorf("CheckCompose() = %v, expected modified", status)
+	}
+	if _, err := manager.Compose(output, fragments); !errors.Is(err, ErrModified) {
+		t.Errorf("Compose() of an edited file = %v, expected ErrModified", err)
+	}
+	if err := manager.RemoveComposed(output); err == nil {
+		t.Errorf("RemoveComposed() of an edited file should fail")
+	}
+
+	forced := NewManager(false, true, false)
+	if action, err := forced.Compose(output, fragments); err != nil || action != "replace" {
+		t.Errorf("Compose() with force = %s, %v, expected replace", action, err)
+	}
+	if err := manager.RemoveComposed(output); err != nil {
+		t.Errorf("RemoveComposed() failed: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package symlink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/render"
+	"github.com/martinmose/agentlink/internal/state"
+)
+
+// ErrModified is returned when a copy was edited since the last sync, so
+// refreshing it would lose the edits
+var ErrModified = errors.New("edited since the last sync")
+
+// CopyContent returns what a copy of sourcePath at linkPath should contain,
+// filtered for opts.Tools and starting with opts.Frontmatter in render mode,
+// followed by the files in opts.Append
+func CopyContent(linkPath, sourcePath string, opts Options) ([]byte, error) {
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file %s: %w", sourcePath, err)
+	}
+	for _, path := range opts.Append {
+		extra, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		name := path
+		if rel, err := filepath.Rel(filepath.Dir(linkPath), path); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+			content = append(content, '\n')
+		}
+		content = append(content, []byte("\n"+comment(linkPath, "From "+name)+"\n")...)
+		content = append(content, extra...)
+	}
+	if opts.Mode == ModeRender {
+		content, err = render.Filter(content, opts.Tools)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", sourcePath, err)
+		}
+	}
+	if opts.Header {
+		content = append([]byte(copyHeader(linkPath, sourcePath)), content...)
+	}
+	if opts.Mode == ModeRender && opts.Frontmatter != "" {
+		content = append([]byte("---\n"+opts.Frontmatter+"---\n\n"), content...)
+	}
+	return content, nil
+}
+
+// StripHeader returns the content of a copy without the header CopyContent
+// adds, if it starts with it
+func StripHeader(content []byte, linkPath, sourcePath string) []byte {
+	return bytes.TrimPrefix(content, []byte(copyHeader(linkPath, sourcePath)))
+}
+
+// copyHeader is the comment at the top of a copy, in the comment syntax of
+// the copy's file type
+func copyHeader(linkPath, sourcePath string) string {
+	source := sourcePath
+	if rel, err := filepath.Rel(filepath.Dir(linkPath), sourcePath); err == nil {
+		source = filepath.ToSlash(rel)
+	}
+	return comment(linkPath, fmt.Sprintf("Generated by agentlink from %s, edit that file instead", source)) + "\n\n"
+}
+
+// comment returns text as a one-line comment in the syntax of path's file
+// type
+func comment(path, text string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".mdc", ".markdown", ".html":
+		return "<!-- " + text + " -->"
+	default:
+		return "# " + text
+	}
+}
+
+// checkCopy checks a link made in copy mode
+func (m *Manager) checkCopy(linkPath, sourcePath string, opts Options) *LinkInfo {
+	info := &LinkInfo{
+		Path:         linkPath,
+		ExpectedPath: sourcePath,
+	}
+
+	fileInfo, err := os.Lstat(linkPath)
+	switch {
+	case os.IsNotExist(err):
+		info.Status = StatusMissing
+		return info
+	case err != nil:
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	case fileInfo.Mode()&os.ModeSymlink != 0:
+		info.Target, _ = os.Readlink(linkPath)
+		info.Status = StatusWrongMode
+		return info
+	case !fileInfo.Mode().IsRegular():
+		info.Status = StatusWrongMode
+		return info
+	}
+
+	content, err := os.ReadFile(linkPath)
+	if err != nil {
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	}
+	expected, err := CopyContent(linkPath, sourcePath, opts)
+	if err != nil {
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	}
+
+	switch {
+	case bytes.Equal(content, expected):
+		info.Status = StatusOK
+	case opts.Hash != "" && state.Hash(content) == opts.Hash:
+		// Untouched since the last sync, the source changed
+		info.Status = StatusStale
+	default:
+		info.Status = StatusModified
+	}
+	return info
+}
+
+// fixCopy writes a copy unless it is up to date. A copy edited since the
+// last sync is only overwritten with --force.
+func (m *Manager) fixCopy(linkPath, sourcePath string, opts Options) (string, error) {
+	info := m.checkCopy(linkPath, sourcePath, opts)
+
+	action := ""
+	switch info.Status {
+	case StatusOK:
+		return "skip", nil
+	case StatusMissing:
+		action = "create"
+	case StatusStale:
+		action = "refresh"
+	case StatusWrongMode:
+		// A symlink to the source is ours, e.g. from before the mode changed
+		target := info.Target
+		if target != "" && !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(linkPath), target)
+		}
+		if filepath.Clean(target) != filepath.Clean(sourcePath) && !m.force {
+			return "", fmt.Errorf("%s exists and is not a copy, use --force to replace it", linkPath)
+		}
+		action = "replace"
+	case StatusModified:
+		if !m.force {
+			return "", fmt.Errorf("%s was %w, run 'agentlink diff' to compare or 'agentlink absorb' to keep the edits (or use --force to overwrite them)", linkPath, ErrModified)
+		}
+		action = "replace"
+	default:
+		return "", info.Error
+	}
+
+	content, err := CopyContent(linkPath, sourcePath, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := m.WriteFile(linkPath, content); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+// WriteFile atomically replaces whatever is at path with a regular file
+// holding content
+func (m *Manager) WriteFile(path string, content []byte) error {
+	if m.dryRun {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory for %s: %w", path, err)
+	}
+
+	tmp := path + ".agentlink-tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// UpdateFile overwrites the existing file at path with content in place,
+// keeping its inode and mode, so hardlinks to it see the new content
+func (m *Manager) UpdateFile(path string, content []byte) error {
+	if m.dryRun {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveFile removes a copy or hardlink made in copy or hardlink mode,
+// unless it was edited since the last sync
+func (m *Manager) RemoveFile(linkPath, sourcePath string, opts Options) error {
+	info := m.CheckLink(linkPath, sourcePath, opts)
+	if info.Status != StatusOK && info.Status != StatusStale {
+		return fmt.Errorf("%s is %s, not removing it", linkPath, info.Status)
+	}
+	if m.dryRun {
+		return nil
+	}
+	if err := os.Remove(linkPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", linkPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package symlink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/martinmose/agentlink/internal/state"
+)
+
+func TestCopyMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	source := filepath.Join(tmpDir, "AGENTS.md")
+	os.WriteFile(source, []byte("v1\n"), 0644)
+	link := filepath.Join(tmpDir, "docker", "CLAUDE.md")
+	opts := Options{Mode: ModeCopy, Header: true}
+
+	action, err := manager.FixLink(link, source, opts)
+	if err != nil || action != "create" {
+		t.Fatalf("FixLink() = %s, %v, expected create", action, err)
+	}
+	content, _ := os.ReadFile(link)
+	if string(content) != "<!-- Generated by agentlink from ../AGENTS.md, edit that file instead -->\n\nv1\n" {
+		t.Errorf("copy content = %q", content)
+	}
+	if string(StripHeader(content, link, source)) != "v1\n" {
+		t.Errorf("StripHeader() = %q", StripHeader(content, link, source))
+	}
+	opts.Hash = state.Hash(content)
+
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusOK {
+		t.Errorf("CheckLink() = %v, expected OK", status)
+	}
+
+	// A source change leaves an untouched copy stale, sync refreshes it
+	os.WriteFile(source, []byte("v2\n"), 0644)
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusStale {
+		t.Errorf("CheckLink() after a source change = %v, expected out of date", status)
+	}
+	if action, err := manager.FixLink(link, source, opts); err != nil || action != "refresh" {
+		t.Errorf("FixLink() = %s, %v, expected refresh", action, err)
+	}
+	content, _ = os.ReadFile(link)
+	opts.Hash = state.Hash(content)
+
+	// An edited copy is not overwritten
+	os.WriteFile(link, append(content, "local edit\n"...), 0644)
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusModified {
+		t.Errorf("CheckLink() after an edit = %v, expected modified", status)
+	}
+	if _, err := manager.FixLink(link, source, opts); !errors.Is(err, ErrModified) {
+		t.Errorf("FixLink() of an edited copy = %v, expected ErrModified", err)
+	}
+	if err := manager.RemoveFile(link, source, opts); err == nil {
+		t.Errorf("RemoveFile() of an edited copy should fail")
+	}
+
+	forced := NewManager(false, true, false)
+	if action, err := forced.FixLink(link, source, opts); err != nil || action != "replace" {
+		t.Errorf("FixLink() with --force = %s, %v, expected replace", action, err)
+	}
+	if err := manager.RemoveFile(link, source, opts); err != nil {
+		t.Errorf("RemoveFile() failed: %v", err)
+	}
+}
+
+func TestCopyModeReplacesSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	source := filepath.Join(tmpDir, "AGENTS.md")
+	os.WriteFile(source, []byte("content"), 0644)
+	link := filepath.Join(tmpDir, "CLAUDE.md")
+	os.Symlink("AGENTS.md", link)
+	other := filepath.Join(tmpDir, "GEMINI.md")
+	os.Symlink("elsewhere.md", other)
+
+	opts := Options{Mode: ModeCopy}
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusWrongMode {
+		t.Errorf("CheckLink() of a symlink in copy mode = %v, expected wrong mode", status)
+	}
+	if action, err := manager.FixLink(link, source, opts); err != nil || action != "replace" {
+		t.Errorf("FixLink() = %s, %v, expected replace", action, err)
+	}
+	if info, err := os.Lstat(link); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("%s should be a regular file now", link)
+	}
+
+	if _, err := manager.FixLink(other, source, opts); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("FixLink() over a foreign symlink = %v, expected a --force hint", err)
+	}
+}
+
+func TestRenderMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	source := filepath.Join(tmpDir, "AGENTS.md")
+	os.WriteFile(source, []byte("shared\n<!-- agentlink:only claude -->\n@imports.md\n<!-- agentlink:end -->\n"), 0644)
+	claude := filepath.Join(tmpDir, "CLAUDE.md")
+	gemini := filepath.Join(tmpDir, "GEMINI.md")
+
+	for _, link := range []struct{ path, tool, expected string }{
+		{claude, "claude", "shared\n@imports.md\n"},
+		{gemini, "gemini", "shared\n"},
+	} {
+		opts := Options{Mode: ModeRender, Tools: []string{link.tool}}
+		if action, err := manager.FixLink(link.path, source, opts); err != nil || action != "create" {
+			t.Fatalf("FixLink() = %s, %v, expected create", action, err)
+		}
+		if content, _ := os.ReadFile(link.path); string(content) != link.expected {
+			t.Errorf("%s content = %q, expected %q", link.tool, content, link.expected)
+		}
+		if status := manager.CheckLink(link.path, source, opts).Status; status != StatusOK {
+			t.Errorf("CheckLink() = %v, expected OK", status)
+		}
+	}
+
+	// A source with unbalanced markers can't be rendered
+	os.WriteFile(source, []byte("<!-- agentlink:only claude -->\n"), 0644)
+	opts := Options{Mode: ModeRender, Tools: []string{"claude"}}
+	if _, err := manager.FixLink(claude, source, opts); err == nil || !strings.Contains(err.Error(), "not closed") {
+		t.Errorf("FixLink() error = %v, expected an unclosed section", err)
+	}
+}
+
+func TestCopyAppend(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	source := filepath.Join(tmpDir, ".aiignore")
+	gitignore := filepath.Join(tmpDir, ".gitignore")
+	os.WriteFile(source, []byte(".env"), 0644)
+	link := filepath.Join(tmpDir, ".cursorignore")
+	opts := Options{Mode: ModeCopy, Append: []string{gitignore}}
+
+	// A missing file adds nothing
+	if _, err := manager.FixLink(link, source, opts); err != nil {
+		t.Fatalf("FixLink() error = %v", err)
+	}
+	if content, _ := os.ReadFile(link); string(content) != ".env" {
+		t.Errorf("copy content = %q", content)
+	}
+
+	os.WriteFile(gitignore, []byte("node_modules/\n"), 0644)
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusModified {
+		t.Errorf("CheckLink() = %v, expected a changed copy", status)
+	}
+	expected := ".env\n\n# From .gitignore\nnode_modules/\n"
+	if content, _ := CopyContent(link, source, opts); string(content) != expected {
+		t.Errorf("CopyContent() = %q, expected %q", content, expected)
+	}
+}
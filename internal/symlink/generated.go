@@ -0,0 +1,100 @@
+package symlink
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/martinmose/agentlink/internal/state"
+)
+
+// CheckGenerated checks a file that should hold content. hash is the hash
+// of what the last sync wrote there, so a file left as written can be told
+// from one edited since: the first is StatusStale, the second
+// StatusModified.
+func (m *Manager) CheckGenerated(path string, content []byte, hash string) *LinkInfo {
+	info := &LinkInfo{Path: path}
+
+	fileInfo, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+		info.Status = StatusMissing
+		return info
+	case err != nil:
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	case !fileInfo.Mode().IsRegular():
+		info.Status = StatusWrongMode
+		return info
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	}
+	switch {
+	case bytes.Equal(current, content):
+		info.Status = StatusOK
+	case hash != "" && state.Hash(current) == hash:
+		info.Status = StatusStale
+	default:
+		info.Status = StatusModified
+	}
+	return info
+}
+
+// WriteGenerated writes content to path unless it is there already. A file
+// edited since the last sync (see CheckGenerated) is only overwritten with
+// --force.
+func (m *Manager) WriteGenerated(path string, content []byte, hash string) (string, error) {
+	info := m.CheckGenerated(path, content, hash)
+
+	action := ""
+	switch info.Status {
+	case StatusOK:
+		return "skip", nil
+	case StatusMissing:
+		action = "create"
+	case StatusStale:
+		action = "refresh"
+	case StatusWrongMode:
+		if !m.force {
+			return "", fmt.Errorf("%s exists and is not a regular file, use --force to replace it", path)
+		}
+		action = "replace"
+	case StatusModified:
+		if !m.force {
+			return "", fmt.Errorf("%s was %w (use --force to overwrite it)", path, ErrModified)
+		}
+		action = "replace"
+	default:
+		return "", info.Error
+	}
+
+	if err := m.WriteFile(path, content); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+// RemoveGenerated removes a file written with WriteGenerated, unless it was
+// edited since: hash is the hash of what was written
+func (m *Manager) RemoveGenerated(path, hash string) error {
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if state.Hash(current) != hash {
+		return fmt.Errorf("%s was edited, not removing it", path)
+	}
+	if m.dryRun {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package symlink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/martinmose/agentlink/internal/state"
+)
+
+func TestWriteGenerated(t *testing.T) {
+	manager := NewManager(false, false, false)
+	path := filepath.Join(t.TempDir(), "agent", "reviewer.md")
+	first := []byte("first\n")
+
+	if action, err := manager.WriteGenerated(path, first, ""); err != nil || action != "create" {
+		t.Fatalf("WriteGenerated() = %s, %v, expected create", action, err)
+	}
+	if action, err := manager.WriteGenerated(path, first, state.Hash(first)); err != nil || action != "skip" {
+		t.Fatalf("WriteGenerated() = %s, %v, expected skip", action, err)
+	}
+
+	// Untouched since it was written, so new content replaces it
+	second := []byte("second\n")
+	if status := manager.CheckGenerated(path, second, state.Hash(first)).Status; status != StatusStale {
+		t.Errorf("CheckGenerated() = %v, expected out of date", status)
+	}
+	if action, err := manager.WriteGenerated(path, second, state.Hash(first)); err != nil || action != "refresh" {
+		t.Fatalf("WriteGenerated() = %s, %v, expected refresh", action, err)
+	}
+
+	// An edit is kept without --force
+	os.WriteFile(path, []byte("edited\n"), 0644)
+	if _, err := manager.WriteGenerated(path, first, state.Hash(second)); !errors.Is(err, ErrModified) {
+		t.Errorf("WriteGenerated() of an edited file = %v, expected ErrModified", err)
+	}
+	if err := manager.RemoveGenerated(path, state.Hash(second)); err == nil {
+		t.Errorf("RemoveGenerated() of an edited file should fail")
+	}
+
+	forced := NewManager(false, true, false)
+	if action, err := forced.WriteGenerated(path, second, state.Hash(second)); err != nil || action != "replace" {
+		t.Fatalf("WriteGenerated() with force = %s, %v, expected replace", action, err)
+	}
+	if err := manager.RemoveGenerated(path, state.Hash(second)); err != nil {
+		t.Fatalf("RemoveGenerated() error = %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("RemoveGenerated() should remove the file")
+	}
+}
@@ -0,0 +1,130 @@
+package symlink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/martinmose/agentlink/internal/state"
+)
+
+// ErrCrossDevice is returned when a hardlink would cross filesystems
+var ErrCrossDevice = errors.New("the link and the source are on different filesystems")
+
+// checkHardlink checks a link made in hardlink mode. A regular file that no
+// longer shares the source's inode and device, e.g. because an editor saved
+// either file by writing a new one, has StatusDiverged.
+func (m *Manager) checkHardlink(linkPath, sourcePath string) *LinkInfo {
+	info := &LinkInfo{
+		Path:         linkPath,
+		ExpectedPath: sourcePath,
+	}
+
+	fileInfo, err := os.Lstat(linkPath)
+	switch {
+	case os.IsNotExist(err):
+		info.Status = StatusMissing
+		return info
+	case err != nil:
+		info.Error = err
+		info.Status = StatusBroken
+		return info
+	case fileInfo.Mode()&os.ModeSymlink != 0:
+		info.Target, _ = os.Readlink(linkPath)
+		info.Status = StatusWrongMode
+		return info
+	case !fileInfo.Mode().IsRegular():
+		info.Status = StatusWrongMode
+		return info
+	}
+
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		info.Error = fmt.Errorf("failed to stat source file %s: %w", sourcePath, err)
+		info.Status = StatusBroken
+		return info
+	}
+
+	if os.SameFile(fileInfo, sourceInfo) {
+		info.Status = StatusOK
+	} else {
+		info.Status = StatusDiverged
+	}
+	return info
+}
+
+// fixHardlink creates a hardlink or relinks one that diverged. A diverged
+// file whose content matches the source, or what the last sync linked
+// (opts.Hash), is relinked; otherwise it holds edits, to be absorbed into
+// the source or dropped with --force.
+func (m *Manager) fixHardlink(linkPath, sourcePath string, opts Options) (string, error) {
+	info := m.checkHardlink(linkPath, sourcePath)
+
+	action := ""
+	switch info.Status {
+	case StatusOK:
+		return "skip", nil
+	case StatusMissing:
+		action = "create"
+	case StatusWrongMode:
+		// A symlink to the source is ours, e.g. from before the mode changed
+		target := info.Target
+		if target != "" && !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(linkPath), target)
+		}
+		if filepath.Clean(target) != filepath.Clean(sourcePath) && !m.force {
+			return "", fmt.Errorf("%s exists and is not a hardlink, use --force to replace it", linkPath)
+		}
+		action = "replace"
+	case StatusDiverged:
+		content, err := os.ReadFile(linkPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", linkPath, err)
+		}
+		source, err := os.ReadFile(sourcePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read source file %s: %w", sourcePath, err)
+		}
+		untouched := bytes.Equal(content, source) || (opts.Hash != "" && state.Hash(content) == opts.Hash)
+		if !untouched && !m.force {
+			return "", fmt.Errorf("%s is no longer a hardlink to %s and was %w, run 'agentlink absorb' to keep the edits (or use --force to relink it and drop them)", linkPath, sourcePath, ErrModified)
+		}
+		action = "relink"
+	default:
+		return "", info.Error
+	}
+
+	if err := m.linkFile(linkPath, sourcePath); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+// linkFile atomically replaces whatever is at linkPath with a hardlink to
+// sourcePath
+func (m *Manager) linkFile(linkPath, sourcePath string) error {
+	if m.dryRun {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory for %s: %w", linkPath, err)
+	}
+
+	tmp := linkPath + ".agentlink-tmp"
+	os.Remove(tmp)
+	if err := os.Link(sourcePath, tmp); err != nil {
+		if errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("cannot hardlink %s to %s: %w, use mode: copy for this link instead", linkPath, sourcePath, ErrCrossDevice)
+		}
+		return fmt.Errorf("failed to create hardlink %s: %w", linkPath, err)
+	}
+	if err := os.Rename(tmp, linkPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", linkPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package symlink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/martinmose/agentlink/internal/state"
+)
+
+func TestHardlinkMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	source := filepath.Join(tmpDir, "AGENTS.md")
+	os.WriteFile(source, []byte("v1\n"), 0644)
+	link := filepath.Join(tmpDir, "sub", "CLAUDE.md")
+	opts := Options{Mode: ModeHardlink}
+
+	action, err := manager.FixLink(link, source, opts)
+	if err != nil || action != "create" {
+		t.Fatalf("FixLink() = %s, %v, expected create", action, err)
+	}
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusOK {
+		t.Errorf("CheckLink() = %v, expected OK", status)
+	}
+	opts.Hash = state.Hash([]byte("v1\n"))
+
+	// An editor saving the source as a new file breaks the hardlink
+	os.Remove(source)
+	os.WriteFile(source, []byte("v2\n"), 0644)
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusDiverged {
+		t.Errorf("CheckLink() = %v, expected diverged", status)
+	}
+
+	// The link still holds what was linked, so it is relinked
+	action, err = manager.FixLink(link, source, opts)
+	if err != nil || action != "relink" {
+		t.Fatalf("FixLink() = %s, %v, expected relink", action, err)
+	}
+	if content, _ := os.ReadFile(link); string(content) != "v2\n" {
+		t.Errorf("link content = %q after relink", content)
+	}
+
+	// A link saved as a new file with edits is left alone
+	os.Remove(link)
+	os.WriteFile(link, []byte("edited\n"), 0644)
+	opts.Hash = state.Hash([]byte("v2\n"))
+	if _, err := manager.FixLink(link, source, opts); err == nil {
+		t.Errorf("FixLink() of an edited link should fail without force")
+	}
+	if err := manager.RemoveFile(link, source, opts); err == nil {
+		t.Errorf("RemoveFile() of a diverged link should fail")
+	}
+
+	forced := NewManager(false, true, false)
+	if action, err := forced.FixLink(link, source, opts); err != nil || action != "relink" {
+		t.Errorf("FixLink() with force = %s, %v, expected relink", action, err)
+	}
+	if err := manager.RemoveFile(link, source, opts); err != nil {
+		t.Errorf("RemoveFile() failed: %v", err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Errorf("RemoveFile() should remove the hardlink")
+	}
+}
+
+func TestHardlinkReplacesSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	source := filepath.Join(tmpDir, "AGENTS.md")
+	os.WriteFile(source, []byte("v1\n"), 0644)
+	link := filepath.Join(tmpDir, "CLAUDE.md")
+	os.Symlink("AGENTS.md", link)
+
+	opts := Options{Mode: ModeHardlink}
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusWrongMode {
+		t.Errorf("CheckLink() = %v, expected wrong mode", status)
+	}
+	if action, err := manager.FixLink(link, source, opts); err != nil || action != "replace" {
+		t.Fatalf("FixLink() = %s, %v, expected replace", action, err)
+	}
+	if status := manager.CheckLink(link, source, opts).Status; status != StatusOK {
+		t.Errorf("CheckLink() = %v, expected OK", status)
+	}
+}
@@ -15,6 +15,11 @@ const (
 	StatusWrongTarget
 	StatusNotSymlink
 	StatusBroken
+	StatusWrongStyle
+	StatusWrongMode
+	StatusStale
+	StatusModified
+	StatusDiverged
 )
 
 func (s LinkStatus) String() string {
@@ -29,11 +34,89 @@ func (s LinkStatus) String() string {
 		return "not a symlink"
 	case StatusBroken:
 		return "broken"
+	case StatusWrongStyle:
+		return "wrong style"
+	case StatusWrongMode:
+		return "wrong mode"
+	case StatusStale:
+		return "out of date"
+	case StatusModified:
+		return "modified"
+	case StatusDiverged:
+		return "diverged"
 	default:
 		return "unknown"
 	}
 }
 
+// Style is how the target of a symlink is written
+type Style int
+
+const (
+	// StyleRelative writes the target relative to the link's directory
+	StyleRelative Style = iota
+	// StyleAbsolute writes the target as an absolute path
+	StyleAbsolute
+)
+
+func (s Style) String() string {
+	if s == StyleAbsolute {
+		return "absolute"
+	}
+	return "relative"
+}
+
+// Mode is how a link is made
+type Mode int
+
+const (
+	// ModeSymlink makes a symlink to the source
+	ModeSymlink Mode = iota
+	// ModeCopy writes a copy of the source, for tools that ignore symlinks
+	ModeCopy
+	// ModeHardlink makes a hardlink to the source, for tools that refuse
+	// symlinks when the link is on the same filesystem
+	ModeHardlink
+	// ModeRender writes a copy of the source filtered for Options.Tools
+	ModeRender
+)
+
+// Options are the settings of a single link
+type Options struct {
+	Style Style
+	Mode  Mode
+	// Header starts a copy with a comment saying it is generated
+	Header bool
+	// Tools are the tools a rendered copy is filtered for
+	Tools []string
+	// Frontmatter is YAML written between --- lines at the top of a
+	// rendered copy, for tools that read their settings from it
+	Frontmatter string
+	// Append are files added to the end of a copy, each after a comment
+	// naming it. A missing file adds nothing.
+	Append []string
+	// Hash is the hash of the copy written by the last sync, if any, to
+	// tell an untouched copy from an edited one
+	Hash string
+}
+
+// targetFor returns the target to write in a symlink at linkPath pointing
+// to targetPath
+func (o Options) targetFor(linkPath, targetPath string) (string, error) {
+	if o.Style == StyleAbsolute {
+		abs, err := filepath.Abs(targetPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate absolute path: %w", err)
+		}
+		return abs, nil
+	}
+	rel, err := filepath.Rel(filepath.Dir(linkPath), targetPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate relative path: %w", err)
+	}
+	return rel, nil
+}
+
 // LinkInfo contains information about a symlink
 type LinkInfo struct {
 	Path         string
@@ -43,6 +126,12 @@ type LinkInfo struct {
 	Error        error
 }
 
+// LinksToTarget reports whether the link resolves to the expected target,
+// even if the target is written in the wrong style
+func (i *LinkInfo) LinksToTarget() bool {
+	return i.Status == StatusOK || i.Status == StatusWrongStyle
+}
+
 // Manager handles symlink operations
 type Manager struct {
 	dryRun  bool
@@ -82,8 +171,64 @@ func (m *Manager) ValidateSource(sourcePath string) error {
 	return nil
 }
 
-// CheckLink checks the status of a symlink
-func (m *Manager) CheckLink(linkPath, expectedTarget string) *LinkInfo {
+// BootstrapSource creates a missing source file so links have something to
+// point to. The content of the first link that exists as a regular file (or
+// a symlink resolving to one) is adopted; otherwise template is written. An
+// adopted regular file is removed afterwards since its content now lives in
+// the source, so it can be replaced by a link. Returns the adopted link path,
+// or "" if the template was used.
+func (m *Manager) BootstrapSource(sourcePath string, links []string, template []byte) (string, error) {
+	content := template
+	adopted := ""
+
+	for _, linkPath := range links {
+		info, err := os.Stat(linkPath)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(linkPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", linkPath, err)
+		}
+		content = data
+		adopted = linkPath
+		break
+	}
+
+	if m.dryRun {
+		return adopted, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(sourcePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create parent directory for %s: %w", sourcePath, err)
+	}
+
+	if err := os.WriteFile(sourcePath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write source file %s: %w", sourcePath, err)
+	}
+
+	if adopted != "" {
+		if info, err := os.Lstat(adopted); err == nil && info.Mode().IsRegular() {
+			if err := os.Remove(adopted); err != nil {
+				return "", fmt.Errorf("failed to remove adopted file %s: %w", adopted, err)
+			}
+		}
+	}
+
+	return adopted, nil
+}
+
+// CheckLink checks the status of a symlink. A link that resolves to the
+// expected target but doesn't write it in the style of opts has
+// StatusWrongStyle.
+func (m *Manager) CheckLink(linkPath, expectedTarget string, opts Options) *LinkInfo {
+	switch opts.Mode {
+	case ModeCopy, ModeRender:
+		return m.checkCopy(linkPath, expectedTarget, opts)
+	case ModeHardlink:
+		return m.checkHardlink(linkPath, expectedTarget)
+	}
+
 	info := &LinkInfo{
 		Path:         linkPath,
 		ExpectedPath: expectedTarget,
@@ -126,6 +271,9 @@ func (m *Manager) CheckLink(linkPath, expectedTarget string) *LinkInfo {
 
 	if target == expectedClean {
 		info.Status = StatusOK
+		if filepath.IsAbs(info.Target) != (opts.Style == StyleAbsolute) {
+			info.Status = StatusWrongStyle
+		}
 	} else {
 		info.Status = StatusWrongTarget
 	}
@@ -134,7 +282,7 @@ func (m *Manager) CheckLink(linkPath, expectedTarget string) *LinkInfo {
 }
 
 // CreateLink creates or fixes a symlink
-func (m *Manager) CreateLink(linkPath, targetPath string) error {
+func (m *Manager) CreateLink(linkPath, targetPath string, opts Options) error {
 	if m.dryRun {
 		return nil // Don't actually create in dry-run mode
 	}
@@ -144,15 +292,74 @@ func (m *Manager) CreateLink(linkPath, targetPath string) error {
 		return fmt.Errorf("failed to create parent directory for %s: %w", linkPath, err)
 	}
 
-	// Calculate relative path from link to target
-	relTarget, err := filepath.Rel(filepath.Dir(linkPath), targetPath)
+	// Calculate the target as written in the link
+	target, err := opts.targetFor(linkPath, targetPath)
 	if err != nil {
-		return fmt.Errorf("failed to calculate relative path: %w", err)
+		return err
 	}
 
 	// Create the symlink
-	if err := os.Symlink(relTarget, linkPath); err != nil {
-		return fmt.Errorf("failed to create symlink %s -> %s: %w", linkPath, relTarget, err)
+	if err := os.Symlink(target, linkPath); err != nil {
+		return fmt.Errorf("failed to create symlink %s -> %s: %w", linkPath, target, err)
+	}
+
+	return nil
+}
+
+// CopySource copies the content of the source at oldPath to newPath,
+// replacing whatever is at newPath atomically. oldPath is left in place so
+// existing links keep resolving until they are retargeted.
+func (m *Manager) CopySource(oldPath, newPath string) error {
+	if m.dryRun {
+		return nil
+	}
+
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat source file %s: %w", oldPath, err)
+	}
+	content, err := os.ReadFile(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read source file %s: %w", oldPath, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory for %s: %w", newPath, err)
+	}
+
+	tmp := newPath + ".agentlink-tmp"
+	if err := os.WriteFile(tmp, content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, newPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", newPath, err)
+	}
+
+	return nil
+}
+
+// ReplaceLink atomically replaces whatever is at linkPath with a symlink to
+// targetPath. The new symlink is created next to linkPath and renamed over
+// it, so readers never see the path missing.
+func (m *Manager) ReplaceLink(linkPath, targetPath string, opts Options) error {
+	if m.dryRun {
+		return nil
+	}
+
+	target, err := opts.targetFor(linkPath, targetPath)
+	if err != nil {
+		return err
+	}
+
+	tmp := linkPath + ".agentlink-tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return fmt.Errorf("failed to create symlink %s -> %s: %w", tmp, target, err)
+	}
+	if err := os.Rename(tmp, linkPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", linkPath, err)
 	}
 
 	return nil
@@ -164,8 +371,8 @@ func (m *Manager) RemoveLink(linkPath, expectedTarget string) error {
 		return nil
 	}
 
-	info := m.CheckLink(linkPath, expectedTarget)
-	if info.Status == StatusOK {
+	info := m.CheckLink(linkPath, expectedTarget, Options{})
+	if info.LinksToTarget() {
 		if err := os.Remove(linkPath); err != nil {
 			return fmt.Errorf("failed to remove symlink %s: %w", linkPath, err)
 		}
@@ -175,15 +382,29 @@ func (m *Manager) RemoveLink(linkPath, expectedTarget string) error {
 }
 
 // FixLink creates or fixes a symlink based on its current status
-func (m *Manager) FixLink(linkPath, targetPath string) (string, error) {
-	info := m.CheckLink(linkPath, targetPath)
+func (m *Manager) FixLink(linkPath, targetPath string, opts Options) (string, error) {
+	switch opts.Mode {
+	case ModeCopy, ModeRender:
+		return m.fixCopy(linkPath, targetPath, opts)
+	case ModeHardlink:
+		return m.fixHardlink(linkPath, targetPath, opts)
+	}
+
+	info := m.CheckLink(linkPath, targetPath, opts)
 
 	switch info.Status {
 	case StatusOK:
 		return "skip", nil
 
+	case StatusWrongStyle:
+		// The link is ours already, only the way its target is written changes
+		if err := m.ReplaceLink(linkPath, targetPath, opts); err != nil {
+			return "", err
+		}
+		return "restyle", nil
+
 	case StatusMissing:
-		if err := m.CreateLink(linkPath, targetPath); err != nil {
+		if err := m.CreateLink(linkPath, targetPath, opts); err != nil {
 			return "", err
 		}
 		return "create", nil
@@ -195,7 +416,7 @@ func (m *Manager) FixLink(linkPath, targetPath string) (string, error) {
 		if err := os.Remove(linkPath); err != nil {
 			return "", fmt.Errorf("failed to remove wrong symlink %s: %w", linkPath, err)
 		}
-		if err := m.CreateLink(linkPath, targetPath); err != nil {
+		if err := m.CreateLink(linkPath, targetPath, opts); err != nil {
 			return "", err
 		}
 		return "fix", nil
@@ -207,7 +428,7 @@ func (m *Manager) FixLink(linkPath, targetPath string) (string, error) {
 		if err := os.RemoveAll(linkPath); err != nil {
 			return "", fmt.Errorf("failed to remove existing file %s: %w", linkPath, err)
 		}
-		if err := m.CreateLink(linkPath, targetPath); err != nil {
+		if err := m.CreateLink(linkPath, targetPath, opts); err != nil {
 			return "", err
 		}
 		return "replace", nil
@@ -216,7 +437,7 @@ func (m *Manager) FixLink(linkPath, targetPath string) (string, error) {
 		if err := os.Remove(linkPath); err != nil {
 			return "", fmt.Errorf("failed to remove broken symlink %s: %w", linkPath, err)
 		}
-		if err := m.CreateLink(linkPath, targetPath); err != nil {
+		if err := m.CreateLink(linkPath, targetPath, opts); err != nil {
 			return "", err
 		}
 		return "fix broken", nil
@@ -120,7 +120,7 @@ func TestCheckLink(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			linkPath := tt.setup()
 			
-			info := manager.CheckLink(linkPath, source)
+			info := manager.CheckLink(linkPath, source, Options{})
 			if info.Status != tt.expectedStatus {
 				t.Errorf("CheckLink() status = %v, expected %v", info.Status, tt.expectedStatus)
 			}
@@ -138,13 +138,13 @@ func TestCreateLink(t *testing.T) {
 	
 	// Create link
 	link := filepath.Join(tmpDir, "test.md")
-	err := manager.CreateLink(link, source)
+	err := manager.CreateLink(link, source, Options{})
 	if err != nil {
 		t.Fatalf("CreateLink() failed: %v", err)
 	}
 	
 	// Verify link exists and points to source
-	info := manager.CheckLink(link, source)
+	info := manager.CheckLink(link, source, Options{})
 	if info.Status != StatusOK {
 		t.Errorf("Created link has wrong status: %v", info.Status)
 	}
@@ -214,7 +214,7 @@ func TestFixLink(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			linkPath := tt.setup()
 			
-			action, err := manager.FixLink(linkPath, source)
+			action, err := manager.FixLink(linkPath, source, Options{})
 			if err != nil {
 				t.Fatalf("FixLink() failed: %v", err)
 			}
@@ -225,7 +225,7 @@ func TestFixLink(t *testing.T) {
 			
 			// Verify the link is correct after fixing (except for skip case)
 			if tt.expectedAction != "skip" {
-				info := manager.CheckLink(linkPath, source)
+				info := manager.CheckLink(linkPath, source, Options{})
 				if info.Status != StatusOK {
 					t.Errorf("Link not correct after fixing: status = %v", info.Status)
 				}
@@ -244,7 +244,7 @@ func TestDryRun(t *testing.T) {
 	link := filepath.Join(tmpDir, "test.md")
 	
 	// Create link in dry-run mode
-	err := manager.CreateLink(link, source)
+	err := manager.CreateLink(link, source, Options{})
 	if err != nil {
 		t.Fatalf("CreateLink() in dry-run failed: %v", err)
 	}
@@ -253,4 +253,160 @@ func TestDryRun(t *testing.T) {
 	if _, err := os.Lstat(link); err == nil {
 		t.Error("Link was created in dry-run mode")
 	}
-}
\ No newline at end of file
+}
+
+func TestBootstrapSource(t *testing.T) {
+	template := []byte("# Starter")
+
+	tests := []struct {
+		name            string
+		setup           func(dir string) []string
+		expectedContent string
+		expectAdopted   int // index into links, -1 for template
+	}{
+		{
+			name: "template when no link exists",
+			setup: func(dir string) []string {
+				return []string{filepath.Join(dir, "AGENTS.md")}
+			},
+			expectedContent: "# Starter",
+			expectAdopted:   -1,
+		},
+		{
+			name: "adopt first existing regular file",
+			setup: func(dir string) []string {
+				os.WriteFile(filepath.Join(dir, "GEMINI.md"), []byte("gemini"), 0644)
+				os.WriteFile(filepath.Join(dir, "OPENCODE.md"), []byte("opencode"), 0644)
+				return []string{
+					filepath.Join(dir, "AGENTS.md"),
+					filepath.Join(dir, "GEMINI.md"),
+					filepath.Join(dir, "OPENCODE.md"),
+				}
+			},
+			expectedContent: "gemini",
+			expectAdopted:   1,
+		},
+		{
+			name: "broken symlinks are not adopted",
+			setup: func(dir string) []string {
+				os.Symlink("CLAUDE.md", filepath.Join(dir, "AGENTS.md"))
+				return []string{filepath.Join(dir, "AGENTS.md")}
+			},
+			expectedContent: "# Starter",
+			expectAdopted:   -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			manager := NewManager(false, false, false)
+			source := filepath.Join(dir, "CLAUDE.md")
+			links := tt.setup(dir)
+
+			adopted, err := manager.BootstrapSource(source, links, template)
+			if err != nil {
+				t.Fatalf("BootstrapSource() failed: %v", err)
+			}
+
+			expectedAdopted := ""
+			if tt.expectAdopted >= 0 {
+				expectedAdopted = links[tt.expectAdopted]
+			}
+			if adopted != expectedAdopted {
+				t.Errorf("BootstrapSource() adopted = %q, expected %q", adopted, expectedAdopted)
+			}
+
+			content, err := os.ReadFile(source)
+			if err != nil {
+				t.Fatalf("Source was not created: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("Source content = %q, expected %q", content, tt.expectedContent)
+			}
+
+			if expectedAdopted != "" {
+				if _, err := os.Lstat(expectedAdopted); !os.IsNotExist(err) {
+					t.Errorf("Adopted file %s should have been removed", expectedAdopted)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceLink(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	oldSource := filepath.Join(tmpDir, "CLAUDE.md")
+	newSource := filepath.Join(tmpDir, "AGENTS.md")
+	os.WriteFile(oldSource, []byte("content"), 0644)
+
+	// Copy the content over an existing link to the old source
+	os.Symlink("CLAUDE.md", newSource)
+	if err := manager.CopySource(oldSource, newSource); err != nil {
+		t.Fatalf("CopySource() failed: %v", err)
+	}
+	info, err := os.Lstat(newSource)
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("New source is not a regular file: %v", err)
+	}
+
+	link := filepath.Join(tmpDir, "nested", "GEMINI.md")
+	os.MkdirAll(filepath.Dir(link), 0755)
+	os.Symlink("../CLAUDE.md", link)
+
+	for _, path := range []string{link, oldSource} {
+		if err := manager.ReplaceLink(path, newSource, Options{}); err != nil {
+			t.Fatalf("ReplaceLink(%s) failed: %v", path, err)
+		}
+		if status := manager.CheckLink(path, newSource, Options{}).Status; status != StatusOK {
+			t.Errorf("%s has status %v after ReplaceLink()", path, status)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || string(content) != "content" {
+			t.Errorf("Cannot read content through %s: %v", path, err)
+		}
+	}
+
+	if _, err := os.Lstat(link + ".agentlink-tmp"); !os.IsNotExist(err) {
+		t.Error("Temporary symlink was left behind")
+	}
+}
+
+func TestLinkStyle(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(false, false, false)
+
+	source := filepath.Join(tmpDir, "source.md")
+	os.WriteFile(source, []byte("test"), 0644)
+	link := filepath.Join(tmpDir, "sub", "link.md")
+	absolute := Options{Style: StyleAbsolute}
+
+	if err := manager.CreateLink(link, source, absolute); err != nil {
+		t.Fatalf("CreateLink() failed: %v", err)
+	}
+	if target, _ := os.Readlink(link); target != source {
+		t.Errorf("absolute link target = %s, expected %s", target, source)
+	}
+	if status := manager.CheckLink(link, source, absolute).Status; status != StatusOK {
+		t.Errorf("CheckLink() with absolute style = %v, expected OK", status)
+	}
+
+	info := manager.CheckLink(link, source, Options{})
+	if info.Status != StatusWrongStyle || !info.LinksToTarget() {
+		t.Errorf("CheckLink() with relative style = %v, expected wrong style", info.Status)
+	}
+
+	// Restyling needs no --force, the link points to the source already
+	action, err := manager.FixLink(link, source, Options{})
+	if err != nil {
+		t.Fatalf("FixLink() failed: %v", err)
+	}
+	if action != "restyle" {
+		t.Errorf("FixLink() action = %s, expected restyle", action)
+	}
+	if target, _ := os.Readlink(link); target != filepath.Join("..", "source.md") {
+		t.Errorf("relative link target = %s, expected ../source.md", target)
+	}
+}
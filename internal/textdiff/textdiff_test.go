@@ -0,0 +1,86 @@
+package textdiff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name: "delete everything",
+			old:  "a\n",
+			new:  "",
+			expected: `--- old
++++ new
+@@ -1 +0,0 @@
+-a
+`,
+		},
+		{
+			name:     "equal",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "insert at the top",
+			old:  "a\nb\n",
+			new:  "v\na\nb\n",
+			expected: `--- old
++++ new
+@@ -1,2 +1,3 @@
++v
+ a
+ b
+`,
+		},
+		{
+			name: "change in the middle keeps context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: `--- old
++++ new
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "distant changes get separate hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			expected: `--- old
++++ new
+@@ -1,4 +1,4 @@
+-a
++A
+ 1
+ 2
+ 3
+@@ -7,4 +7,4 @@
+ 6
+ 7
+ 8
+-b
++B
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", tt.old, tt.new); got != tt.expected {
+				t.Errorf("Unified() =\n%s\nexpected:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected int
+	}{
+		{"", 0},
+		{"Run the tests", 3},
+		{"Run the tests before committing.", 7},
+		{"internationalization", 4},
+		{"2025", 2},
+		{"- Use `go test ./...`\n", 12},
+		{"    indented\n\n\nnext", 5},
+		{"日本語", 3},
+	}
+	for _, tt := range tests {
+		if got := Estimate([]byte(tt.content)); got != tt.expected {
+			t.Errorf("Estimate(%q) = %d, expected %d", tt.content, got, tt.expected)
+		}
+	}
+}
+
+func TestEstimateProse(t *testing.T) {
+	// About 4 characters per token, as for real tokenizers on English
+	prose := strings.Repeat("Keep functions small and name them after what they do. ", 100)
+	got := Estimate([]byte(prose))
+	if ratio := float64(len(prose)) / float64(got); ratio < 3 || ratio > 5 {
+		t.Errorf("Estimate() = %d for %d characters, %.1f characters per token", got, len(prose), ratio)
+	}
+}
@@ -0,0 +1,403 @@
+package toolconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// MCPConfig is the file a tool reads its MCP servers from
+type MCPConfig struct {
+	// Tool is the tool's name in the registry
+	Tool string
+	// Path is the file
+	Path string
+	// Key holds the servers, by name
+	Key string
+}
+
+// MCPConfigFor returns the MCP config of tool for the project at root
+func MCPConfigFor(tool, root string) (MCPConfig, error) {
+	t, ok := tools.Lookup(tool)
+	if !ok || t.MCPKey == "" {
+		return MCPConfig{}, fmt.Errorf("%s has no MCP config agentlink can write", tool)
+	}
+	c := MCPConfig{Tool: tool, Key: t.MCPKey}
+	switch {
+	case t.MCPFile != "":
+		c.Path = filepath.Join(root, t.MCPFile)
+	case tool == "codex":
+		home, err := codexHome()
+		if err != nil {
+			return MCPConfig{}, err
+		}
+		c.Path = filepath.Join(home, "config.toml")
+	default:
+		return MCPConfig{}, fmt.Errorf("agentlink cannot find the MCP config of %s", tool)
+	}
+	return c, nil
+}
+
+// MCPConfigAt returns the MCP config at path, telling the tool by the
+// file's name
+func MCPConfigAt(path string) (MCPConfig, bool) {
+	for _, tool := range tools.Registry {
+		switch {
+		case tool.MCPKey == "":
+		case tool.MCPFile != "" && strings.HasSuffix(filepath.ToSlash(path), "/"+tool.MCPFile),
+			tool.MCPFile == "" && tool.Name == "codex" && filepath.Base(path) == "config.toml":
+			return MCPConfig{Tool: tool.Name, Path: path, Key: tool.MCPKey}, true
+		}
+	}
+	return MCPConfig{}, false
+}
+
+// String describes the config as key in file
+func (c MCPConfig) String() string {
+	return fmt.Sprintf("%s in %s", c.Key, c.Path)
+}
+
+// isTOML reports whether the config is a TOML file
+func (c MCPConfig) isTOML() bool {
+	return filepath.Ext(c.Path) == ".toml"
+}
+
+// mcpField is a field of a server entry as a tool writes it
+type mcpField struct {
+	key   string
+	value interface{}
+}
+
+// fields returns server as the tool writes it, in order
+func (c MCPConfig) fields(server *config.MCPServer) []mcpField {
+	var fields []mcpField
+	add := func(key string, value interface{}) {
+		fields = append(fields, mcpField{key, value})
+	}
+
+	if server.URL != "" {
+		switch c.Tool {
+		case "claude", "copilot":
+			add("type", "http")
+		}
+		add("url", server.URL)
+		if len(server.Headers) > 0 {
+			key := "headers"
+			if c.Tool == "codex" {
+				key = "http_headers"
+			}
+			add(key, server.Headers)
+		}
+		return fields
+	}
+
+	if c.Tool == "copilot" {
+		add("type", "stdio")
+	}
+	add("command", server.Command)
+	if len(server.Args) > 0 {
+		add("args", server.Args)
+	}
+	if len(server.Env) > 0 {
+		add("env", server.Env)
+	}
+	return fields
+}
+
+// Entry returns server as the tool writes it, as JSON with sorted keys to
+// compare with the entries of Servers
+func (c MCPConfig) Entry(server *config.MCPServer) []byte {
+	entry := make(map[string]interface{})
+	for _, field := range c.fields(server) {
+		entry[field.key] = field.value
+	}
+	data, _ := json.Marshal(entry)
+	return data
+}
+
+// Servers returns the servers the file lists, each as JSON with sorted keys
+func (c MCPConfig) Servers() (map[string][]byte, error) {
+	data, err := os.ReadFile(c.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", c.Path, err)
+	}
+
+	entries := make(map[string]interface{})
+	if c.isTOML() {
+		var values map[string]interface{}
+		if _, err := toml.Decode(string(data), &values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", c.Path, err)
+		}
+		if servers, ok := values[c.Key].(map[string]interface{}); ok {
+			entries = servers
+		}
+	} else {
+		_, err = config.EditJSON(data, func(object *yaml.Node) error {
+			if servers := mappingValue(object, c.Key); servers != nil {
+				return servers.Decode(&entries)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", c.Path, err)
+		}
+	}
+
+	servers := make(map[string][]byte, len(entries))
+	for name, entry := range entries {
+		if servers[name], err = json.Marshal(entry); err != nil {
+			return nil, fmt.Errorf("failed to read server %s in %s: %w", name, c.Path, err)
+		}
+	}
+	return servers, nil
+}
+
+// Write sets the entries of servers and removes the ones named in remove,
+// keeping everything else in the file. A JSON file left empty is removed.
+func (c MCPConfig) Write(servers config.MCPServers, remove []string, dryRun bool) error {
+	data, err := os.ReadFile(c.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", c.Path, err)
+	}
+
+	empty := false
+	if c.isTOML() {
+		for _, name := range remove {
+			if data, err = setTOMLTable(data, c.Key, name, nil); err != nil {
+				return fmt.Errorf("failed to edit %s: %w", c.Path, err)
+			}
+		}
+		for _, name := range servers.Names() {
+			if data, err = setTOMLTable(data, c.Key, name, c.fields(servers[name])); err != nil {
+				return fmt.Errorf("failed to edit %s: %w", c.Path, err)
+			}
+		}
+	} else {
+		data, err = config.EditJSON(data, func(object *yaml.Node) error {
+			c.editJSON(object, servers, remove)
+			empty = len(object.Content) == 0
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to edit %s: %w", c.Path, err)
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+	if empty {
+		if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", c.Path, err)
+		}
+		return nil
+	}
+	return writeFile(c.Path, data)
+}
+
+// editJSON sets and removes servers under the key of a JSON object,
+// dropping the key once it lists no servers
+func (c MCPConfig) editJSON(object *yaml.Node, servers config.MCPServers, remove []string) {
+	list := mappingValue(object, c.Key)
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.MappingNode}
+		object.Content = append(object.Content, stringNode(c.Key), list)
+	}
+
+	for _, name := range remove {
+		for i := 0; i+1 < len(list.Content); i += 2 {
+			if list.Content[i].Value == name {
+				list.Content = append(list.Content[:i], list.Content[i+2:]...)
+				break
+			}
+		}
+	}
+	for _, name := range servers.Names() {
+		entry := &yaml.Node{Kind: yaml.MappingNode}
+		for _, field := range c.fields(servers[name]) {
+			entry.Content = append(entry.Content, stringNode(field.key), valueNode(field.value))
+		}
+		if existing := mappingValue(list, name); existing != nil {
+			*existing = *entry
+		} else {
+			list.Content = append(list.Content, stringNode(name), entry)
+		}
+	}
+
+	if len(list.Content) == 0 {
+		for i := 0; i+1 < len(object.Content); i += 2 {
+			if object.Content[i+1] == list {
+				object.Content = append(object.Content[:i], object.Content[i+2:]...)
+				break
+			}
+		}
+	}
+}
+
+// valueNode returns a field value as a node
+func valueNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case []string:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			node.Content = append(node.Content, stringNode(item))
+		}
+		return node
+	case map[string]string:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range sortedKeys(v) {
+			node.Content = append(node.Content, stringNode(key), stringNode(v[key]))
+		}
+		return node
+	default:
+		return stringNode(fmt.Sprint(v))
+	}
+}
+
+// tomlBareKey matches keys that need no quotes
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey returns key quoted if it needs to be
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// tomlValue writes a field value as TOML
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		quoted := make([]string, len(v))
+		for i, item := range v {
+			quoted[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case map[string]string:
+		var pairs []string
+		for _, key := range sortedKeys(v) {
+			pairs = append(pairs, tomlKey(key)+" = "+strconv.Quote(v[key]))
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	default:
+		return strconv.Quote(fmt.Sprint(v))
+	}
+}
+
+// setTOMLTable replaces the server name under key in a TOML document with
+// fields, editing the text so the rest of the file is kept. The server is
+// replaced where it is defined, as a [key.name] table or as an inline table
+// or dotted keys under [key], and its parts elsewhere are removed. No fields
+// removes the server.
+func setTOMLTable(data []byte, key, name string, fields []mcpField) ([]byte, error) {
+	text := string(data)
+	statements, err := scanTOML(text)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := []string{key, name}
+	var spans []tomlSpan
+	at, insert := -1, ""
+	for i := 0; i < len(statements); i++ {
+		s := statements[i]
+		if !s.Header && len(s.Path) < len(prefix) && hasPrefix(prefix, s.Path) {
+			return nil, fmt.Errorf("%s is not a table agentlink can edit", strings.Join(s.Path, "."))
+		}
+		if !hasPrefix(s.Path, prefix) {
+			continue
+		}
+
+		span := tomlSpan{s.Start, s.End}
+		if s.Header {
+			// The table ends at the next table that isn't one of its
+			// subtables, blank lines before it stay
+			for i+1 < len(statements) && (!statements[i+1].Header || hasPrefix(statements[i+1].Path, prefix)) {
+				i++
+			}
+			if i+1 < len(statements) {
+				span.end = statements[i+1].Start
+			} else {
+				span.end = len(text)
+			}
+			for span.end > s.End {
+				prev := strings.LastIndexByte(text[:span.end-1], '\n') + 1
+				if !isBlank(text[prev:span.end]) {
+					break
+				}
+				span.end = prev
+			}
+		}
+		spans = append(spans, span)
+
+		if at < 0 && fields != nil {
+			at = span.start
+			if s.Header {
+				insert = tomlTableText(key, name, fields)
+			} else {
+				insert = tomlInlineText(prefix[s.Table:], fields)
+			}
+		}
+	}
+
+	if at >= 0 || fields == nil {
+		return []byte(spliceTOML(text, spans, at, insert)), nil
+	}
+
+	text = spliceTOML(text, spans, -1, "")
+	if text != "" {
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if !strings.HasSuffix(text, "\n\n") {
+			text += "\n"
+		}
+	}
+	return []byte(text + tomlTableText(key, name, fields)), nil
+}
+
+// tomlTableText writes fields as the table [key.name]
+func tomlTableText(key, name string, fields []mcpField) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s.%s]\n", tomlKey(key), tomlKey(name))
+	for _, field := range fields {
+		fmt.Fprintf(&b, "%s = %s\n", tomlKey(field.key), tomlValue(field.value))
+	}
+	return b.String()
+}
+
+// tomlInlineText writes fields as an inline table under the dotted key path
+func tomlInlineText(path []string, fields []mcpField) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	pairs := make([]string, len(fields))
+	for i, field := range fields {
+		pairs[i] = tomlKey(field.key) + " = " + tomlValue(field.value)
+	}
+	return fmt.Sprintf("%s = { %s }\n", strings.Join(keys, "."), strings.Join(pairs, ", "))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,248 @@
+package toolconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/martinmose/agentlink/internal/config"
+)
+
+var testServers = config.MCPServers{
+	"github": {URL: "https://api.githubcopilot.com/mcp/", Headers: map[string]string{"Authorization": "Bearer ${GITHUB_TOKEN}"}},
+	"files":  {Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-filesystem", "."}},
+}
+
+func TestMCPConfigJSON(t *testing.T) {
+	root := t.TempDir()
+	c, err := MCPConfigFor("claude", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Path != filepath.Join(root, ".mcp.json") || c.Key != "mcpServers" {
+		t.Errorf("MCPConfigFor() = %+v", c)
+	}
+
+	original := "{\n  \"mcpServers\": {\n    \"local\": {\n      \"command\": \"./server\"\n    }\n  },\n  \"other\": true\n}\n"
+	os.WriteFile(c.Path, []byte(original), 0600)
+
+	if err := c.Write(testServers, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(c.Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Write() should keep the file's mode 0600 (%v)", err)
+	}
+	content, _ := os.ReadFile(c.Path)
+	expected := `{
+  "mcpServers": {
+    "local": {
+      "command": "./server"
+    },
+    "files": {
+      "command": "npx",
+      "args": [
+        "-y",
+        "@modelcontextprotocol/server-filesystem",
+        "."
+      ]
+    },
+    "github": {
+      "type": "http",
+      "url": "https://api.githubcopilot.com/mcp/",
+      "headers": {
+        "Authorization": "Bearer ${GITHUB_TOKEN}"
+      }
+    }
+  },
+  "other": true
+}
+`
+	if string(content) != expected {
+		t.Errorf("after Write():\n%s\nexpected:\n%s", content, expected)
+	}
+
+	servers, err := c.Servers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range testServers.Names() {
+		if string(servers[name]) != string(c.Entry(testServers[name])) {
+			t.Errorf("Servers()[%s] = %s, Entry() = %s", name, servers[name], c.Entry(testServers[name]))
+		}
+	}
+
+	if err := c.Write(nil, []string{"files", "github"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(c.Path); string(content) != original {
+		t.Errorf("removing the servers should restore the file, got:\n%s", content)
+	}
+
+	// A file that only held the servers is removed with them
+	os.Remove(c.Path)
+	c.Write(testServers, nil, false)
+	if err := c.Write(nil, testServers.Names(), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.Path); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", c.Path)
+	}
+}
+
+func TestMCPConfigEntries(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		tool     string
+		expected string
+	}{
+		{"copilot", `{"headers":{"Authorization":"Bearer ${GITHUB_TOKEN}"},"type":"http","url":"https://api.githubcopilot.com/mcp/"}`},
+		{"cursor", `{"headers":{"Authorization":"Bearer ${GITHUB_TOKEN}"},"url":"https://api.githubcopilot.com/mcp/"}`},
+		{"codex", `{"http_headers":{"Authorization":"Bearer ${GITHUB_TOKEN}"},"url":"https://api.githubcopilot.com/mcp/"}`},
+	}
+	t.Setenv("CODEX_HOME", root)
+	for _, tt := range tests {
+		c, err := MCPConfigFor(tt.tool, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry := string(c.Entry(testServers["github"])); entry != tt.expected {
+			t.Errorf("%s Entry() = %s, expected %s", tt.tool, entry, tt.expected)
+		}
+	}
+
+	if _, err := MCPConfigFor("windsurf", root); err == nil {
+		t.Errorf("expected an error for a tool without an MCP config")
+	}
+}
+
+func TestMCPConfigTOML(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CODEX_HOME", home)
+	c, err := MCPConfigFor("codex", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Path != filepath.Join(home, "config.toml") {
+		t.Errorf("Path = %s", c.Path)
+	}
+
+	original := "model = \"o3\"\n\n[mcp_servers.local]\ncommand = \"./server\"\n\n[profiles.fast]\nmodel = \"o4-mini\"\n"
+	os.WriteFile(c.Path, []byte(original), 0644)
+
+	if err := c.Write(testServers, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(c.Path)
+	expected := original + `
+[mcp_servers.files]
+command = "npx"
+args = ["-y", "@modelcontextprotocol/server-filesystem", "."]
+
+[mcp_servers.github]
+url = "https://api.githubcopilot.com/mcp/"
+http_headers = { Authorization = "Bearer ${GITHUB_TOKEN}" }
+`
+	if string(content) != expected {
+		t.Errorf("after Write():\n%s\nexpected:\n%s", content, expected)
+	}
+
+	servers, err := c.Servers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range testServers.Names() {
+		if string(servers[name]) != string(c.Entry(testServers[name])) {
+			t.Errorf("Servers()[%s] = %s, Entry() = %s", name, servers[name], c.Entry(testServers[name]))
+		}
+	}
+	if _, ok := servers["local"]; !ok {
+		t.Errorf("Servers() should list local")
+	}
+
+	// Rewriting a server replaces its table, subtables included
+	os.WriteFile(c.Path, []byte(string(content)+"\n[mcp_servers.files.env]\nDEBUG = \"1\"\n"), 0644)
+	changed := config.MCPServers{"files": {Command: "uvx", Args: []string{"server"}}}
+	if err := c.Write(changed, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	servers, _ = c.Servers()
+	if string(servers["files"]) != `{"args":["server"],"command":"uvx"}` {
+		t.Errorf("files = %s", servers["files"])
+	}
+
+	if err := c.Write(nil, []string{"files", "github"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(c.Path); string(content) != original {
+		t.Errorf("removing the servers should restore the file, got:\n%s", content)
+	}
+}
+
+func TestMCPConfigTOMLInline(t *testing.T) {
+	t.Setenv("CODEX_HOME", t.TempDir())
+	c, err := MCPConfigFor("codex", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		original string
+		expected string
+	}{
+		{
+			"inline under the table",
+			"[mcp_servers]\ngithub = { command = \"old\" } # mine\nlocal = { command = \"./server\" }\n\n[profiles.fast]\nmodel = \"o4-mini\"\n",
+			"[mcp_servers]\ngithub = { url = \"https://api.githubcopilot.com/mcp/\", http_headers = { Authorization = \"Bearer ${GITHUB_TOKEN}\" } }\nlocal = { command = \"./server\" }\n\n[profiles.fast]\nmodel = \"o4-mini\"\n\n[mcp_servers.files]\ncommand = \"npx\"\nargs = [\"-y\", \"@modelcontextprotocol/server-filesystem\", \".\"]\n",
+		},
+		{
+			"dotted keys",
+			"mcp_servers.github.command = \"old\"\nmcp_servers.github.args = [\n  \"a]\",\n]\nmodel = \"o3\"\n",
+			"mcp_servers.github = { url = \"https://api.githubcopilot.com/mcp/\", http_headers = { Authorization = \"Bearer ${GITHUB_TOKEN}\" } }\nmodel = \"o3\"\n\n[mcp_servers.files]\ncommand = \"npx\"\nargs = [\"-y\", \"@modelcontextprotocol/server-filesystem\", \".\"]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(c.Path, []byte(tt.original), 0644)
+			if err := c.Write(testServers, nil, false); err != nil {
+				t.Fatal(err)
+			}
+			content, _ := os.ReadFile(c.Path)
+			if string(content) != tt.expected {
+				t.Errorf("after Write():\n%s\nexpected:\n%s", content, tt.expected)
+			}
+
+			var values map[string]interface{}
+			if _, err := toml.Decode(string(content), &values); err != nil {
+				t.Fatalf("Write() left invalid TOML: %v\n%s", err, content)
+			}
+			servers, err := c.Servers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range testServers.Names() {
+				if string(servers[name]) != string(c.Entry(testServers[name])) {
+					t.Errorf("Servers()[%s] = %s, Entry() = %s", name, servers[name], c.Entry(testServers[name]))
+				}
+			}
+
+			if err := c.Write(nil, testServers.Names(), false); err != nil {
+				t.Fatal(err)
+			}
+			content, _ = os.ReadFile(c.Path)
+			if _, err := toml.Decode(string(content), &values); err != nil {
+				t.Fatalf("removing the servers left invalid TOML: %v\n%s", err, content)
+			}
+			if servers, _ := c.Servers(); servers["github"] != nil || servers["files"] != nil {
+				t.Errorf("removing the servers left:\n%s", content)
+			}
+		})
+	}
+
+	// Servers inside an inline table for the whole key can't be edited
+	os.WriteFile(c.Path, []byte("mcp_servers = { github = { command = \"old\" } }\n"), 0644)
+	if err := c.Write(testServers, nil, false); err == nil {
+		t.Errorf("expected an error for an inline mcp_servers table")
+	}
+}
@@ -0,0 +1,217 @@
+package toolconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tomlStatement is a table header or a key/value pair in a TOML document,
+// found by scanning its text so it can be edited in place
+type tomlStatement struct {
+	// Start and End are the offsets of the statement in the text, from the
+	// start of its first line to after the newline of its last
+	Start, End int
+	// Header is set for [table] and [[array]] headers
+	Header bool
+	// Path is the full key: the table's for a header, the table's followed
+	// by the key for a key/value pair
+	Path []string
+	// Table is how many elements of Path come from the enclosing table
+	Table int
+}
+
+// scanTOML returns the statements of a TOML document in order. Blank
+// lines and comments between them belong to no statement.
+func scanTOML(text string) ([]tomlStatement, error) {
+	var statements []tomlStatement
+	var table []string
+	pos := 0
+	for n := 1; pos < len(text); n++ {
+		end := lineEnd(text, pos)
+		line := strings.TrimSpace(text[pos:end])
+		if line == "" || strings.HasPrefix(line, "#") {
+			pos = end
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			keys := strings.TrimPrefix(strings.TrimPrefix(line, "["), "[")
+			path, _, err := tomlKeyPath(keys, ']')
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			table = path
+			statements = append(statements, tomlStatement{Start: pos, End: end, Header: true, Path: path, Table: len(path)})
+			pos = end
+			continue
+		}
+
+		offset := len(text[pos:end]) - len(strings.TrimLeft(text[pos:end], " \t"))
+		key, eq, err := tomlKeyPath(text[pos+offset:end], '=')
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		valueStart := pos + offset + eq + 1
+		valueEnd := valueStart + tomlValueLen(text[valueStart:])
+		n += strings.Count(text[pos:valueEnd], "\n") - 1
+		path := append(append([]string{}, table...), key...)
+		statements = append(statements, tomlStatement{Start: pos, End: valueEnd, Path: path, Table: len(table)})
+		pos = valueEnd
+	}
+	return statements, nil
+}
+
+// tomlKeyPath parses the dotted key at the start of text, up to the
+// terminator, and returns its parts and the offset of the terminator
+func tomlKeyPath(text string, terminator byte) ([]string, int, error) {
+	var path []string
+	i := 0
+	for {
+		for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+			i++
+		}
+		if i == len(text) {
+			return nil, 0, fmt.Errorf("expected %q after key", terminator)
+		}
+
+		switch text[i] {
+		case '"', '\'':
+			n := tomlStringLen(text[i+1:], text[i:i+1])
+			quoted := text[i : i+1+n]
+			if !strings.HasSuffix(quoted, text[i:i+1]) || len(quoted) < 2 {
+				return nil, 0, fmt.Errorf("unterminated quoted key")
+			}
+			part := quoted[1 : len(quoted)-1]
+			if text[i] == '"' {
+				unquoted, err := strconv.Unquote(quoted)
+				if err != nil {
+					return nil, 0, fmt.Errorf("invalid quoted key %s", quoted)
+				}
+				part = unquoted
+			}
+			path = append(path, part)
+			i += 1 + n
+		default:
+			start := i
+			for i < len(text) && tomlBareKey.MatchString(text[i:i+1]) {
+				i++
+			}
+			if i == start {
+				return nil, 0, fmt.Errorf("invalid key")
+			}
+			path = append(path, text[start:i])
+		}
+
+		for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+			i++
+		}
+		switch {
+		case i < len(text) && text[i] == '.':
+			i++
+		case i < len(text) && text[i] == terminator:
+			return path, i, nil
+		default:
+			return nil, 0, fmt.Errorf("expected %q after key", terminator)
+		}
+	}
+}
+
+// lineEnd returns where the line starting at pos ends, after its newline
+func lineEnd(text string, pos int) int {
+	if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+		return pos + i + 1
+	}
+	return len(text)
+}
+
+// tomlValueLen returns the length of the TOML value at the start of text,
+// through the end of the line it ends on, so a trailing comment is part of
+// it. Brackets in strings and comments are skipped, so the end of an array
+// spanning lines is found exactly.
+func tomlValueLen(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case strings.HasPrefix(text[i:], `"""`) || strings.HasPrefix(text[i:], `'''`):
+			i += 3 + tomlStringLen(text[i+3:], text[i:i+3]) - 1
+		case c == '"' || c == '\'':
+			i += 1 + tomlStringLen(text[i+1:], text[i:i+1]) - 1
+		case c == '#':
+			// The comment runs to the newline, which is looked at next
+			i = lineEnd(text, i) - 2
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == '\n' && depth <= 0:
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// tomlStringLen returns the length of the rest of a string opened with
+// quote, closing quote included. Basic strings, in double quotes, have
+// escapes, and a single-line string that isn't closed ends with its line.
+func tomlStringLen(text, quote string) int {
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote[0] == '"':
+			i++
+		case strings.HasPrefix(text[i:], quote):
+			return i + len(quote)
+		case text[i] == '\n' && len(quote) == 1:
+			return i
+		}
+	}
+	return len(text)
+}
+
+// tomlSpan is a part of a TOML document's text, from start to end
+type tomlSpan struct {
+	start, end int
+}
+
+// spliceTOML removes the spans, which must be in order, from text and
+// writes insert where the span starting at at was. A removal doesn't leave
+// two blank lines in a row, nor blank lines at the end.
+func spliceTOML(text string, spans []tomlSpan, at int, insert string) string {
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		b.WriteString(text[pos:span.start])
+		pos = span.end
+		if span.start == at {
+			b.WriteString(insert)
+			continue
+		}
+		out := b.String()
+		if next := text[pos:lineEnd(text, pos)]; next != "" && isBlank(next) && (out == "" || strings.HasSuffix(out, "\n\n")) {
+			pos += len(next)
+		}
+	}
+	b.WriteString(text[pos:])
+
+	out := b.String()
+	if last := len(spans) - 1; last >= 0 && spans[last].start != at && isBlank(text[spans[last].end:]) {
+		out = strings.TrimRight(out, "\n")
+		if out != "" {
+			out += "\n"
+		}
+	}
+	return out
+}
+
+// hasPrefix reports whether path starts with prefix
+func hasPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
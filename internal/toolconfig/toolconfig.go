@@ -0,0 +1,341 @@
+// Package toolconfig edits the settings files of AI tools so they read the
+// source under its own name, instead of through a link
+package toolconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/martinmose/agentlink/internal/config"
+	"github.com/martinmose/agentlink/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// Setting is the setting of a tool that names its instruction files
+type Setting struct {
+	// Tool is the tool's name in the registry
+	Tool string
+	// Path is the settings file
+	Path string
+	// Key is the setting, see tools.Tool.SettingsKey
+	Key string
+	// Global is set for a settings file every project shares, such as the
+	// Codex config
+	Global bool
+}
+
+// For returns the setting that makes tool read a file in place of the
+// instruction file at linkPath: the project or personal settings of Gemini
+// CLI, depending on where the link is, or the Codex config
+func For(tool, linkPath string) (Setting, error) {
+	t, ok := tools.Lookup(tool)
+	if !ok || t.SettingsKey == "" {
+		return Setting{}, fmt.Errorf("%s has no setting for its instruction file name", tool)
+	}
+	setting := Setting{Tool: tool, Key: t.SettingsKey}
+
+	switch tool {
+	case "gemini":
+		dir := filepath.Dir(linkPath)
+		if filepath.Base(dir) != ".gemini" {
+			dir = filepath.Join(dir, ".gemini")
+		}
+		setting.Path = filepath.Join(dir, "settings.json")
+	case "codex":
+		home, err := codexHome()
+		if err != nil {
+			return Setting{}, err
+		}
+		setting.Path = filepath.Join(home, "config.toml")
+		setting.Global = true
+	default:
+		return Setting{}, fmt.Errorf("agentlink cannot edit the settings of %s", tool)
+	}
+	return setting, nil
+}
+
+// String describes the setting as key in file
+func (s Setting) String() string {
+	return fmt.Sprintf("%s in %s", s.Key, s.Path)
+}
+
+// Names returns the file names the setting currently lists
+func (s Setting) Names() ([]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.Path, err)
+	}
+
+	if filepath.Ext(s.Path) == ".toml" {
+		var values map[string]interface{}
+		if _, err := toml.Decode(string(data), &values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", s.Path, err)
+		}
+		return stringList(values[s.Key]), nil
+	}
+
+	var names []string
+	_, err = config.EditJSON(data, func(object *yaml.Node) error {
+		if value := mappingValue(object, s.Key); value != nil {
+			names = nodeStrings(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	return names, nil
+}
+
+// Has reports whether the setting lists name
+func (s Setting) Has(name string) (bool, error) {
+	names, err := s.Names()
+	if err != nil {
+		return false, err
+	}
+	return contains(names, name), nil
+}
+
+// Add makes the setting list name, keeping the names and other settings
+// already there, and reports whether the file changed
+func (s Setting) Add(name string, dryRun bool) (bool, error) {
+	names, err := s.Names()
+	if err != nil || contains(names, name) {
+		return false, err
+	}
+	return true, s.write(append(names, name), dryRun)
+}
+
+// Remove takes name out of the setting, dropping the setting once it lists
+// nothing else, and reports whether the file changed
+func (s Setting) Remove(name string, dryRun bool) (bool, error) {
+	names, err := s.Names()
+	if err != nil || !contains(names, name) {
+		return false, err
+	}
+	var kept []string
+	for _, other := range names {
+		if other != name {
+			kept = append(kept, other)
+		}
+	}
+	return true, s.write(kept, dryRun)
+}
+
+// write replaces the names in the setting
+func (s Setting) write(names []string, dryRun bool) error {
+	data, err := os.ReadFile(s.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", s.Path, err)
+	}
+
+	if filepath.Ext(s.Path) == ".toml" {
+		data = setTOMLList(data, s.Key, names)
+	} else {
+		data, err = config.EditJSON(data, func(object *yaml.Node) error {
+			setJSONNames(object, s.Key, names)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to edit %s: %w", s.Path, err)
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+	return writeFile(s.Path, data)
+}
+
+// codexHome returns $CODEX_HOME, or ~/.codex
+func codexHome() (string, error) {
+	if home := os.Getenv("CODEX_HOME"); home != "" {
+		return home, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".codex"), nil
+}
+
+// writeFile replaces path with data through a temporary file, so a tool
+// never reads it half written
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	// Settings often hold secrets, keep the mode of the file replaced
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp := path + ".agentlink-tmp"
+	os.Remove(tmp)
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// setJSONNames sets key in object to names: a single name as a string, as
+// Gemini CLI writes it, several as an array, none by removing the key
+func setJSONNames(object *yaml.Node, key string, names []string) {
+	var value *yaml.Node
+	switch len(names) {
+	case 0:
+	case 1:
+		value = stringNode(names[0])
+	default:
+		value = &yaml.Node{Kind: yaml.SequenceNode}
+		for _, name := range names {
+			value.Content = append(value.Content, stringNode(name))
+		}
+	}
+
+	for i := 0; i+1 < len(object.Content); i += 2 {
+		if object.Content[i].Value != key {
+			continue
+		}
+		if value == nil {
+			object.Content = append(object.Content[:i], object.Content[i+2:]...)
+		} else {
+			object.Content[i+1] = value
+		}
+		return
+	}
+	if value != nil {
+		object.Content = append(object.Content, stringNode(key), value)
+	}
+}
+
+// tomlTable matches a table header, which ends the top-level keys
+var tomlTable = regexp.MustCompile(`^\s*\[`)
+
+// setTOMLList sets the top-level key in a TOML document to names, editing
+// the text so comments and formatting elsewhere are kept. No names removes
+// the key.
+func setTOMLList(data []byte, key string, names []string) []byte {
+	text := string(data)
+
+	replacement := ""
+	if len(names) > 0 {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = strconv.Quote(name)
+		}
+		replacement = fmt.Sprintf("%s = [%s]\n", key, strings.Join(quoted, ", "))
+	}
+
+	// Step through the top-level keys, whose values may span lines
+	keyLine := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(key) + `\s*=`)
+	pos := 0
+	for pos < len(text) {
+		line := text[pos:lineEnd(text, pos)]
+		if tomlTable.MatchString(line) {
+			break
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			pos += len(line)
+			continue
+		}
+		end := pos + eq + 1 + tomlValueLen(text[pos+eq+1:])
+		if !keyLine.MatchString(line) {
+			pos = end
+			continue
+		}
+
+		rest := text[end:]
+		// Don't leave two blank lines where the key was
+		if replacement == "" && (pos == 0 || strings.HasSuffix(text[:pos], "\n\n")) {
+			if next := text[end:lineEnd(text, end)]; next != "" && isBlank(next) {
+				rest = text[end+len(next):]
+			}
+		}
+		return []byte(text[:pos] + replacement + rest)
+	}
+
+	if replacement == "" {
+		return data
+	}
+	if pos < len(text) {
+		replacement += "\n"
+	} else if pos > 0 && !strings.HasSuffix(text, "\n") {
+		replacement = "\n" + replacement
+	}
+	return []byte(text[:pos] + replacement + text[pos:])
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// nodeStrings returns the string or strings in a scalar or sequence node
+func nodeStrings(node *yaml.Node) []string {
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+	var values []string
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode {
+			values = append(values, item.Value)
+		}
+	}
+	return values
+}
+
+// stringList returns the strings in a decoded TOML value
+func stringList(value interface{}) []string {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		values = append(values, v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
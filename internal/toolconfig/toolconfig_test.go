@@ -0,0 +1,163 @@
+package toolconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestGeminiSetting(t *testing.T) {
+	tmpDir := t.TempDir()
+	setting, err := For("gemini", filepath.Join(tmpDir, "GEMINI.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setting.Path != filepath.Join(tmpDir, ".gemini", "settings.json") {
+		t.Errorf("Path = %s", setting.Path)
+	}
+
+	os.MkdirAll(filepath.Dir(setting.Path), 0755)
+	original := "{\n  \"theme\": \"Dracula\",\n  \"contextFileName\": \"GEMINI.md\",\n  \"sandbox\": true\n}\n"
+	os.WriteFile(setting.Path, []byte(original), 0644)
+
+	if changed, err := setting.Add("AGENTS.md", false); err != nil || !changed {
+		t.Fatalf("Add() = %v, %v", changed, err)
+	}
+	content, _ := os.ReadFile(setting.Path)
+	expected := "{\n  \"theme\": \"Dracula\",\n  \"contextFileName\": [\n    \"GEMINI.md\",\n    \"AGENTS.md\"\n  ],\n  \"sandbox\": true\n}\n"
+	if string(content) != expected {
+		t.Errorf("after Add():\n%s\nexpected:\n%s", content, expected)
+	}
+	if has, err := setting.Has("AGENTS.md"); err != nil || !has {
+		t.Errorf("Has() = %v, %v", has, err)
+	}
+	if changed, _ := setting.Add("AGENTS.md", false); changed {
+		t.Errorf("Add() of a listed name should not change the file")
+	}
+
+	if changed, err := setting.Remove("AGENTS.md", false); err != nil || !changed {
+		t.Fatalf("Remove() = %v, %v", changed, err)
+	}
+	if content, _ := os.ReadFile(setting.Path); string(content) != original {
+		t.Errorf("Remove() should restore the file, got:\n%s", content)
+	}
+}
+
+func TestGeminiSettingComments(t *testing.T) {
+	tmpDir := t.TempDir()
+	setting, err := For("gemini", filepath.Join(tmpDir, "GEMINI.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Gemini CLI reads settings.json with comments
+	os.MkdirAll(filepath.Dir(setting.Path), 0755)
+	os.WriteFile(setting.Path, []byte("{\n  // Look\n  \"theme\": \"Dracula\", /* not \"a\" // comment */\n  \"url\": \"http://example.com\"\n}\n"), 0644)
+	if _, err := setting.Add("AGENTS.md", false); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(setting.Path)
+	expected := "{\n  \"theme\": \"Dracula\",\n  \"url\": \"http://example.com\",\n  \"contextFileName\": \"AGENTS.md\"\n}\n"
+	if string(content) != expected {
+		t.Errorf("after Add():\n%s\nexpected:\n%s", content, expected)
+	}
+}
+
+func TestGeminiPersonalSetting(t *testing.T) {
+	home := t.TempDir()
+	setting, err := For("gemini", filepath.Join(home, ".gemini", "GEMINI.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setting.Path != filepath.Join(home, ".gemini", "settings.json") {
+		t.Errorf("Path = %s", setting.Path)
+	}
+
+	// A missing settings file is created, and emptied again by Remove
+	if _, err := setting.Add("AGENTS.md", false); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(setting.Path); string(content) != "{\n  \"contextFileName\": \"AGENTS.md\"\n}\n" {
+		t.Errorf("unexpected settings %q", content)
+	}
+	if _, err := setting.Remove("AGENTS.md", false); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(setting.Path); string(content) != "{}\n" {
+		t.Errorf("unexpected settings %q", content)
+	}
+}
+
+func TestCodexSetting(t *testing.T) {
+	codexHome := t.TempDir()
+	t.Setenv("CODEX_HOME", codexHome)
+	setting, err := For("codex", "/project/AGENTS.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setting.Path != filepath.Join(codexHome, "config.toml") || !setting.Global {
+		t.Errorf("Path = %s, Global = %v", setting.Path, setting.Global)
+	}
+
+	original := "# My Codex config\nmodel = \"o3\"\n\n[mcp_servers.docs]\ncommand = \"docs-mcp\"\n"
+	os.WriteFile(setting.Path, []byte(original), 0600)
+
+	if _, err := setting.Add("RULES.md", false); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(setting.Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Add() should keep the file's mode 0600 (%v)", err)
+	}
+	expected := "# My Codex config\nmodel = \"o3\"\n\nproject_doc_fallback_filenames = [\"RULES.md\"]\n\n[mcp_servers.docs]\ncommand = \"docs-mcp\"\n"
+	if content, _ := os.ReadFile(setting.Path); string(content) != expected {
+		t.Errorf("after Add():\n%s\nexpected:\n%s", content, expected)
+	}
+
+	if _, err := setting.Add("CONTEXT.md", false); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := setting.Names(); len(names) != 2 || names[1] != "CONTEXT.md" {
+		t.Errorf("Names() = %v", names)
+	}
+
+	setting.Remove("CONTEXT.md", false)
+	setting.Remove("RULES.md", false)
+	if content, _ := os.ReadFile(setting.Path); string(content) != original {
+		t.Errorf("Remove() should restore the file, got:\n%s", content)
+	}
+}
+
+func TestCodexSettingMultiline(t *testing.T) {
+	codexHome := t.TempDir()
+	t.Setenv("CODEX_HOME", codexHome)
+	setting, err := For("codex", "/project/AGENTS.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Brackets in comments and strings don't end the array
+	os.WriteFile(setting.Path, []byte("project_doc_fallback_filenames = [\n  \"A.md\", # [x\n  \"B].md\",\n]\nmodel = \"o3\" # ]\n\n[tools]\nweb_search = true\n"), 0644)
+	if _, err := setting.Add("RULES.md", false); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(setting.Path)
+	expected := "project_doc_fallback_filenames = [\"A.md\", \"B].md\", \"RULES.md\"]\nmodel = \"o3\" # ]\n\n[tools]\nweb_search = true\n"
+	if string(content) != expected {
+		t.Errorf("after Add():\n%s\nexpected:\n%s", content, expected)
+	}
+	var values map[string]interface{}
+	if _, err := toml.Decode(string(content), &values); err != nil {
+		t.Errorf("Add() wrote invalid TOML: %v", err)
+	}
+	if values["model"] != "o3" {
+		t.Errorf("Add() lost model: %v", values)
+	}
+}
+
+func TestUnsupportedTool(t *testing.T) {
+	if _, err := For("claude", "CLAUDE.md"); err == nil {
+		t.Errorf("For() of a tool without a setting should fail")
+	}
+}
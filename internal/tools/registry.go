@@ -0,0 +1,329 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/martinmose/agentlink/internal/suggest"
+)
+
+// Tool describes where an AI tool looks for its instruction files
+type Tool struct {
+	// Name is the short identifier used in output, e.g. "claude"
+	Name string
+	// DisplayName is the human readable product name
+	DisplayName string
+	// ProjectFiles are instruction files the tool reads, relative to a project root
+	ProjectFiles []string
+	// HomeDir is the tool's config directory, relative to the home directory
+	HomeDir string
+	// GlobalFile is the personal instruction file, relative to the home directory
+	GlobalFile string
+	// SettingsKey is the setting that names the instruction files the tool
+	// reads, if it has one, so it can be pointed at the source by name
+	SettingsKey string
+	// AgentsDir and CommandsDir hold the tool's subagent and command
+	// definitions, relative to a project root
+	AgentsDir   string
+	CommandsDir string
+	// IgnoreFile lists the paths the tool leaves alone, in .gitignore
+	// syntax, relative to a project root
+	IgnoreFile string
+	// MCPFile is the project file listing the tool's MCP servers under
+	// MCPKey. Codex has MCPKey only, it reads them from its config.toml.
+	MCPFile string
+	MCPKey  string
+	// MaxBytes and MaxTokens are how much of its instruction file the tool
+	// reads, or reads well, where it has a known limit. Tokens are counted
+	// with tokens.Estimate.
+	MaxBytes  int
+	MaxTokens int
+	// ExpandsImports is set for tools that inline the files named by
+	// @imports, which count against their budget
+	ExpandsImports bool
+}
+
+// Kinds of definitions kept in a tool's AgentsDir and CommandsDir
+const (
+	KindAgent   = "agent"
+	KindCommand = "command"
+)
+
+// Registry lists the tools agentlink knows about, in order of preference
+var Registry = []Tool{
+	{
+		Name:         "claude",
+		DisplayName:  "Claude Code",
+		ProjectFiles: []string{"CLAUDE.md", ".claude/CLAUDE.md"},
+		HomeDir:      ".claude",
+		GlobalFile:   ".claude/CLAUDE.md",
+		AgentsDir:    ".claude/agents",
+		CommandsDir:  ".claude/commands",
+		MCPFile:      ".mcp.json",
+		MCPKey:       "mcpServers",
+		// Claude Code warns that a CLAUDE.md over 40k characters hurts
+		// performance
+		MaxBytes:       40000,
+		ExpandsImports: true,
+	},
+	{
+		Name:         "codex",
+		DisplayName:  "Codex CLI",
+		ProjectFiles: []string{"AGENTS.md"},
+		HomeDir:      ".codex",
+		GlobalFile:   ".codex/AGENTS.md",
+		SettingsKey:  "project_doc_fallback_filenames",
+		MCPKey:       "mcp_servers",
+		// project_doc_max_bytes, beyond which Codex truncates
+		MaxBytes: 32 * 1024,
+	},
+	{
+		Name:           "gemini",
+		DisplayName:    "Gemini CLI",
+		ProjectFiles:   []string{"GEMINI.md"},
+		HomeDir:        ".gemini",
+		GlobalFile:     ".gemini/GEMINI.md",
+		SettingsKey:    "contextFileName",
+		IgnoreFile:     ".geminiignore",
+		ExpandsImports: true,
+	},
+	{
+		Name:         "opencode",
+		DisplayName:  "OpenCode",
+		ProjectFiles: []string{"AGENTS.md"},
+		HomeDir:      ".config/opencode",
+		GlobalFile:   ".config/opencode/AGENTS.md",
+		AgentsDir:    ".opencode/agent",
+		CommandsDir:  ".opencode/command",
+	},
+	{
+		Name:         "copilot",
+		DisplayName:  "GitHub Copilot",
+		ProjectFiles: []string{".github/copilot-instructions.md"},
+		MCPFile:      ".vscode/mcp.json",
+		MCPKey:       "servers",
+	},
+	{
+		Name:         "cursor",
+		DisplayName:  "Cursor",
+		ProjectFiles: []string{".cursorrules"},
+		IgnoreFile:   ".cursorignore",
+		MCPFile:      ".cursor/mcp.json",
+		MCPKey:       "mcpServers",
+	},
+	{
+		Name:         "windsurf",
+		DisplayName:  "Windsurf",
+		ProjectFiles: []string{".windsurfrules"},
+		IgnoreFile:   ".codeiumignore",
+		// Windsurf reads up to 6000 characters of a rules file, bytes for
+		// ASCII text
+		MaxBytes: 6000,
+	},
+	{
+		Name:        "aider",
+		DisplayName: "Aider",
+		IgnoreFile:  ".aiderignore",
+	},
+}
+
+// Lookup returns the tool with the given name
+func Lookup(name string) (Tool, bool) {
+	for _, tool := range Registry {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// Names returns the names of the tools in the registry
+func Names() []string {
+	names := make([]string, len(Registry))
+	for i, tool := range Registry {
+		names[i] = tool.Name
+	}
+	return names
+}
+
+// Detection describes a tool found in the home directory
+type Detection struct {
+	Tool Tool
+	// Dir is the absolute path of the tool's config directory
+	Dir string
+	// File is the absolute path of the tool's personal instruction file
+	File string
+	// FileExists reports whether File exists (as a file or a symlink)
+	FileExists bool
+	// IsSymlink reports whether File is a symlink
+	IsSymlink bool
+	// Size is the size of File in bytes, if it is a regular file
+	Size int64
+}
+
+// DetectHome finds tools with a config directory in homeDir
+func DetectHome(homeDir string) []Detection {
+	var detections []Detection
+
+	for _, tool := range Registry {
+		if tool.HomeDir == "" || tool.GlobalFile == "" {
+			continue
+		}
+
+		dir := filepath.Join(homeDir, tool.HomeDir)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		detection := Detection{
+			Tool: tool,
+			Dir:  dir,
+			File: filepath.Join(homeDir, tool.GlobalFile),
+		}
+
+		if info, err := os.Lstat(detection.File); err == nil {
+			detection.FileExists = true
+			detection.IsSymlink = info.Mode()&os.ModeSymlink != 0
+			if info.Mode().IsRegular() {
+				detection.Size = info.Size()
+			}
+		}
+
+		detections = append(detections, detection)
+	}
+
+	return detections
+}
+
+// ProposeGlobal picks a personal source and its links from detected tools.
+// The largest existing regular instruction file becomes the source, since it
+// is most likely the one actually being edited. If no tool has one yet, the
+// first detected tool's file that isn't a symlink is proposed. Every other
+// tool's file becomes a link. Tools sharing a file path are only listed once.
+func ProposeGlobal(detections []Detection) (string, []string) {
+	if len(detections) == 0 {
+		return "", nil
+	}
+
+	candidates := make([]Detection, 0, len(detections))
+	source := ""
+	for _, d := range detections {
+		if d.IsSymlink {
+			continue
+		}
+		if source == "" {
+			source = d.File
+		}
+		if d.FileExists {
+			candidates = append(candidates, d)
+		}
+	}
+	if source == "" {
+		return "", nil
+	}
+
+	if len(candidates) > 0 {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].Size > candidates[j].Size
+		})
+		source = candidates[0].File
+	}
+
+	var links []string
+	seen := map[string]bool{source: true}
+	for _, d := range detections {
+		if seen[d.File] {
+			continue
+		}
+		seen[d.File] = true
+		links = append(links, d.File)
+	}
+
+	return source, links
+}
+
+// MatchPath returns the tools that read the instruction file at path (which
+// must be absolute). Paths inside root are matched against each tool's
+// project files, paths inside homeDir against its personal file.
+func MatchPath(path, root, homeDir string) []Tool {
+	projectRel := relSlash(root, path)
+	homeRel := relSlash(homeDir, path)
+
+	var matches []Tool
+	for _, tool := range Registry {
+		matched := homeRel != "" && homeRel == tool.GlobalFile
+		for _, file := range tool.ProjectFiles {
+			if projectRel != "" && projectRel == file {
+				matched = true
+			}
+		}
+		if matched {
+			matches = append(matches, tool)
+		}
+	}
+	return matches
+}
+
+// DefinitionDir returns the tool's directory for definitions of kind,
+// relative to a project root, or "" if it has none
+func (t Tool) DefinitionDir(kind string) string {
+	switch kind {
+	case KindAgent:
+		return t.AgentsDir
+	case KindCommand:
+		return t.CommandsDir
+	}
+	return ""
+}
+
+// MatchDefinitionDir returns the tool and kind of definitions kept in dir,
+// and the project root dir is in
+func MatchDefinitionDir(dir string) (tool Tool, kind, root string, ok bool) {
+	slashed := filepath.ToSlash(filepath.Clean(dir))
+	for _, t := range Registry {
+		for _, k := range []string{KindAgent, KindCommand} {
+			rel := t.DefinitionDir(k)
+			if rel == "" {
+				continue
+			}
+			if slashed == rel || strings.HasSuffix(slashed, "/"+rel) {
+				root := filepath.FromSlash(strings.TrimSuffix(strings.TrimSuffix(slashed, rel), "/"))
+				if root == "" {
+					root = "."
+				}
+				return t, k, root, true
+			}
+		}
+	}
+	return Tool{}, "", "", false
+}
+
+// SuggestFile returns the known instruction file name closest to the base
+// name of path, or "" if none is close enough to be a likely typo
+func SuggestFile(path string) string {
+	var names []string
+	for _, tool := range Registry {
+		if tool.GlobalFile != "" {
+			names = append(names, filepath.Base(tool.GlobalFile))
+		}
+		for _, file := range tool.ProjectFiles {
+			names = append(names, filepath.Base(file))
+		}
+	}
+	return suggest.Closest(filepath.Base(path), names, 2)
+}
+
+// relSlash returns path relative to base in slash form, or "" if path is
+// not inside base
+func relSlash(base, path string) string {
+	if base == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectHome(t *testing.T) {
+	home := t.TempDir()
+
+	os.MkdirAll(filepath.Join(home, ".claude"), 0755)
+	os.WriteFile(filepath.Join(home, ".claude", "CLAUDE.md"), []byte("personal"), 0644)
+	os.MkdirAll(filepath.Join(home, ".config", "opencode"), 0755)
+	// A file named like a tool directory must not count as a detection
+	os.WriteFile(filepath.Join(home, ".codex"), []byte("not a dir"), 0644)
+
+	detections := DetectHome(home)
+	if len(detections) != 2 {
+		t.Fatalf("Expected 2 detections, got %d", len(detections))
+	}
+
+	if detections[0].Tool.Name != "claude" || !detections[0].FileExists {
+		t.Errorf("Expected claude with existing file, got %+v", detections[0])
+	}
+	if detections[0].Size != int64(len("personal")) {
+		t.Errorf("Expected size %d, got %d", len("personal"), detections[0].Size)
+	}
+
+	if detections[1].Tool.Name != "opencode" || detections[1].FileExists {
+		t.Errorf("Expected opencode without file, got %+v", detections[1])
+	}
+	expectedFile := filepath.Join(home, ".config", "opencode", "AGENTS.md")
+	if detections[1].File != expectedFile {
+		t.Errorf("Expected file %s, got %s", expectedFile, detections[1].File)
+	}
+}
+
+func TestProposeGlobal(t *testing.T) {
+	claude := Detection{File: "/h/.claude/CLAUDE.md", FileExists: true, Size: 10}
+	codex := Detection{File: "/h/.codex/AGENTS.md", FileExists: true, Size: 200}
+	gemini := Detection{File: "/h/.gemini/GEMINI.md"}
+	symlinked := Detection{File: "/h/.config/opencode/AGENTS.md", FileExists: true, IsSymlink: true, Size: 0}
+
+	tests := []struct {
+		name           string
+		detections     []Detection
+		expectedSource string
+		expectedLinks  int
+	}{
+		{
+			name:           "largest existing file wins",
+			detections:     []Detection{claude, codex, gemini},
+			expectedSource: codex.File,
+			expectedLinks:  2,
+		},
+		{
+			name:           "symlinks are never the source",
+			detections:     []Detection{symlinked, gemini},
+			expectedSource: gemini.File,
+			expectedLinks:  1,
+		},
+		{
+			name:           "only symlinks proposes nothing",
+			detections:     []Detection{symlinked},
+			expectedSource: "",
+			expectedLinks:  0,
+		},
+		{
+			name:           "no existing files uses first tool",
+			detections:     []Detection{gemini, {File: "/h/.codex/AGENTS.md"}},
+			expectedSource: gemini.File,
+			expectedLinks:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, links := ProposeGlobal(tt.detections)
+			if source != tt.expectedSource {
+				t.Errorf("ProposeGlobal() source = %s, expected %s", source, tt.expectedSource)
+			}
+			if len(links) != tt.expectedLinks {
+				t.Errorf("ProposeGlobal() links = %v, expected %d", links, tt.expectedLinks)
+			}
+			for _, link := range links {
+				if link == source {
+					t.Errorf("Source %s also proposed as link", source)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	root := "/work/repo"
+	home := "/home/me"
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/work/repo/CLAUDE.md", []string{"claude"}},
+		{"/work/repo/AGENTS.md", []string{"codex", "opencode"}},
+		{"/work/repo/.github/copilot-instructions.md", []string{"copilot"}},
+		{"/work/repo/docs/CLAUDE.md", nil},
+		{"/home/me/.codex/AGENTS.md", []string{"codex"}},
+		{"/home/me/AGENTS.md", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			matches := MatchPath(tt.path, root, home)
+			if len(matches) != len(tt.expected) {
+				t.Fatalf("MatchPath() = %v, expected %v", matches, tt.expected)
+			}
+			for i, tool := range matches {
+				if tool.Name != tt.expected[i] {
+					t.Errorf("MatchPath()[%d] = %s, expected %s", i, tool.Name, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchDefinitionDir(t *testing.T) {
+	tests := []struct {
+		dir          string
+		expectedTool string
+		expectedKind string
+		expectedRoot string
+	}{
+		{"/work/repo/.claude/agents", "claude", KindAgent, "/work/repo"},
+		{"/work/repo/.claude/commands", "claude", KindCommand, "/work/repo"},
+		{"/work/repo/.opencode/agent/", "opencode", KindAgent, "/work/repo"},
+		{".claude/agents", "claude", KindAgent, "."},
+		{"/work/repo/agents", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			tool, kind, root, ok := MatchDefinitionDir(tt.dir)
+			if ok != (tt.expectedTool != "") {
+				t.Fatalf("MatchDefinitionDir() ok = %v", ok)
+			}
+			if tool.Name != tt.expectedTool || kind != tt.expectedKind || root != tt.expectedRoot {
+				t.Errorf("MatchDefinitionDir() = %s, %s, %s, expected %s, %s, %s", tool.Name, kind, root, tt.expectedTool, tt.expectedKind, tt.expectedRoot)
+			}
+		})
+	}
+}
+
+func TestSuggestFile(t *testing.T) {
+	tests := map[string]string{
+		"AGENT.md":                 "AGENTS.md",
+		"claude.md":                "CLAUDE.md",
+		"copilot-instruction.md":   "copilot-instructions.md",
+		"completely-different.txt": "",
+	}
+
+	for path, expected := range tests {
+		if got := SuggestFile(path); got != expected {
+			t.Errorf("SuggestFile(%s) = %q, expected %q", path, got, expected)
+		}
+	}
+}